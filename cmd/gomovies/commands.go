@@ -0,0 +1,579 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/catalog"
+	"github.com/andrew00x/gomovies/pkg/client"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/service"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+// serverProbeTimeout is time to wait for connection to server running on this host.
+const serverProbeTimeout = time.Second
+
+// commandContext holds options shared by all commands. Commands work with local catalog files unless server is set,
+// then they call API of running server. Player lives in server process, so player commands always need server.
+// Configuration is loaded on first use unless conf is set and local catalog is created with catalogFactory if it is set,
+// e.g. by tests.
+type commandContext struct {
+	server         string
+	output         string
+	pin            string
+	out            io.Writer
+	conf           *config.Config
+	catalogFactory func(conf *config.Config) (*service.CatalogService, error)
+}
+
+var commands = map[string]func(ctx *commandContext, args []string) error{
+	"config":  configCommand,
+	"export":  exportCommand,
+	"import":  importCommand,
+	"list":    listCommand,
+	"match":   matchCommand,
+	"play":    playCommand,
+	"queue":   queueCommand,
+	"scan":    scanCommand,
+	"search":  searchCommand,
+	"torrent": torrentCommand,
+}
+
+// runCommand runs subcommand instead of starting server, e.g. "gomovies export -format csv -o movies.csv".
+func runCommand(ctx *commandContext, args []string) error {
+	if ctx.output != tableOutput && ctx.output != jsonOutput {
+		return fmt.Errorf("unsupported output: %s, table or json expected", ctx.output)
+	}
+	cmd, found := commands[args[0]]
+	if !found {
+		var names []string
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command: %s, available commands: serve, %s", args[0], strings.Join(names, ", "))
+	}
+	return cmd(ctx, args[1:])
+}
+
+func (ctx *commandContext) config() (*config.Config, error) {
+	if ctx.conf == nil {
+		conf, err := config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		ctx.conf = conf
+	}
+	return ctx.conf, nil
+}
+
+func (ctx *commandContext) remote() bool {
+	return ctx.server != ""
+}
+
+// useServer makes commands talk to server running on this host unless other server is set.
+func (ctx *commandContext) useServer() error {
+	if ctx.remote() {
+		return nil
+	}
+	conf, err := ctx.config()
+	if err != nil {
+		return err
+	}
+	ctx.server = fmt.Sprintf("http://localhost:%d", conf.WebPort)
+	return nil
+}
+
+func (ctx *commandContext) catalog() (*service.CatalogService, error) {
+	conf, err := ctx.config()
+	if err != nil {
+		return nil, err
+	}
+	if ctx.catalogFactory != nil {
+		return ctx.catalogFactory(conf)
+	}
+	return service.CreateCatalogService(conf)
+}
+
+// catalogForUpdate returns local catalog for commands which change it. Server keeps catalog in memory and overwrites
+// catalog file with its own copy, so catalog must be changed through server while server is running on this host.
+func (ctx *commandContext) catalogForUpdate() (*service.CatalogService, error) {
+	conf, err := ctx.config()
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort("localhost", strconv.Itoa(conf.WebPort))
+	if conn, err := net.DialTimeout("tcp", addr, serverProbeTimeout); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("server is running at %s, its catalog would overwrite changes, use -server http://%s", addr, addr)
+	}
+	return ctx.catalog()
+}
+
+// client returns client of server, it must be used only if server is set.
+func (ctx *commandContext) client() *client.Client {
+	return client.CreateClient(ctx.server, nil)
+}
+
+// print writes result as indented JSON or as table with the given header and rows.
+func (ctx *commandContext) print(result interface{}, header []string, rows [][]string) error {
+	if ctx.output == jsonOutput {
+		encoder := json.NewEncoder(ctx.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	tw := tabwriter.NewWriter(ctx.out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, strings.Join(header, "\t")); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func (ctx *commandContext) message(msg string) (err error) {
+	if ctx.output == jsonOutput {
+		return ctx.print(api.MessagePayload{Message: msg}, nil, nil)
+	}
+	_, err = fmt.Fprintln(ctx.out, msg)
+	return
+}
+
+func (ctx *commandContext) printMovies(movies []api.Movie) error {
+	if movies == nil {
+		movies = []api.Movie{}
+	}
+	var rows [][]string
+	for _, m := range movies {
+		tmdbId := ""
+		if m.TMDbId != 0 {
+			tmdbId = strconv.Itoa(m.TMDbId)
+		}
+		rows = append(rows, []string{strconv.Itoa(m.Id), m.Title, m.DriveName, strconv.FormatBool(m.Available), tmdbId, m.File})
+	}
+	return ctx.print(movies, []string{"ID", "TITLE", "DRIVE", "AVAILABLE", "TMDB ID", "FILE"}, rows)
+}
+
+func (ctx *commandContext) printQueue(queue api.Queue) error {
+	if queue.Items == nil {
+		queue.Items = []api.MoviePath{}
+	}
+	var rows [][]string
+	for i, item := range queue.Items {
+		current := ""
+		if i == queue.Current {
+			current = "*"
+		}
+		rows = append(rows, []string{strconv.Itoa(i), current, item.File})
+	}
+	return ctx.print(queue, []string{"POSITION", "CURRENT", "FILE"}, rows)
+}
+
+func usageError(usage string) error {
+	return fmt.Errorf("usage: gomovies %s", usage)
+}
+
+func scanCommand(ctx *commandContext, args []string) (err error) {
+	if len(args) != 0 {
+		return usageError("scan")
+	}
+	var movies []api.Movie
+	if ctx.remote() {
+		c := ctx.client()
+		if err = c.Refresh(context.Background()); err != nil {
+			return
+		}
+		movies, err = c.Movies(context.Background(), client.MovieQuery{})
+	} else {
+		// catalog scans directories when it is created
+		var srv *service.CatalogService
+		if srv, err = ctx.catalogForUpdate(); err != nil {
+			return
+		}
+		if err = srv.Save(); err != nil {
+			return
+		}
+		movies = srv.All()
+	}
+	if err != nil {
+		return
+	}
+	return ctx.printMovies(movies)
+}
+
+func listCommand(ctx *commandContext, args []string) (err error) {
+	if len(args) != 0 {
+		return usageError("list")
+	}
+	var movies []api.Movie
+	if ctx.remote() {
+		movies, err = ctx.client().Movies(context.Background(), client.MovieQuery{})
+	} else {
+		var srv *service.CatalogService
+		if srv, err = ctx.catalog(); err == nil {
+			movies = srv.All()
+		}
+	}
+	if err != nil {
+		return
+	}
+	return ctx.printMovies(movies)
+}
+
+func searchCommand(ctx *commandContext, args []string) (err error) {
+	query := strings.Join(args, " ")
+	if query == "" {
+		return usageError("search <title, tag or person>")
+	}
+	var movies []api.Movie
+	if ctx.remote() {
+		movies, err = ctx.client().Search(context.Background(), query, client.MovieQuery{})
+	} else {
+		var srv *service.CatalogService
+		if srv, err = ctx.catalog(); err == nil {
+			movies = srv.Find(query)
+		}
+	}
+	if err != nil {
+		return
+	}
+	return ctx.printMovies(movies)
+}
+
+// matchCommand searches TMDb for movie with the given title or links movie in catalog with TMDb movie.
+func matchCommand(ctx *commandContext, args []string) (err error) {
+	flags := flag.NewFlagSet("match", flag.ContinueOnError)
+	lang := flags.String("lang", "en", "language of details")
+	id := flags.Int("id", 0, "id of movie in catalog to link with TMDb movie")
+	tmdbId := flags.Int("tmdb", 0, "id of TMDb movie")
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	if *id != 0 || *tmdbId != 0 {
+		if *id == 0 || *tmdbId == 0 || flags.NArg() != 0 {
+			return usageError("match -id <movie id> -tmdb <TMDb id>")
+		}
+		return linkMovie(ctx, *id, *tmdbId)
+	}
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		return usageError("match [-lang <lang>] <title>")
+	}
+	var found []api.MovieDetails
+	if ctx.remote() {
+		found, err = ctx.client().SearchDetails(context.Background(), query, *lang)
+	} else {
+		var conf *config.Config
+		var srv *service.DetailsService
+		if conf, err = ctx.config(); err != nil {
+			return
+		}
+		if srv, err = service.CreateDetailsService(conf); err != nil {
+			return
+		}
+		found, err = srv.SearchDetails(query, *lang)
+	}
+	if err != nil {
+		return
+	}
+	if found == nil {
+		found = []api.MovieDetails{}
+	}
+	var rows [][]string
+	for _, d := range found {
+		rows = append(rows, []string{strconv.Itoa(d.TMDbId), d.Title, d.OriginalTitle, d.ReleaseDate})
+	}
+	return ctx.print(found, []string{"TMDB ID", "TITLE", "ORIGINAL TITLE", "RELEASE DATE"}, rows)
+}
+
+func linkMovie(ctx *commandContext, id, tmdbId int) (err error) {
+	var movie api.Movie
+	if ctx.remote() {
+		if movie, err = remoteMovie(ctx, id); err != nil {
+			return
+		}
+		movie.TMDbId = tmdbId
+		movie, err = ctx.client().Update(context.Background(), movie, ctx.pin)
+	} else {
+		var srv *service.CatalogService
+		if srv, err = ctx.catalogForUpdate(); err != nil {
+			return
+		}
+		found := false
+		if movie, found = srv.Get(id); !found {
+			return fmt.Errorf("unknown movie, id: %d", id)
+		}
+		movie.TMDbId = tmdbId
+		if movie, err = srv.Update(movie); err == nil {
+			err = srv.Save()
+		}
+	}
+	if err != nil {
+		return
+	}
+	return ctx.printMovies([]api.Movie{movie})
+}
+
+func remoteMovie(ctx *commandContext, id int) (movie api.Movie, err error) {
+	var movies []api.Movie
+	if movies, err = ctx.client().Movies(context.Background(), client.MovieQuery{}); err != nil {
+		return
+	}
+	for _, m := range movies {
+		if m.Id == id {
+			movie = m
+			return
+		}
+	}
+	err = fmt.Errorf("unknown movie, id: %d", id)
+	return
+}
+
+// movieFile resolves argument of player commands which is either path to file or id of movie in catalog.
+func movieFile(ctx *commandContext, arg string) (string, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return arg, nil
+	}
+	m, err := remoteMovie(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return m.File, nil
+}
+
+func playCommand(ctx *commandContext, args []string) (err error) {
+	if len(args) != 1 {
+		return usageError("play <file or movie id>")
+	}
+	if err = ctx.useServer(); err != nil {
+		return
+	}
+	var playback api.Playback
+	if playback.File, err = movieFile(ctx, args[0]); err != nil {
+		return
+	}
+	var status api.PlayerStatus
+	if status, err = ctx.client().PlayMovie(context.Background(), playback); err != nil {
+		return
+	}
+	return ctx.print(status, []string{"FILE", "DURATION", "POSITION"},
+		[][]string{{status.File, strconv.Itoa(status.Duration), strconv.Itoa(status.Position)}})
+}
+
+func queueCommand(ctx *commandContext, args []string) (err error) {
+	if err = ctx.useServer(); err != nil {
+		return
+	}
+	switch {
+	case len(args) == 0:
+	case args[0] == "add" && len(args) > 1:
+		var files []string
+		for _, arg := range args[1:] {
+			var f string
+			if f, err = movieFile(ctx, arg); err != nil {
+				return
+			}
+			files = append(files, f)
+		}
+		_, err = ctx.client().Enqueue(context.Background(), files)
+	case args[0] == "clear" && len(args) == 1:
+		err = ctx.client().ClearQueue(context.Background())
+	default:
+		return usageError("queue [add <file or movie id>... | clear]")
+	}
+	if err != nil {
+		return
+	}
+	var queue api.Queue
+	if queue, err = ctx.client().Queue(context.Background()); err != nil {
+		return
+	}
+	return ctx.printQueue(queue)
+}
+
+func torrentCommand(ctx *commandContext, args []string) (err error) {
+	const usage = "torrent add <file> | torrent list"
+	if len(args) == 0 {
+		return usageError(usage)
+	}
+	var srv *service.TorrentService
+	if !ctx.remote() {
+		var conf *config.Config
+		if conf, err = ctx.config(); err != nil {
+			return
+		}
+		if conf.TorrentRemoteCtrlAddr == "" {
+			return errors.New("torrent client is not configured, set torrent_remote_ctrl_addr")
+		}
+		srv = service.CreateTorrentService(conf)
+	}
+	switch {
+	case args[0] == "add" && len(args) == 2:
+		var content []byte
+		if content, err = ioutil.ReadFile(args[1]); err != nil {
+			return
+		}
+		if srv != nil {
+			err = srv.AddFile(content)
+		} else {
+			err = ctx.client().AddTorrent(context.Background(), content)
+		}
+		if err != nil {
+			return
+		}
+		return ctx.message(fmt.Sprintf("Torrent %s added", filepath.Base(args[1])))
+	case args[0] == "list" && len(args) == 1:
+		var downloads []api.TorrentDownload
+		if srv != nil {
+			downloads, err = srv.Torrents()
+		} else {
+			downloads, err = ctx.client().Torrents(context.Background())
+		}
+		if err != nil {
+			return
+		}
+		return printDownloads(ctx, downloads)
+	}
+	return usageError(usage)
+}
+
+func printDownloads(ctx *commandContext, downloads []api.TorrentDownload) error {
+	if downloads == nil {
+		downloads = []api.TorrentDownload{}
+	}
+	var rows [][]string
+	for _, d := range downloads {
+		var done int64
+		if d.Size > 0 {
+			done = d.CompletedSize * 100 / d.Size
+		}
+		state := "downloading"
+		switch {
+		case d.Hashing:
+			state = "hashing"
+		case d.Completed:
+			state = "completed"
+		case d.Stopped:
+			state = "stopped"
+		}
+		rows = append(rows, []string{d.Name, strconv.FormatInt(d.Size, 10), fmt.Sprintf("%d%%", done), state})
+	}
+	return ctx.print(downloads, []string{"NAME", "SIZE", "DONE", "STATE"}, rows)
+}
+
+// configCommand validates configuration file, configuration of server is not available remotely so it is always local.
+func configCommand(ctx *commandContext, args []string) (err error) {
+	if len(args) == 0 || len(args) > 2 || args[0] != "validate" {
+		return usageError("config validate [file]")
+	}
+	path := config.ConfFile()
+	if len(args) == 2 {
+		path = args[1]
+	}
+	var conf *config.Config
+	if conf, err = config.LoadConfigFile(path); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, dir := range conf.Dirs {
+		// directory may be on drive which is not plugged in at the moment
+		if _, e := os.Stat(dir); e != nil {
+			log.WithFields(log.Fields{"dir": dir, "err": e}).Warn("Movies' directory is not available")
+		}
+	}
+	return ctx.message(fmt.Sprintf("Configuration %s is valid", path))
+}
+
+func exportCommand(ctx *commandContext, args []string) (err error) {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", catalog.JsonFormat, "format of export, json or csv")
+	output := flags.String("o", "", "output file, standard output by default")
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	var srv *service.CatalogService
+	if !ctx.remote() {
+		if srv, err = ctx.catalog(); err != nil {
+			return
+		}
+	}
+	w := ctx.out
+	if *output != "" {
+		var f *os.File
+		if f, err = os.Create(*output); err != nil {
+			return
+		}
+		defer func() {
+			if clsErr := f.Close(); clsErr != nil && err == nil {
+				err = clsErr
+			}
+		}()
+		w = f
+	}
+	if srv != nil {
+		return srv.Export(w, *format)
+	}
+	return ctx.client().Export(context.Background(), w, *format)
+}
+
+func importCommand(ctx *commandContext, args []string) (err error) {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", catalog.JsonFormat, "format of import, json or csv")
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	if flags.NArg() != 1 {
+		return usageError("import [-format json|csv] <file>")
+	}
+	var r io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		var f *os.File
+		if f, err = os.Open(name); err != nil {
+			return
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	var result api.ImportResult
+	if ctx.remote() {
+		result, err = ctx.client().Import(context.Background(), r, *format, ctx.pin)
+	} else {
+		var srv *service.CatalogService
+		if srv, err = ctx.catalogForUpdate(); err != nil {
+			return
+		}
+		if result, err = srv.Import(r, *format); err != nil {
+			return
+		}
+		err = srv.Save()
+	}
+	if err != nil {
+		return
+	}
+	return ctx.print(result, []string{"MATCHED", "BY CONTENT", "UNMATCHED", "REJECTED"},
+		[][]string{{strconv.Itoa(result.Matched), strconv.Itoa(result.ByContent), strconv.Itoa(len(result.Unmatched)), strconv.Itoa(len(result.Rejected))}})
+}
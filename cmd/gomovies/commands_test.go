@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/server/servertest"
+	"github.com/andrew00x/gomovies/pkg/service"
+)
+
+var movies = []api.Movie{
+	{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv", Available: true},
+	{Id: 2, File: "/movies/up.mkv", Title: "up.mkv", Available: true},
+}
+
+// remoteContext runs commands against API of server.
+func remoteContext(s *servertest.Server, output string) (*commandContext, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &commandContext{server: s.URL, output: output, out: &out}, &out
+}
+
+// localContext runs commands against catalog, server is expected on port which nobody listens.
+func localContext(t *testing.T, ctl *servertest.Catalog, output string) (*commandContext, *bytes.Buffer) {
+	var out bytes.Buffer
+	conf := &config.Config{WebPort: freePort(t), DetailsLangs: []string{"en"}}
+	return &commandContext{output: output, out: &out, conf: conf, catalogFactory: func(conf *config.Config) (*service.CatalogService, error) {
+		return service.CreateCatalogServiceWith(ctl, conf), nil
+	}}, &out
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestListMoviesOfServer(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	ctx, out := remoteContext(s, tableOutput)
+
+	err := runCommand(ctx, []string{"list"})
+
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+	assert.Contains(t, lines[1], "brave.mkv")
+	assert.Contains(t, lines[2], "/movies/up.mkv")
+}
+
+func TestListMoviesOfLocalCatalogAsJson(t *testing.T) {
+	ctx, out := localContext(t, servertest.NewCatalog(movies...), jsonOutput)
+
+	err := runCommand(ctx, []string{"list"})
+
+	assert.Nil(t, err)
+	var listed []api.Movie
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &listed))
+	assert.Equal(t, movies, listed)
+}
+
+func TestSearchMovies(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	remote, remoteOut := remoteContext(s, jsonOutput)
+	local, localOut := localContext(t, servertest.NewCatalog(movies...), jsonOutput)
+
+	assert.Nil(t, runCommand(remote, []string{"search", "up"}))
+	assert.Nil(t, runCommand(local, []string{"search", "up"}))
+
+	for _, out := range []*bytes.Buffer{remoteOut, localOut} {
+		var found []api.Movie
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &found))
+		assert.Equal(t, []api.Movie{movies[1]}, found)
+	}
+}
+
+func TestLinkMovieWithTmdb(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	remote, _ := remoteContext(s, tableOutput)
+	ctl := servertest.NewCatalog(movies...)
+	local, _ := localContext(t, ctl, tableOutput)
+
+	assert.Nil(t, runCommand(remote, []string{"match", "-id", "2", "-tmdb", "14160"}))
+	assert.Nil(t, runCommand(local, []string{"match", "-id", "2", "-tmdb", "14160"}))
+
+	for _, c := range []*servertest.Catalog{s.Catalog, ctl} {
+		m, _ := c.Get(2)
+		assert.Equal(t, 14160, m.TMDbId)
+	}
+	assert.EqualError(t, runCommand(local, []string{"match", "-id", "7", "-tmdb", "14160"}), "unknown movie, id: 7")
+	assert.EqualError(t, runCommand(local, []string{"match", "-id", "2"}), "usage: gomovies match -id <movie id> -tmdb <TMDb id>")
+}
+
+func TestLocalCatalogIsNotChangedWhileServerIsRunning(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	ctl := servertest.NewCatalog(movies...)
+	ctx, _ := localContext(t, ctl, tableOutput)
+	ctx.conf.WebPort = l.Addr().(*net.TCPAddr).Port
+
+	err = runCommand(ctx, []string{"match", "-id", "2", "-tmdb", "14160"})
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "server is running")
+	m, _ := ctl.Get(2)
+	assert.Equal(t, 0, m.TMDbId)
+	assert.NotNil(t, runCommand(ctx, []string{"scan"}))
+	assert.Nil(t, runCommand(ctx, []string{"list"}))
+}
+
+func TestExportAndImportCatalog(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "commands")
+	assert.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	exported := filepath.Join(dir, "movies.csv")
+	remote, remoteOut := remoteContext(s, jsonOutput)
+	local, localOut := localContext(t, servertest.NewCatalog(movies...), jsonOutput)
+
+	assert.Nil(t, runCommand(remote, []string{"export", "-format", "csv", "-o", exported}))
+	content, err := ioutil.ReadFile(exported)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "/movies/brave.mkv")
+	assert.Nil(t, runCommand(remote, []string{"import", "-format", "csv", exported}))
+	assert.Nil(t, runCommand(local, []string{"import", "-format", "csv", exported}))
+
+	for _, out := range []*bytes.Buffer{remoteOut, localOut} {
+		var result api.ImportResult
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &result))
+		assert.Equal(t, 2, result.Matched)
+		assert.Empty(t, result.Unmatched)
+	}
+}
+
+func TestImportResultAsTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commands")
+	assert.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	imported := filepath.Join(dir, "movies.json")
+	assert.Nil(t, ioutil.WriteFile(imported, []byte(`{"version":1,"movies":[{"file":"/movies/up.mkv"},{"file":"/movies/cars.mkv"}]}`), 0644))
+	ctx, out := localContext(t, servertest.NewCatalog(movies...), tableOutput)
+
+	err = runCommand(ctx, []string{"import", imported})
+
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, []string{"MATCHED", "BY", "CONTENT", "UNMATCHED", "REJECTED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"1", "0", "1", "0"}, strings.Fields(lines[1]))
+}
+
+func TestPlayAndEnqueueMoviesById(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	ctx, out := remoteContext(s, jsonOutput)
+
+	assert.Nil(t, runCommand(ctx, []string{"play", "1"}))
+	assert.Contains(t, s.Player.Actions(), "play /movies/brave.mkv")
+
+	out.Reset()
+	assert.Nil(t, runCommand(ctx, []string{"queue", "add", "2"}))
+	var queue api.Queue
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &queue))
+	assert.Equal(t, []api.MoviePath{{File: "/movies/brave.mkv"}, {File: "/movies/up.mkv"}}, queue.Items)
+	assert.Equal(t, 0, queue.Current)
+
+	out.Reset()
+	assert.Nil(t, runCommand(ctx, []string{"queue", "clear"}))
+	queue = api.Queue{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &queue))
+	assert.Empty(t, queue.Items)
+}
+
+func TestValidateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commands")
+	assert.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "config.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"dirs": ["`+dir+`"], "web_port": 8000}`), 0644))
+	var out bytes.Buffer
+	ctx := &commandContext{output: tableOutput, out: &out}
+
+	err = runCommand(ctx, []string{"config", "validate", path})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Configuration "+path+" is valid\n", out.String())
+}
+
+func TestUnknownCommandAndOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	err := runCommand(&commandContext{output: tableOutput, out: &out}, []string{"rename"})
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "unknown command: rename, available commands: serve, config, export"))
+	err = runCommand(&commandContext{output: "xml", out: &out}, []string{"list"})
+	assert.EqualError(t, err, "unsupported output: xml, table or json expected")
+}
@@ -6,7 +6,7 @@ require (
 	github.com/andrew00x/omxcontrol v0.1.0
 	github.com/andrew00x/xmlrpc v0.1.0
 	github.com/go-xmlfmt/xmlfmt v0.0.0-20161217153300-0315779074c2 // indirect
-	github.com/godbus/dbus v4.1.0+incompatible // indirect
+	github.com/godbus/dbus v4.1.0+incompatible
 	github.com/mpl/scgiclient v0.0.0-20171022154509-88aedc8df75e // indirect
 	github.com/sirupsen/logrus v1.4.2
 	github.com/stretchr/testify v1.4.0
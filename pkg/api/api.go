@@ -1,23 +1,38 @@
 package api
 
+import "time"
+
 type Movie struct {
-	Available        bool          `json:"available"`
-	DriveName        string        `json:"drive"`
-	Id               int           `json:"id"`
-	File             string        `json:"file"`
-	Title            string        `json:"title"`
-	TMDbId           int           `json:"tmdb_id,omitempty"`
-	DetailsAvailable bool          `json:"detailsAvailable"`
-	Details          *MovieDetails `json:"details,omitempty"`
+	Available        bool           `json:"available"`
+	DriveName        string         `json:"drive"`
+	Host             string         `json:"host,omitempty"`
+	Id               int            `json:"id"`
+	File             string         `json:"file"`
+	ContentId        string         `json:"content_id,omitempty"`
+	Title            string         `json:"title"`
+	TMDbId           int            `json:"tmdb_id,omitempty"`
+	PlayerOptions    *PlayerOptions `json:"player_options,omitempty"`
+	Overrides        *Overrides     `json:"overrides,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+	Collections      []string       `json:"collections,omitempty"`
+	DetailsAvailable bool           `json:"detailsAvailable"`
+	Details          *MovieDetails  `json:"details,omitempty"`
 }
 
 type MovieDetails struct {
+	BackdropUrl    string   `json:"backdropUrl,omitempty"`
 	Budget         int64    `json:"budget"`
+	Cast           []Actor  `json:"cast,omitempty"`
+	Certification  string   `json:"certification,omitempty"`
+	CollectionId   int      `json:"collectionId,omitempty"`
+	CollectionName string   `json:"collectionName,omitempty"`
 	Companies      []string `json:"companies,omitempty"`
 	Countries      []string `json:"countries,omitempty"`
+	Directors      []Crew   `json:"directors,omitempty"`
 	Genres         []string `json:"genres,omitempty"`
 	ImdbId         string   `json:"imdbId"`
 	OriginalTitle  string   `json:"originalTitle"`
+	Notes          string   `json:"notes,omitempty"`
 	Overview       string   `json:"overview"`
 	PosterSmallUrl string   `json:"posterSmallUrl"`
 	PosterLargeUrl string   `json:"posterLargeUrl"`
@@ -25,17 +40,124 @@ type MovieDetails struct {
 	Revenue        int64    `json:"revenue"`
 	Runtime        int      `json:"runtime"`
 	TagLine        string   `json:"tagline"`
+	Tags           []string `json:"tags,omitempty"`
 	Title          string   `json:"title"`
 	TMDbId         int      `json:"tmdbId"`
+	Trailers       []string `json:"trailers,omitempty"`
+	VoteAverage    float64  `json:"voteAverage,omitempty"`
+	VoteCount      int      `json:"voteCount,omitempty"`
+	Writers        []Crew   `json:"writers,omitempty"`
+}
+
+// Overrides are details of movie edited by user. They take precedence over details found by metadata providers and
+// are kept in catalog, so they survive rescans and refreshes of details.
+type Overrides struct {
+	Title     string   `json:"title,omitempty"`
+	Year      int      `json:"year,omitempty"`
+	Overview  string   `json:"overview,omitempty"`
+	Genres    []string `json:"genres,omitempty"`
+	PosterUrl string   `json:"posterUrl,omitempty"`
+	Notes     string   `json:"notes,omitempty"`
+}
+
+// Label is user tag or collection together with number of movies in it.
+type Label struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Franchise is TMDb collection of movies, e.g. trilogy, with its parts ordered by release date. Parts which are not in
+// catalog have no movie id.
+type Franchise struct {
+	Id        int             `json:"id"`
+	Name      string          `json:"name"`
+	Overview  string          `json:"overview,omitempty"`
+	PosterUrl string          `json:"posterUrl,omitempty"`
+	Parts     []FranchisePart `json:"parts"`
+	Missing   int             `json:"missing"`
+}
+
+type FranchisePart struct {
+	MovieId     int    `json:"movieId,omitempty"`
+	File        string `json:"file,omitempty"`
+	TMDbId      int    `json:"tmdbId"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate"`
+	PosterUrl   string `json:"posterUrl,omitempty"`
+}
+
+// DuplicatesReport lists copies of the same movie, e.g. on different drives or in different quality, and how much space
+// may be freed by removing copies which are not preferred.
+type DuplicatesReport struct {
+	Groups      []DuplicateGroup `json:"groups"`
+	Reclaimable int64            `json:"reclaimable"`
+}
+
+// DuplicateGroup is set of copies of the same movie, Reasons tell how copies were matched: "tmdb", "title" or "content".
+type DuplicateGroup struct {
+	Title       string    `json:"title"`
+	Reasons     []string  `json:"reasons"`
+	Preferred   int       `json:"preferred"`
+	Variants    []Variant `json:"variants"`
+	Reclaimable int64     `json:"reclaimable"`
+}
+
+type Variant struct {
+	MovieId    int    `json:"movieId"`
+	File       string `json:"file"`
+	DriveName  string `json:"drive"`
+	Available  bool   `json:"available"`
+	Resolution int    `json:"resolution,omitempty"`
+	Size       int64  `json:"size"`
+}
+
+type Actor struct {
+	Name       string `json:"name"`
+	Character  string `json:"character,omitempty"`
+	ProfileUrl string `json:"profileUrl,omitempty"`
+}
+
+type Crew struct {
+	Name       string `json:"name"`
+	Job        string `json:"job,omitempty"`
+	ProfileUrl string `json:"profileUrl,omitempty"`
+}
+
+// Profile restricts movies which may be listed and played. Empty restriction means no restriction. Profile with PIN
+// is locked, PIN is required to switch to another profile or to change profiles while it is active.
+type Profile struct {
+	Name             string   `json:"name"`
+	MaxCertification string   `json:"maxCertification,omitempty"`
+	BlockedGenres    []string `json:"blockedGenres,omitempty"`
+	AllowedDirs      []string `json:"allowedDirs,omitempty"`
+	Pin              string   `json:"pin,omitempty"`
+	Locked           bool     `json:"locked"`
+}
+
+// ProfileSwitch is request to activate profile, PIN of currently active profile is required if it is locked.
+type ProfileSwitch struct {
+	Name string `json:"name"`
+	Pin  string `json:"pin,omitempty"`
 }
 
 type Playback struct {
+	Host             string `json:"host,omitempty"`
 	File             string `json:"file"`
 	Position         int    `json:"position"`
 	ActiveAudioTrack int    `json:"activeAudioTrack"`
 	ActiveSubtitle   int    `json:"activeSubtitle"`
 }
 
+type PlayerOptions struct {
+	Profile          string `json:"profile,omitempty"`
+	AudioOutput      string `json:"audio_output,omitempty"`
+	Passthrough      *bool  `json:"passthrough,omitempty"`
+	SubtitleFontSize int    `json:"subtitle_font_size,omitempty"`
+	SubtitleAlign    string `json:"subtitle_align,omitempty"`
+	AspectMode       string `json:"aspect_mode,omitempty"`
+	Display          int    `json:"display,omitempty"`
+}
+
 type PlayerStatus struct {
 	File             string `json:"file"`
 	Duration         int    `json:"duration"`
@@ -69,8 +191,93 @@ type TorrentDownload struct {
 	Attrs         map[string]string `json:"attrs,omitempty"`
 }
 
+type Peer struct {
+	Name      string `json:"name"`
+	Addr      string `json:"addr"`
+	Available bool   `json:"available"`
+}
+
+type Renderer struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+type Playlist struct {
+	Name    string  `json:"name"`
+	Items   []int   `json:"items"`
+	Repeat  string  `json:"repeat,omitempty"`
+	Shuffle bool    `json:"shuffle"`
+	Movies  []Movie `json:"movies,omitempty"`
+}
+
+type PlaylistItems struct {
+	Position *int  `json:"position,omitempty"`
+	Items    []int `json:"items"`
+}
+
+type PlaylistMove struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+type Queue struct {
+	Items   []MoviePath `json:"items"`
+	Current int         `json:"current"`
+	Mode    QueueMode   `json:"mode"`
+}
+
+type QueueMode struct {
+	Repeat      string `json:"repeat"`
+	Shuffle     bool   `json:"shuffle"`
+	AutoAdvance bool   `json:"autoAdvance"`
+}
+
+type Timer struct {
+	Id           int        `json:"id"`
+	Action       string     `json:"action"`
+	At           *time.Time `json:"at,omitempty"`
+	In           int        `json:"in,omitempty"`
+	AfterCurrent bool       `json:"afterCurrent,omitempty"`
+	Playlist     string     `json:"playlist,omitempty"`
+}
+
+// MessagePayload describes error. Code and Drive are set when drive must be mounted to play movie, code is
+// "mount_drive" then.
 type MessagePayload struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+	Drive   string `json:"drive,omitempty"`
+}
+
+// CatalogBundle is portable copy of catalog, it is used to move catalog between instances.
+type CatalogBundle struct {
+	Version  int       `json:"version"`
+	Exported time.Time `json:"exported"`
+	Movies   []Movie   `json:"movies"`
+}
+
+// ImportResult tells how many imported movies were matched to movies in catalog by path or by content. Unmatched
+// movies are not added to catalog, rejected movies have invalid data and are skipped.
+type ImportResult struct {
+	Matched   int      `json:"matched"`
+	ByContent int      `json:"byContent"`
+	Unmatched []string `json:"unmatched"`
+	Rejected  []string `json:"rejected"`
+}
+
+// Drive is storage with movies. Drive which is attached but not mounted may be mounted with udisks. Capacity and free
+// space are known for mounted drive only.
+type Drive struct {
+	Name       string    `json:"name"`
+	Device     string    `json:"device,omitempty"`
+	MountPoint string    `json:"mountPoint,omitempty"`
+	Attached   bool      `json:"attached"`
+	Mounted    bool      `json:"mounted"`
+	Capacity   uint64    `json:"capacity,omitempty"`
+	Free       uint64    `json:"free,omitempty"`
+	LastSeen   time.Time `json:"lastSeen"`
+	Movies     int       `json:"movies"`
 }
 
 type MoviePath struct {
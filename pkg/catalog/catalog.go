@@ -1,6 +1,8 @@
 package catalog
 
 import (
+	"fmt"
+
 	"github.com/andrew00x/gomovies/pkg/api"
 	"github.com/andrew00x/gomovies/pkg/config"
 )
@@ -13,13 +15,46 @@ func CreateCatalog(conf *config.Config) (Catalog, error) {
 	return catalogFactory(conf)
 }
 
+// Groups of labels which user puts on movies. Unlike tags added with AddTag, which are generated from details of movies
+// and live in index only, labels are kept in catalog.
+const (
+	TagGroup        = "tags"
+	CollectionGroup = "collections"
+)
+
 type Catalog interface {
 	All() []api.Movie
 	Find(title string) []api.Movie
 	Get(id int) (api.Movie, bool)
+	GetByFile(path string) (api.Movie, bool)
 	Load() error
 	Refresh() error
 	Save() error
 	Update(u api.Movie) (api.Movie, error)
 	AddTag(tag string, id int) error
+	AddLabel(group string, id int, name string) (api.Movie, error)
+	RemoveLabel(group string, id int, name string) (api.Movie, error)
+	RenameLabel(group, name, newName string) error
+	DeleteLabel(group, name string) error
+	Drives() ([]api.Drive, error)
+	MountDrive(name string) error
+	Import(movies []api.Movie) (api.ImportResult, error)
+}
+
+// MovieLabels returns user tags or collections of movie.
+func MovieLabels(m api.Movie, group string) []string {
+	if labels := labelsOf(&m, group); labels != nil {
+		return *labels
+	}
+	return nil
+}
+
+// LabelNotFoundError is returned when none of movies has user tag or collection.
+type LabelNotFoundError struct {
+	Group string
+	Name  string
+}
+
+func (e *LabelNotFoundError) Error() string {
+	return fmt.Sprintf("unknown %s: %s", e.Group, e.Name)
 }
@@ -3,9 +3,14 @@ package catalog
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -20,12 +25,30 @@ type JsonCatalog struct {
 	movies map[int]*api.Movie
 	conf   *config.Config
 	index  Index
+	// detailTags are tags added with AddTag, they are kept to restore them when movie is indexed again
+	detailTags map[int][]string
+	lastSeen   map[string]time.Time
+	saveTimer  *time.Timer
 }
 
+// catalogBackups is number of previous versions of catalog file which are kept.
+const catalogBackups = 3
+
+// catalogBackupInterval is minimal time between previous versions of catalog file, otherwise autosave would replace all
+// of them within a few minutes.
+var catalogBackupInterval = time.Hour
+
+// autosaveDelay is time without changes after which changed catalog is saved.
+var autosaveDelay = 5 * time.Second
+
 var catalogFile string
 
+// drivesFile keeps time when drives were mounted last time.
+var drivesFile string
+
 func init() {
 	catalogFile = filepath.Join(config.ConfDir(), "catalog.json")
+	drivesFile = filepath.Join(config.ConfDir(), "drives.json")
 	catalogFactory = createJsonCatalog
 }
 
@@ -91,14 +114,42 @@ func (ctl *JsonCatalog) Get(id int) (mov api.Movie, found bool) {
 	return
 }
 
+func (ctl *JsonCatalog) GetByFile(path string) (mov api.Movie, found bool) {
+	ctl.mu.RLock()
+	defer ctl.mu.RUnlock()
+	for _, m := range ctl.movies {
+		if m.File == path {
+			found = true
+			mov = *m
+			if exists, err := file.Exists(m.File); err != nil {
+				log.WithFields(log.Fields{"err": err, "file": m.File}).Warn("Error occurred while trying access movie file")
+			} else {
+				mov.Available = exists
+			}
+			break
+		}
+	}
+	return
+}
+
 func (ctl *JsonCatalog) Load() (err error) {
 	var movies map[int]*api.Movie
 	ctl.mu.Lock()
 	defer ctl.mu.Unlock()
+	// changes which are not saved yet would be lost when catalog file is read again
+	if err = ctl.flush(); err != nil {
+		return
+	}
 	if movies, err = ctl.readCatalog(); err != nil {
 		return
 	}
-	if err = ctl.scanForMovies(movies); err != nil {
+	if ctl.lastSeen == nil {
+		if ctl.lastSeen, err = readLastSeen(); err != nil {
+			return
+		}
+	}
+	var changed bool
+	if changed, err = ctl.scanForMovies(movies); err != nil {
 		return
 	}
 	var index Index
@@ -106,10 +157,14 @@ func (ctl *JsonCatalog) Load() (err error) {
 		return
 	}
 	for _, m := range movies {
-		index.Add(m.Title, m.Id)
+		indexMovie(index, m)
 	}
 	ctl.movies = movies
 	ctl.index = index
+	ctl.detailTags = make(map[int][]string)
+	if changed {
+		ctl.scheduleSave()
+	}
 	return
 }
 
@@ -117,28 +172,160 @@ func (ctl *JsonCatalog) Refresh() error {
 	return ctl.Load()
 }
 
+// Save writes catalog file immediately, pending autosave is cancelled. It must be called before exit since changes are
+// saved with delay, see scheduleSave.
 func (ctl *JsonCatalog) Save() (err error) {
 	ctl.mu.Lock()
 	defer ctl.mu.Unlock()
+	ctl.cancelSave()
+	return ctl.save()
+}
+
+// flush saves catalog if there is pending autosave.
+func (ctl *JsonCatalog) flush() error {
+	if ctl.saveTimer == nil {
+		return nil
+	}
+	ctl.cancelSave()
 	return ctl.save()
 }
 
+func (ctl *JsonCatalog) cancelSave() {
+	if ctl.saveTimer != nil {
+		ctl.saveTimer.Stop()
+		ctl.saveTimer = nil
+	}
+}
+
 func (ctl *JsonCatalog) save() (err error) {
-	var f *os.File
-	if f, err = os.OpenFile(catalogFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+	backups := file.Backups{Count: catalogBackups, Interval: catalogBackupInterval, Valid: validCatalog}
+	err = file.WriteAtomicWithBackups(catalogFile, 0644, backups, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(ctl.movies)
+	})
+	if err != nil {
 		return
 	}
-	defer func() {
-		if clsErr := f.Close(); clsErr != nil {
-			err = clsErr
+	err = ctl.saveLastSeen()
+	return
+}
+
+// scheduleSave saves catalog once there were no changes during autosaveDelay, so series of changes, e.g. while drive is
+// scanned, is saved once.
+func (ctl *JsonCatalog) scheduleSave() {
+	ctl.cancelSave()
+	ctl.saveTimer = time.AfterFunc(autosaveDelay, func() {
+		if err := ctl.Save(); err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Unable save catalog file")
+		} else {
+			log.Debug("Catalog file saved")
 		}
-	}()
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	err = encoder.Encode(ctl.movies)
+	})
+}
+
+// Drives returns drives which have movies in catalog and drives where configured directories are. Drive is attached if
+// its device is known to system even if it is not mounted.
+func (ctl *JsonCatalog) Drives() (result []api.Drive, err error) {
+	var attached []*drive
+	if attached, err = attachedDrives(); err != nil {
+		return
+	}
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+	counts := make(map[string]int)
+	for _, m := range ctl.movies {
+		if m.DriveName != "" {
+			counts[m.DriveName]++
+		}
+	}
+	listed := make(map[string]bool)
+	for _, dir := range ctl.conf.Dirs {
+		if d := dirDrive(attached, dir); d != nil {
+			listed[d.name] = true
+		}
+	}
+	if ctl.lastSeen == nil {
+		ctl.lastSeen = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for _, d := range attached {
+		if !listed[d.name] && counts[d.name] == 0 {
+			continue
+		}
+		listed[d.name] = true
+		drv := api.Drive{Name: d.name, Device: d.devSpec, MountPoint: d.mountPoint, Attached: true, Mounted: d.mountPoint != ""}
+		if drv.Mounted {
+			ctl.lastSeen[d.name] = now
+			if drv.Capacity, drv.Free, err = diskSpace(d.mountPoint); err != nil {
+				log.WithFields(log.Fields{"drive": d.name, "err": err}).Warn("Unable get disk space of drive")
+				err = nil
+			}
+		}
+		result = append(result, drv)
+	}
+	for name := range counts {
+		if !listed[name] {
+			result = append(result, api.Drive{Name: name})
+		}
+	}
+	for i := range result {
+		result[i].LastSeen = ctl.lastSeen[result[i].Name]
+		result[i].Movies = counts[result[i].Name]
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	err = ctl.saveLastSeen()
 	return
 }
 
+// MountDrive mounts attached drive with udisks, catalog should be refreshed after that to find new movies on drive.
+func (ctl *JsonCatalog) MountDrive(name string) error {
+	attached, err := attachedDrives()
+	if err != nil {
+		return err
+	}
+	d := findDrive(attached, func(d *drive) bool { return d.name == name })
+	if d == nil {
+		return fmt.Errorf("drive %s is not attached", name)
+	}
+	if d.mountPoint != "" {
+		return nil
+	}
+	log.WithFields(log.Fields{"drive": name, "device": d.devSpec}).Info("Mount drive")
+	return mountCommand(d.devSpec)
+}
+
+func (ctl *JsonCatalog) saveLastSeen() error {
+	if ctl.lastSeen == nil {
+		return nil
+	}
+	return file.WriteAtomic(drivesFile, 0644, 0, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(ctl.lastSeen)
+	})
+}
+
+func readLastSeen() (lastSeen map[string]time.Time, err error) {
+	lastSeen = make(map[string]time.Time)
+	var exists bool
+	if exists, err = file.Exists(drivesFile); exists && err == nil {
+		var f *os.File
+		if f, err = os.Open(drivesFile); err != nil {
+			return
+		}
+		defer func() {
+			if clsErr := f.Close(); clsErr != nil {
+				err = clsErr
+			}
+		}()
+		err = json.NewDecoder(f).Decode(&lastSeen)
+	}
+	return
+}
+
+// Update changes TMDb id, player options and overrides of movie. Fields which are not set, i.e. zero or nil, are left
+// unchanged, empty player options or overrides, e.g. {}, clear them.
 func (ctl *JsonCatalog) Update(u api.Movie) (m api.Movie, err error) {
 	ctl.mu.Lock()
 	defer ctl.mu.Unlock()
@@ -151,16 +338,46 @@ func (ctl *JsonCatalog) Update(u api.Movie) (m api.Movie, err error) {
 	if exists, err = file.Exists(p.File); err != nil {
 		return
 	}
-	// nothing else at the moment for update
-	p.TMDbId = u.TMDbId
+	if err = validateEdits(u); err != nil {
+		return
+	}
+	if u.Overrides != nil {
+		if reflect.DeepEqual(*u.Overrides, api.Overrides{}) {
+			p.Overrides = nil
+		} else {
+			p.Overrides = u.Overrides
+		}
+		ctl.reindexMovie(p)
+	}
+	if u.TMDbId != 0 {
+		p.TMDbId = u.TMDbId
+	}
+	if u.PlayerOptions != nil {
+		if reflect.DeepEqual(*u.PlayerOptions, api.PlayerOptions{}) {
+			p.PlayerOptions = nil
+		} else {
+			p.PlayerOptions = u.PlayerOptions
+		}
+	}
 	p.Available = exists
 	m = *p
-
-	err = ctl.save()
-
+	ctl.scheduleSave()
 	return
 }
 
+// validateEdits checks data of movie which is set by user.
+func validateEdits(m api.Movie) error {
+	if m.PlayerOptions != nil {
+		if err := config.ValidatePlayerOptions(*m.PlayerOptions); err != nil {
+			return err
+		}
+	}
+	if m.Overrides != nil && m.Overrides.Year < 0 {
+		return fmt.Errorf("invalid year: %d", m.Overrides.Year)
+	}
+	return nil
+}
+
 func (ctl *JsonCatalog) AddTag(tag string, id int) error {
 	ctl.mu.Lock()
 	defer ctl.mu.Unlock()
@@ -169,48 +386,314 @@ func (ctl *JsonCatalog) AddTag(tag string, id int) error {
 		return fmt.Errorf("unable add tag for unknown movie, id: %d", id)
 	}
 	ctl.index.Add(tag, id)
+	if ctl.detailTags == nil {
+		ctl.detailTags = make(map[int][]string)
+	}
+	ctl.detailTags[id] = append(ctl.detailTags[id], tag)
 	log.WithFields(log.Fields{"file": m.File, "tag": tag}).Info("Add tag for movie")
 	return nil
 }
 
+// Import merges data of movies exported from other catalog into this catalog. Movies are matched by path of file and
+// then by content id, so catalog may be imported on other device where drives are mounted at other paths. Data which is
+// already in catalog wins, tags and collections are joined. Movies with invalid player options or overrides are rejected
+// and nothing of them is imported.
+func (ctl *JsonCatalog) Import(movies []api.Movie) (result api.ImportResult, err error) {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+	byFile := make(map[string]*api.Movie, len(ctl.movies))
+	byContent := make(map[string]*api.Movie, len(ctl.movies))
+	for _, m := range ctl.movies {
+		byFile[m.File] = m
+		if m.ContentId != "" {
+			byContent[m.ContentId] = m
+		}
+	}
+	result.Unmatched = []string{}
+	result.Rejected = []string{}
+	for _, imported := range movies {
+		if e := validateEdits(imported); e != nil {
+			log.WithFields(log.Fields{"file": imported.File, "err": e}).Warn("Reject imported movie")
+			result.Rejected = append(result.Rejected, imported.File)
+			continue
+		}
+		m, found := byFile[imported.File]
+		if !found && imported.ContentId != "" {
+			if m, found = byContent[imported.ContentId]; found {
+				result.ByContent++
+			}
+		}
+		if !found {
+			result.Unmatched = append(result.Unmatched, imported.File)
+			continue
+		}
+		result.Matched++
+		mergeImported(m, imported)
+		ctl.reindexMovie(m)
+	}
+	if result.Matched > 0 {
+		ctl.scheduleSave()
+	}
+	return
+}
+
+func mergeImported(m *api.Movie, imported api.Movie) {
+	if m.TMDbId == 0 {
+		m.TMDbId = imported.TMDbId
+	}
+	if m.PlayerOptions == nil {
+		m.PlayerOptions = imported.PlayerOptions
+	}
+	if m.Overrides == nil {
+		m.Overrides = imported.Overrides
+	}
+	for _, t := range imported.Tags {
+		if indexOf(m.Tags, t) < 0 {
+			m.Tags = append(m.Tags, t)
+		}
+	}
+	for _, c := range imported.Collections {
+		if indexOf(m.Collections, c) < 0 {
+			m.Collections = append(m.Collections, c)
+		}
+	}
+}
+
+// indexMovie adds title of movie to index, as well as title, genres and labels set by user.
+func indexMovie(index Index, m *api.Movie) {
+	index.Add(m.Title, m.Id)
+	if m.Overrides != nil {
+		if m.Overrides.Title != "" {
+			index.Add(m.Overrides.Title, m.Id)
+		}
+		for _, g := range m.Overrides.Genres {
+			index.Add(g, m.Id)
+		}
+	}
+	for _, t := range m.Tags {
+		index.Add(t, m.Id)
+	}
+	for _, c := range m.Collections {
+		index.Add(c, m.Id)
+	}
+}
+
+// reindexMovie replaces entries of movie in index after details or labels set by user are changed, so old values are
+// not found any more. Tags added with AddTag are kept.
+func (ctl *JsonCatalog) reindexMovie(m *api.Movie) {
+	ctl.index.Remove(m.Id)
+	indexMovie(ctl.index, m)
+	for _, t := range ctl.detailTags[m.Id] {
+		ctl.index.Add(t, m.Id)
+	}
+}
+
+// AddLabel puts user tag or collection on movie, see TagGroup and CollectionGroup. Labels are compared ignoring case.
+func (ctl *JsonCatalog) AddLabel(group string, id int, name string) (m api.Movie, err error) {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+	var p *api.Movie
+	var labels *[]string
+	if p, labels, err = ctl.labels(group, id); err != nil {
+		return
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		err = fmt.Errorf("empty name of %s", group)
+		return
+	}
+	if indexOf(*labels, name) < 0 {
+		*labels = append(*labels, name)
+		ctl.index.Add(name, id)
+		ctl.scheduleSave()
+	}
+	m = *p
+	return
+}
+
+// RemoveLabel removes user tag or collection from movie.
+func (ctl *JsonCatalog) RemoveLabel(group string, id int, name string) (m api.Movie, err error) {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+	var p *api.Movie
+	var labels *[]string
+	if p, labels, err = ctl.labels(group, id); err != nil {
+		return
+	}
+	if i := indexOf(*labels, name); i >= 0 {
+		*labels = append((*labels)[:i], (*labels)[i+1:]...)
+		if len(*labels) == 0 {
+			*labels = nil
+		}
+		ctl.reindexMovie(p)
+		ctl.scheduleSave()
+	}
+	m = *p
+	return
+}
+
+// RenameLabel renames user tag or collection on all movies.
+func (ctl *JsonCatalog) RenameLabel(group, name, newName string) error {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+	if newName = strings.TrimSpace(newName); newName == "" {
+		return fmt.Errorf("empty name of %s", group)
+	}
+	return ctl.updateLabels(group, name, func(labels []string, i int) []string {
+		if j := indexOf(labels, newName); j >= 0 && j != i {
+			return append(labels[:i], labels[i+1:]...)
+		}
+		labels[i] = newName
+		return labels
+	})
+}
+
+// DeleteLabel removes user tag or collection from all movies.
+func (ctl *JsonCatalog) DeleteLabel(group, name string) error {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+	return ctl.updateLabels(group, name, func(labels []string, i int) []string {
+		return append(labels[:i], labels[i+1:]...)
+	})
+}
+
+func (ctl *JsonCatalog) labels(group string, id int) (*api.Movie, *[]string, error) {
+	p := ctl.movies[id]
+	if p == nil {
+		return nil, nil, fmt.Errorf("unknown movie, id: %d", id)
+	}
+	labels := labelsOf(p, group)
+	if labels == nil {
+		return nil, nil, fmt.Errorf("unknown group of labels: %s", group)
+	}
+	return p, labels, nil
+}
+
+func (ctl *JsonCatalog) updateLabels(group, name string, update func(labels []string, i int) []string) error {
+	found := false
+	for _, m := range ctl.movies {
+		labels := labelsOf(m, group)
+		if labels == nil {
+			return fmt.Errorf("unknown group of labels: %s", group)
+		}
+		if i := indexOf(*labels, name); i >= 0 {
+			found = true
+			if *labels = update(*labels, i); len(*labels) == 0 {
+				*labels = nil
+			}
+			ctl.reindexMovie(m)
+		}
+	}
+	if !found {
+		return &LabelNotFoundError{Group: group, Name: name}
+	}
+	ctl.scheduleSave()
+	return nil
+}
+
+func labelsOf(m *api.Movie, group string) *[]string {
+	switch group {
+	case TagGroup:
+		return &m.Tags
+	case CollectionGroup:
+		return &m.Collections
+	}
+	return nil
+}
+
+func indexOf(labels []string, name string) int {
+	for i, l := range labels {
+		if strings.EqualFold(l, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// readCatalog reads catalog file. If file is corrupted or missing, e.g. after power cut, catalog is recovered from the
+// newest valid backup.
 func (ctl *JsonCatalog) readCatalog() (movies map[int]*api.Movie, err error) {
-	movies = make(map[int]*api.Movie)
 	var exists bool
-	if exists, err = file.Exists(catalogFile); exists && err == nil {
+	if movies, exists, err = decodeCatalog(catalogFile); err == nil && exists {
+		return
+	}
+	for n := 1; n <= catalogBackups; n++ {
+		backup := file.Backup(catalogFile, n)
+		if recovered, found, e := decodeCatalog(backup); e == nil && found {
+			log.WithFields(log.Fields{"err": err, "backup": backup}).Warn("Catalog file is corrupted or missing, recover it from backup")
+			return recovered, nil
+		}
+	}
+	if err == nil {
+		movies = make(map[int]*api.Movie)
+	}
+	return
+}
+
+// validCatalog checks whether content may be decoded as catalog, corrupted catalog file is not kept as backup.
+func validCatalog(r io.Reader) bool {
+	var movies map[int]*api.Movie
+	return json.NewDecoder(r).Decode(&movies) == nil
+}
+
+func decodeCatalog(path string) (movies map[int]*api.Movie, exists bool, err error) {
+	movies = make(map[int]*api.Movie)
+	if exists, err = file.Exists(path); exists && err == nil {
 		var f *os.File
-		if f, err = os.OpenFile(catalogFile, os.O_RDONLY, 0644); err != nil {
+		if f, err = os.Open(path); err != nil {
 			return
 		}
 		defer func() {
-			if clsErr := f.Close(); clsErr != nil {
+			if clsErr := f.Close(); clsErr != nil && err == nil {
 				err = clsErr
 			}
 		}()
 		parser := json.NewDecoder(f)
-		err = parser.Decode(&movies)
+		if err = parser.Decode(&movies); err != nil {
+			err = fmt.Errorf("invalid catalog file %s: %s", path, err)
+		}
 	}
 	return
 }
 
-func (ctl *JsonCatalog) scanForMovies(files map[int]*api.Movie) (err error) {
+// scanForMovies adds new movie files to catalog and removes movies which files do not exist anymore. Movies on drives
+// which are not mounted are kept. Movie which file is found under different path, i.e. file was moved or renamed, keeps
+// its id and all its data, files are matched by content id, see file.PartialHash. Content id of existing files is set
+// before new files are looked for. Movies saved without content id, e.g. by older version, are matched by name of file.
+func (ctl *JsonCatalog) scanForMovies(files map[int]*api.Movie) (changed bool, err error) {
 	var drives []*drive
 	if drives, err = mountedDrives(); err != nil {
 		return
 	}
+	now := time.Now()
+	for _, d := range drives {
+		ctl.lastSeen[d.name] = now
+	}
 	known := make(map[string]bool, len(files))
+	lost := make(map[string]*api.Movie)
+	lostByName := make(map[string]*api.Movie)
 	var maxID = 0
 	for id, f := range files {
+		if id > maxID {
+			maxID = id
+		}
 		fileDriveMounted := driveMounted(drives, f)
 		exists := false
 		if exists, err = file.Exists(f.File); err == nil && (exists || !fileDriveMounted) {
 			known[f.File] = true
-			if id > maxID {
-				maxID = id
+			if exists && f.ContentId == "" {
+				f.ContentId = contentId(f.File)
+				changed = changed || f.ContentId != ""
 			}
 		} else if err != nil {
 			return
 		} else {
+			changed = true
 			delete(files, id)
+			if f.ContentId != "" {
+				lost[f.ContentId] = f
+			} else {
+				lostByName[filepath.Base(f.File)] = f
+			}
 		}
 	}
 	idGen := util.CreateIdGenerator(maxID)
@@ -219,14 +702,33 @@ func (ctl *JsonCatalog) scanForMovies(files map[int]*api.Movie) (err error) {
 		if exists, err = file.Exists(dir); exists && err == nil {
 			err = filepath.Walk(dir, func(path string, fInfo os.FileInfo, _ error) error {
 				if !known[path] && fInfo.Mode().IsRegular() && util.Contains(ctl.conf.VideoFileExts, filepath.Ext(fInfo.Name())) {
-					id := idGen.Next()
+					changed = true
 					title := fInfo.Name()
 					drive := fileDrive(drives, path)
 					driveName := ""
 					if drive != nil {
 						driveName = drive.name
 					}
-					files[id] = &api.Movie{Id: id, File: path, Title: title, DriveName: driveName}
+					cid := contentId(path)
+					if len(lost) > 0 || len(lostByName) > 0 {
+						m, ok := lost[cid]
+						if ok && cid != "" {
+							delete(lost, cid)
+						} else if m, ok = lostByName[title]; ok {
+							delete(lostByName, title)
+							m.ContentId = cid
+						}
+						if ok {
+							log.WithFields(log.Fields{"file": path, "old_file": m.File}).Info("Movie file moved")
+							m.File = path
+							m.Title = title
+							m.DriveName = driveName
+							files[m.Id] = m
+							return nil
+						}
+					}
+					id := idGen.Next()
+					files[id] = &api.Movie{Id: id, File: path, ContentId: cid, Title: title, DriveName: driveName}
 					log.WithFields(log.Fields{"file": path}).Debug("Add file to catalog")
 				}
 				return nil
@@ -238,3 +740,19 @@ func (ctl *JsonCatalog) scanForMovies(files map[int]*api.Movie) (err error) {
 	}
 	return
 }
+
+// contentId identifies content of movie file, empty files have no content id.
+func contentId(path string) string {
+	info, err := os.Stat(path)
+	if err == nil && info.Size() == 0 {
+		return ""
+	}
+	var id string
+	if err == nil {
+		id, err = file.PartialHash(path)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"file": path, "err": err}).Warn("Unable identify content of movie file")
+	}
+	return id
+}
@@ -4,15 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"text/template"
+	"time"
 
 	"testing"
 
 	"github.com/andrew00x/gomovies/pkg/api"
 	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/file"
 )
 
 var conf config.Config
@@ -100,6 +103,175 @@ func TestCreateAndUpdateCatalog(t *testing.T) {
 	assert.ElementsMatch(t, expectedIndex, index.added)
 }
 
+func TestMovedFileKeepsItsData(t *testing.T) {
+	setup()
+
+	indexFactory = func(_ *config.Config) (Index, error) { return &indexMock{[]indexItem{}, []int{}}, nil }
+	iceAge := filepath.Join(cartoonsDir, "ice age.avi")
+	mustWriteMovieFile(iceAge, "ice age")
+	catalog, err := createJsonCatalog(&conf)
+	assert.Nil(t, err)
+	m, found := catalog.GetByFile(iceAge)
+	assert.True(t, found)
+	assert.NotEmpty(t, m.ContentId)
+	_, err = catalog.Update(api.Movie{Id: m.Id, TMDbId: 425})
+	assert.Nil(t, err)
+	_, err = catalog.AddLabel(TagGroup, m.Id, "Watch with kids")
+	assert.Nil(t, err)
+
+	renamed := filepath.Join(moviesDir, "Ice Age (2002).avi")
+	if err = os.Rename(iceAge, renamed); err != nil {
+		log.Fatal(err)
+	}
+	mustWriteMovieFile(filepath.Join(cartoonsDir, "cars.avi"), "cars")
+	err = catalog.Load()
+	assert.Nil(t, err)
+
+	_, found = catalog.GetByFile(iceAge)
+	assert.False(t, found)
+	moved, found := catalog.GetByFile(renamed)
+	assert.True(t, found)
+	assert.Equal(t, m.Id, moved.Id)
+	assert.Equal(t, "Ice Age (2002).avi", moved.Title)
+	assert.Equal(t, sda1Label, moved.DriveName)
+	assert.Equal(t, 425, moved.TMDbId)
+	assert.Equal(t, []string{"Watch with kids"}, moved.Tags)
+	cars, found := catalog.GetByFile(filepath.Join(cartoonsDir, "cars.avi"))
+	assert.True(t, found)
+	assert.NotEqual(t, m.Id, cars.Id)
+}
+
+func TestContentIdIsSetForMoviesSavedWithoutIt(t *testing.T) {
+	setup()
+
+	indexFactory = func(_ *config.Config) (Index, error) { return &indexMock{[]indexItem{}, []int{}}, nil }
+	iceAge := filepath.Join(cartoonsDir, "ice age.avi")
+	mustWriteMovieFile(iceAge, "ice age")
+	mustSaveCatalogFile([]api.Movie{{Id: 7, File: iceAge, Title: "ice age.avi", DriveName: sdb1Label, TMDbId: 425}})
+
+	catalog, err := createJsonCatalog(&conf)
+	assert.Nil(t, err)
+
+	m, found := catalog.Get(7)
+	assert.True(t, found)
+	assert.NotEmpty(t, m.ContentId)
+}
+
+func TestMovedFileSavedWithoutContentIdIsMatchedByName(t *testing.T) {
+	setup()
+
+	indexFactory = func(_ *config.Config) (Index, error) { return &indexMock{[]indexItem{}, []int{}}, nil }
+	mustSaveCatalogFile([]api.Movie{{Id: 7, File: filepath.Join(cartoonsDir, "ice age.avi"), Title: "ice age.avi", DriveName: sdb1Label, TMDbId: 425}})
+	moved := filepath.Join(moviesDir, "ice age.avi")
+	mustWriteMovieFile(moved, "ice age")
+
+	catalog, err := createJsonCatalog(&conf)
+	assert.Nil(t, err)
+
+	m, found := catalog.GetByFile(moved)
+	assert.True(t, found)
+	assert.Equal(t, 7, m.Id)
+	assert.Equal(t, 425, m.TMDbId)
+	assert.Equal(t, sda1Label, m.DriveName)
+	assert.NotEmpty(t, m.ContentId)
+}
+
+func TestListDrives(t *testing.T) {
+	setup()
+
+	indexFactory = func(_ *config.Config) (Index, error) { return &indexMock{[]indexItem{}, []int{}}, nil }
+	offline := filepath.Join(testRoot, "media", "pi", "wd320", "brave.mkv")
+	mustSaveCatalogFile([]api.Movie{{Id: 1, File: offline, Title: "brave.mkv", DriveName: "wd320"}})
+	lastSeen := time.Date(2019, 3, 1, 20, 0, 0, 0, time.UTC)
+	mustWriteMovieFile(drivesFile, `{"wd320": "2019-03-01T20:00:00Z"}`)
+	catalog, err := createJsonCatalog(&conf)
+	assert.Nil(t, err)
+
+	drives, err := catalog.(*JsonCatalog).Drives()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(drives))
+	assert.Equal(t, "wd320", drives[0].Name)
+	assert.False(t, drives[0].Attached)
+	assert.Equal(t, 1, drives[0].Movies)
+	assert.True(t, lastSeen.Equal(drives[0].LastSeen))
+	assert.Equal(t, "wd500", drives[1].Name)
+	assert.True(t, drives[1].Mounted)
+	assert.Equal(t, 0, drives[1].Movies)
+	assert.Equal(t, "wd640", drives[2].Name)
+	assert.Equal(t, filepath.Join(testRoot, "media", "pi", "wd640"), drives[2].MountPoint)
+	assert.Equal(t, 4, drives[2].Movies)
+	assert.True(t, drives[2].Capacity > 0)
+	assert.False(t, drives[2].LastSeen.IsZero())
+}
+
+func TestMountDrive(t *testing.T) {
+	setup()
+
+	var mounted []string
+	mountCommand = func(devSpec string) error {
+		mounted = append(mounted, devSpec)
+		return nil
+	}
+	catalog := &JsonCatalog{conf: &conf}
+
+	assert.Nil(t, catalog.MountDrive("RECOVERY"))
+	assert.Nil(t, catalog.MountDrive(sda1Label))
+	assert.EqualError(t, catalog.MountDrive("wd320"), "drive wd320 is not attached")
+	assert.Equal(t, []string{filepath.Join(devcd, "mmcblk0p1")}, mounted)
+}
+
+func TestImportMergesMatchedMovies(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", Tags: []string{"Epic"}},
+		2: {Id: 2, File: filepath.Join(moviesDir, "green mile.mkv"), Title: "green mile.mkv", ContentId: "500-abc", TMDbId: 497},
+	}
+	index := indexMock{[]indexItem{}, []int{}}
+	catalog := &JsonCatalog{movies: movies, index: &index}
+
+	result, err := catalog.Import([]api.Movie{
+		{File: filepath.Join(moviesDir, "gladiator.mkv"), TMDbId: 98, Tags: []string{"epic", "Rome"}},
+		{File: "/media/usb/The Green Mile.mkv", ContentId: "500-abc", TMDbId: 1, Collections: []string{"King"}},
+		{File: "/media/usb/brave.mkv", ContentId: "700-abc", TMDbId: 62177},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.ImportResult{Matched: 2, ByContent: 1, Unmatched: []string{"/media/usb/brave.mkv"}, Rejected: []string{}}, result)
+	assert.Equal(t, 98, movies[1].TMDbId)
+	assert.Equal(t, []string{"Epic", "Rome"}, movies[1].Tags)
+	assert.Equal(t, 497, movies[2].TMDbId)
+	assert.Equal(t, []string{"King"}, movies[2].Collections)
+	assert.Nil(t, catalog.Save())
+	saved, _, err := decodeCatalog(catalogFile)
+	assert.Nil(t, err)
+	assert.Equal(t, movies, saved)
+}
+
+func TestImportRejectsInvalidData(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv"},
+		2: {Id: 2, File: filepath.Join(moviesDir, "green mile.mkv"), Title: "green mile.mkv"},
+	}
+	index := indexMock{[]indexItem{}, []int{}}
+	catalog := &JsonCatalog{movies: movies, index: &index}
+
+	result, err := catalog.Import([]api.Movie{
+		{File: filepath.Join(moviesDir, "gladiator.mkv"), TMDbId: 98, PlayerOptions: &api.PlayerOptions{AudioOutput: "speakers"}, Tags: []string{"Rome"}},
+		{File: filepath.Join(moviesDir, "green mile.mkv"), Overrides: &api.Overrides{Year: -1999}},
+	})
+
+	assert.Nil(t, err)
+	expected := []string{filepath.Join(moviesDir, "gladiator.mkv"), filepath.Join(moviesDir, "green mile.mkv")}
+	assert.Equal(t, api.ImportResult{Unmatched: []string{}, Rejected: expected}, result)
+	assert.Equal(t, 0, movies[1].TMDbId)
+	assert.Nil(t, movies[1].PlayerOptions)
+	assert.Nil(t, movies[1].Tags)
+	assert.Nil(t, movies[2].Overrides)
+}
+
 func TestSaveCatalog(t *testing.T) {
 	setup()
 
@@ -126,6 +298,104 @@ func TestSaveCatalog(t *testing.T) {
 	assert.Equal(t, movies, saved)
 }
 
+func TestSaveCatalogKeepsBackups(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv"}}
+	catalog := &JsonCatalog{movies: movies}
+	for id := 1; id <= 5; id++ {
+		movies[1].TMDbId = id
+		assert.Nil(t, catalog.Save())
+	}
+
+	saved, _, err := decodeCatalog(catalogFile)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, saved[1].TMDbId)
+	for n := 1; n <= catalogBackups; n++ {
+		backup, _, err := decodeCatalog(file.Backup(catalogFile, n))
+		assert.Nil(t, err)
+		assert.Equal(t, 5-n, backup[1].TMDbId)
+	}
+	_, exists, _ := decodeCatalog(file.Backup(catalogFile, catalogBackups+1))
+	assert.False(t, exists)
+}
+
+func TestSaveCatalogKeepsBackupsWithInterval(t *testing.T) {
+	setup()
+
+	catalogBackupInterval = time.Hour
+	movies := map[int]*api.Movie{1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv"}}
+	catalog := &JsonCatalog{movies: movies}
+	for id := 1; id <= 5; id++ {
+		movies[1].TMDbId = id
+		assert.Nil(t, catalog.Save())
+	}
+
+	saved, _, err := decodeCatalog(catalogFile)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, saved[1].TMDbId)
+	backup, _, err := decodeCatalog(file.Backup(catalogFile, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, backup[1].TMDbId)
+	_, exists, _ := decodeCatalog(file.Backup(catalogFile, 2))
+	assert.False(t, exists)
+}
+
+func TestCorruptedCatalogIsNotKeptAsBackup(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", TMDbId: 98}}
+	catalog := &JsonCatalog{movies: movies}
+	assert.Nil(t, catalog.Save())
+	assert.Nil(t, catalog.Save())
+	mustWriteMovieFile(catalogFile, `{"1": {"id": 1, "file": "`)
+
+	movies[1].TMDbId = 99
+	assert.Nil(t, catalog.Save())
+
+	backup, _, err := decodeCatalog(file.Backup(catalogFile, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 98, backup[1].TMDbId)
+	_, exists, _ := decodeCatalog(file.Backup(catalogFile, 2))
+	assert.False(t, exists)
+}
+
+func TestRecoverCatalogFromBackup(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", TMDbId: 98}}
+	catalog := &JsonCatalog{movies: movies}
+	assert.Nil(t, catalog.Save())
+	assert.Nil(t, catalog.Save())
+	mustWriteMovieFile(catalogFile, `{"1": {"id": 1, "file": "`)
+
+	recovered, err := catalog.readCatalog()
+	assert.Nil(t, err)
+	assert.Equal(t, 98, recovered[1].TMDbId)
+
+	if err = os.Remove(catalogFile); err != nil {
+		log.Fatal(err)
+	}
+	recovered, err = catalog.readCatalog()
+	assert.Nil(t, err)
+	assert.Equal(t, 98, recovered[1].TMDbId)
+}
+
+func TestAutosaveAfterScan(t *testing.T) {
+	setup()
+
+	autosaveDelay = 10 * time.Millisecond
+	indexFactory = func(_ *config.Config) (Index, error) { return &indexMock{[]indexItem{}, []int{}}, nil }
+	catalog, err := createJsonCatalog(&conf)
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	saved, exists, err := decodeCatalog(catalogFile)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, len(catalog.All()), len(saved))
+}
+
 func TestGetById(t *testing.T) {
 	setup()
 
@@ -298,7 +568,11 @@ func TestUpdateCatalog(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 101, movies[1].TMDbId)
 	assert.Equal(t, 101, updated.TMDbId)
+	_, exists, err := decodeCatalog(catalogFile)
+	assert.Nil(t, err)
+	assert.False(t, exists, "catalog is saved with delay")
 
+	assert.Nil(t, catalog.Save())
 	f, err := os.Open(catalogFile)
 	assert.Nil(t, err)
 	parser := json.NewDecoder(f)
@@ -310,6 +584,141 @@ func TestUpdateCatalog(t *testing.T) {
 	assert.Equal(t, movies, saved)
 }
 
+func TestGetByFile(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv"},
+		2: {Id: 2, File: filepath.Join(moviesDir, "green mile.mkv"), Title: "green mile.mkv"},
+	}
+	catalog := &JsonCatalog{movies: movies}
+
+	result, ok := catalog.GetByFile(filepath.Join(moviesDir, "green mile.mkv"))
+
+	assert.True(t, ok)
+	assert.Equal(t, api.Movie{Id: 2, File: filepath.Join(moviesDir, "green mile.mkv"), Title: "green mile.mkv", Available: true}, result)
+
+	_, ok = catalog.GetByFile(filepath.Join(moviesDir, "unknown.mkv"))
+	assert.False(t, ok)
+}
+
+func TestUpdatePlayerOptions(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", Available: true},
+	}
+	catalog := &JsonCatalog{movies: movies}
+
+	opts := &api.PlayerOptions{Profile: "surround", AudioOutput: "hdmi"}
+	updated, err := catalog.Update(api.Movie{Id: 1, PlayerOptions: opts})
+	assert.Nil(t, err)
+	assert.Equal(t, opts, movies[1].PlayerOptions)
+	assert.Equal(t, opts, updated.PlayerOptions)
+
+	_, err = catalog.Update(api.Movie{Id: 1, PlayerOptions: &api.PlayerOptions{AspectMode: "zoom"}})
+	assert.NotNil(t, err)
+	assert.Equal(t, opts, movies[1].PlayerOptions)
+}
+
+func TestUpdateTMDbIdKeepsPlayerOptions(t *testing.T) {
+	setup()
+
+	opts := &api.PlayerOptions{Profile: "surround"}
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", PlayerOptions: opts},
+	}
+	catalog := &JsonCatalog{movies: movies}
+
+	updated, err := catalog.Update(api.Movie{Id: 1, TMDbId: 98})
+	assert.Nil(t, err)
+	assert.Equal(t, 98, updated.TMDbId)
+	assert.Equal(t, opts, updated.PlayerOptions)
+
+	updated, err = catalog.Update(api.Movie{Id: 1, PlayerOptions: &api.PlayerOptions{}})
+	assert.Nil(t, err)
+	assert.Equal(t, 98, updated.TMDbId)
+	assert.Nil(t, updated.PlayerOptions)
+}
+
+func TestUpdateOverridesKeepsTMDbIdAndPlayerOptions(t *testing.T) {
+	setup()
+
+	opts := &api.PlayerOptions{AudioOutput: "hdmi"}
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", TMDbId: 98, PlayerOptions: opts},
+	}
+	index := indexMock{[]indexItem{}, []int{}}
+	catalog := &JsonCatalog{movies: movies, index: &index}
+
+	updated, err := catalog.Update(api.Movie{Id: 1, Overrides: &api.Overrides{Notes: "watch with dad"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 98, updated.TMDbId)
+	assert.Equal(t, opts, updated.PlayerOptions)
+	assert.Equal(t, "watch with dad", updated.Overrides.Notes)
+}
+
+func TestUpdateOverrides(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv", TMDbId: 98},
+	}
+	index := indexMock{[]indexItem{}, []int{}}
+	catalog := &JsonCatalog{movies: movies, index: &index}
+
+	overrides := &api.Overrides{Title: "Gladiator (Extended)", Year: 2000, Notes: "watch with dad"}
+	updated, err := catalog.Update(api.Movie{Id: 1, TMDbId: 98, Overrides: overrides})
+	assert.Nil(t, err)
+	assert.Equal(t, overrides, updated.Overrides)
+	assert.ElementsMatch(t, []indexItem{{"gladiator.mkv", 1}, {"Gladiator (Extended)", 1}}, index.added)
+
+	updated, err = catalog.Update(api.Movie{Id: 1, TMDbId: 98})
+	assert.Nil(t, err)
+	assert.Equal(t, overrides, updated.Overrides)
+
+	updated, err = catalog.Update(api.Movie{Id: 1, TMDbId: 98, Overrides: &api.Overrides{}})
+	assert.Nil(t, err)
+	assert.Nil(t, updated.Overrides)
+}
+
+func TestIndexFollowsOverridesAndLabels(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "gladiator.mkv"), Title: "gladiator.mkv"},
+	}
+	catalog := &JsonCatalog{movies: movies, index: &SimpleIndex{make(map[string]map[int]void)}}
+	assert.Nil(t, catalog.AddTag("Russell Crowe", 1))
+
+	_, err := catalog.Update(api.Movie{Id: 1, Overrides: &api.Overrides{Title: "Gladiator (Extended)", Genres: []string{"History"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(catalog.Find("extended")))
+	assert.Equal(t, 1, len(catalog.Find("history")))
+
+	_, err = catalog.Update(api.Movie{Id: 1, Overrides: &api.Overrides{Title: "Gladiator (Director's Cut)"}})
+	assert.Nil(t, err)
+	assert.Empty(t, catalog.Find("extended"))
+	assert.Empty(t, catalog.Find("history"))
+	assert.Equal(t, 1, len(catalog.Find("director")))
+
+	_, err = catalog.AddLabel(TagGroup, 1, "Christmas")
+	assert.Nil(t, err)
+	_, err = catalog.AddLabel(CollectionGroup, 1, "Epics")
+	assert.Nil(t, err)
+	_, err = catalog.RemoveLabel(TagGroup, 1, "christmas")
+	assert.Nil(t, err)
+	assert.Empty(t, catalog.Find("christmas"))
+	assert.Nil(t, catalog.RenameLabel(CollectionGroup, "Epics", "Sword and sandal"))
+	assert.Empty(t, catalog.Find("epics"))
+	assert.Equal(t, 1, len(catalog.Find("sandal")))
+	assert.Nil(t, catalog.DeleteLabel(CollectionGroup, "Sword and sandal"))
+	assert.Empty(t, catalog.Find("sandal"))
+
+	assert.Equal(t, 1, len(catalog.Find("crowe")))
+	assert.Equal(t, 1, len(catalog.Find("gladiator.mkv")))
+}
+
 func TestUpdateCatalogFailsWhenUpdatedFileDoesNotExist(t *testing.T) {
 	movies := make(map[int]*api.Movie)
 	catalog := &JsonCatalog{movies: movies}
@@ -351,6 +760,60 @@ func TestAddTagFailsWhenTryTagNotExistedMovie(t *testing.T) {
 	assert.Equal(t, "unable add tag for unknown movie, id: 3", err.Error())
 }
 
+func TestUserTagsAndCollections(t *testing.T) {
+	setup()
+
+	movies := map[int]*api.Movie{
+		1: {Id: 1, File: filepath.Join(moviesDir, "star wars", "star wars 1.avi"), Title: "star wars 1.avi"},
+		2: {Id: 2, File: filepath.Join(moviesDir, "star wars", "star wars 2.mkv"), Title: "star wars 2.mkv"},
+	}
+	index := indexMock{[]indexItem{}, []int{}}
+	catalog := &JsonCatalog{movies: movies, index: &index}
+
+	m, err := catalog.AddLabel(TagGroup, 1, "Christmas")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Christmas"}, m.Tags)
+	_, err = catalog.AddLabel(TagGroup, 1, "christmas")
+	assert.Nil(t, err)
+	_, err = catalog.AddLabel(CollectionGroup, 1, "Star Wars")
+	assert.Nil(t, err)
+	_, err = catalog.AddLabel(CollectionGroup, 2, "Star Wars")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Christmas"}, movies[1].Tags)
+	assert.Equal(t, []indexItem{{"Christmas", 1}, {"Star Wars", 1}, {"Star Wars", 2}}, index.added)
+
+	err = catalog.RenameLabel(CollectionGroup, "star wars", "Saga")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Saga"}, movies[2].Collections)
+
+	m, err = catalog.RemoveLabel(TagGroup, 1, "CHRISTMAS")
+	assert.Nil(t, err)
+	assert.Nil(t, m.Tags)
+
+	err = catalog.DeleteLabel(CollectionGroup, "Saga")
+	assert.Nil(t, err)
+	assert.Nil(t, movies[1].Collections)
+	assert.Nil(t, movies[2].Collections)
+
+	err = catalog.DeleteLabel(CollectionGroup, "Saga")
+	assert.Equal(t, &LabelNotFoundError{Group: CollectionGroup, Name: "Saga"}, err)
+	_, err = catalog.AddLabel("genres", 1, "Drama")
+	assert.EqualError(t, err, "unknown group of labels: genres")
+
+	_, err = catalog.AddLabel(TagGroup, 2, "Watch with kids")
+	assert.Nil(t, err)
+	assert.Nil(t, catalog.Save())
+	saved, err := catalog.readCatalog()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Watch with kids"}, saved[2].Tags)
+}
+
+func mustWriteMovieFile(path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func mustCreateEtcDir(rootDir string, drives []devDrive) (etc string) {
 	var wd string
 	var err error
@@ -417,6 +880,9 @@ func setup() {
 	confDir := filepath.Join(testRoot, "gomovies", "config")
 	mustCreateDir(confDir)
 	catalogFile = filepath.Join(confDir, "catalog.json")
+	drivesFile = filepath.Join(confDir, "drives.json")
+	autosaveDelay = time.Hour
+	catalogBackupInterval = 0
 	conf = config.Config{VideoFileExts: []string{".mkv", ".avi"}, Dirs: []string{moviesDir, cartoonsDir}}
 }
 
@@ -533,6 +999,16 @@ func (idx *indexMock) Add(title string, id int) {
 	idx.added = append(idx.added, indexItem{title, id})
 }
 
+func (idx *indexMock) Remove(id int) {
+	added := idx.added[:0]
+	for _, item := range idx.added {
+		if item.id != id {
+			added = append(added, item)
+		}
+	}
+	idx.added = added
+}
+
 func (idx *indexMock) Find(_ string) []int {
 	return idx.found
 }
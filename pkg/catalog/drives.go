@@ -1,10 +1,13 @@
 package catalog
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/andrew00x/gomovies/pkg/api"
 	"github.com/andrew00x/gomovies/pkg/file"
@@ -24,7 +27,31 @@ func init() {
 	etcDir = "/etc"
 }
 
+// mountCommand mounts block device with udisks on behalf of user who runs gomovies.
+var mountCommand = func(devSpec string) error {
+	out, err := exec.Command("udisksctl", "mount", "--no-user-interaction", "--block-device", devSpec).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("unable mount %s: %s", devSpec, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 func mountedDrives() ([]*drive, error) {
+	drives, err := attachedDrives()
+	if err != nil {
+		return nil, err
+	}
+	mounted := make([]*drive, 0, len(drives))
+	for _, drive := range drives {
+		if drive.mountPoint != "" {
+			mounted = append(mounted, drive)
+		}
+	}
+	return mounted, nil
+}
+
+// attachedDrives returns all drives known to system, mounted or not.
+func attachedDrives() ([]*drive, error) {
 	drives := make(map[string]*drive)
 
 	err := drivesByLabel(drives)
@@ -42,14 +69,22 @@ func mountedDrives() ([]*drive, error) {
 		return nil, err
 	}
 
-	mounted := make([]*drive, 0, len(drives))
+	attached := make([]*drive, 0, len(drives))
 	for _, drive := range drives {
-		if drive.mountPoint != "" {
-			mounted = append(mounted, drive)
-		}
+		attached = append(attached, drive)
 	}
+	return attached, nil
+}
 
-	return mounted, nil
+// diskSpace returns total and available space of file system mounted at mount point.
+func diskSpace(mountPoint string) (total uint64, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(mountPoint, &st); err != nil {
+		return
+	}
+	total = uint64(st.Bsize) * st.Blocks
+	free = uint64(st.Bsize) * st.Bavail
+	return
 }
 
 func drivesByLabel(drives map[string]*drive) error {
@@ -117,6 +152,17 @@ func fileDrive(drives []*drive, file string) *drive {
 	return findDrive(drives, func(d *drive) bool { return strings.HasPrefix(file, d.mountPoint) })
 }
 
+// dirDrive returns drive which directory is on, drive with the longest mount point wins since drives may be mounted
+// inside each other, e.g. "/" and "/media/pi/wd500".
+func dirDrive(drives []*drive, dir string) (result *drive) {
+	for _, d := range drives {
+		if d.mountPoint != "" && strings.HasPrefix(dir, d.mountPoint) && (result == nil || len(d.mountPoint) > len(result.mountPoint)) {
+			result = d
+		}
+	}
+	return
+}
+
 func driveMounted(drives []*drive, f *api.Movie) bool {
 	return findDrive(drives, func(d *drive) bool { return d.name == f.DriveName }) != nil
 }
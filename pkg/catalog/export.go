@@ -0,0 +1,144 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// Formats of exported catalog.
+const (
+	JsonFormat = "json"
+	CsvFormat  = "csv"
+)
+
+// bundleVersion is version of format of JSON bundle, it is increased on incompatible changes.
+const bundleVersion = 1
+
+// csvHeader lists columns of exported CSV, labels in cell are separated with csvListSeparator.
+var csvHeader = []string{"id", "file", "title", "drive", "content_id", "tmdb_id", "tags", "collections", "override_title",
+	"override_year", "notes"}
+
+const csvListSeparator = "|"
+
+// Export writes movies and all their data, e.g. TMDb ids, tags and overrides, in specified format. JSON bundle keeps
+// everything, CSV is meant for spreadsheets and keeps main fields only.
+func Export(w io.Writer, movies []api.Movie, format string) error {
+	switch format {
+	case JsonFormat, "":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(api.CatalogBundle{Version: bundleVersion, Exported: time.Now().UTC(), Movies: movies})
+	case CsvFormat:
+		return exportCsv(w, movies)
+	}
+	return fmt.Errorf("unsupported format: %s", format)
+}
+
+// ReadExport reads movies exported with Export.
+func ReadExport(r io.Reader, format string) ([]api.Movie, error) {
+	switch format {
+	case JsonFormat, "":
+		var bundle api.CatalogBundle
+		if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+			return nil, fmt.Errorf("invalid bundle: %s", err)
+		}
+		if bundle.Version > bundleVersion {
+			return nil, fmt.Errorf("unsupported version of bundle: %d", bundle.Version)
+		}
+		return bundle.Movies, nil
+	case CsvFormat:
+		return importCsv(r)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+func exportCsv(w io.Writer, movies []api.Movie) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, m := range movies {
+		var o api.Overrides
+		if m.Overrides != nil {
+			o = *m.Overrides
+		}
+		record := []string{
+			strconv.Itoa(m.Id),
+			m.File,
+			m.Title,
+			m.DriveName,
+			m.ContentId,
+			formatInt(m.TMDbId),
+			strings.Join(m.Tags, csvListSeparator),
+			strings.Join(m.Collections, csvListSeparator),
+			o.Title,
+			formatInt(o.Year),
+			o.Notes,
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func importCsv(r io.Reader) ([]api.Movie, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = len(csvHeader)
+	records, err := in.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %s", err)
+	}
+	if len(records) == 0 || records[0][0] != csvHeader[0] {
+		return nil, fmt.Errorf("invalid CSV: header is missing")
+	}
+	movies := make([]api.Movie, 0, len(records)-1)
+	for i, record := range records[1:] {
+		m := api.Movie{File: record[1], Title: record[2], DriveName: record[3], ContentId: record[4]}
+		var year int
+		if m.Id, err = parseInt(record[0]); err == nil {
+			if m.TMDbId, err = parseInt(record[5]); err == nil {
+				year, err = parseInt(record[9])
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV, line %d: %s", i+2, err)
+		}
+		m.Tags = splitList(record[6])
+		m.Collections = splitList(record[7])
+		if record[8] != "" || year != 0 || record[10] != "" {
+			m.Overrides = &api.Overrides{Title: record[8], Year: year, Notes: record[10]}
+		}
+		movies = append(movies, m)
+	}
+	return movies, nil
+}
+
+func formatInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func parseInt(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, csvListSeparator)
+}
@@ -0,0 +1,41 @@
+package catalog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+var exported = []api.Movie{
+	{Id: 1, File: "/media/pi/wd500/brave.mkv", Title: "brave.mkv", DriveName: "wd500", ContentId: "700-abc", TMDbId: 62177,
+		Tags: []string{"Watch with kids", "Pixar"}, Overrides: &api.Overrides{Title: "Brave", Year: 2012, Notes: "Scottish"}},
+	{Id: 2, File: "/media/pi/wd500/gladiator, extended.mkv", Title: "gladiator, extended.mkv", Collections: []string{"Epic"}},
+}
+
+func TestExportAndReadJsonBundle(t *testing.T) {
+	var buf bytes.Buffer
+	err := Export(&buf, exported, JsonFormat)
+	assert.Nil(t, err)
+
+	movies, err := ReadExport(&buf, JsonFormat)
+	assert.Nil(t, err)
+	assert.Equal(t, exported, movies)
+}
+
+func TestExportAndReadCsv(t *testing.T) {
+	var buf bytes.Buffer
+	err := Export(&buf, exported, CsvFormat)
+	assert.Nil(t, err)
+
+	movies, err := ReadExport(&buf, CsvFormat)
+	assert.Nil(t, err)
+	assert.Equal(t, exported, movies)
+
+	_, err = ReadExport(bytes.NewBufferString("1,/movies/brave.mkv\n"), CsvFormat)
+	assert.NotNil(t, err)
+	_, err = ReadExport(bytes.NewBufferString(`{"version": 2, "movies": []}`), JsonFormat)
+	assert.EqualError(t, err, "unsupported version of bundle: 2")
+}
@@ -11,6 +11,8 @@ import (
 type Index interface {
 	Add(tag string, id int)
 	Find(tag string) []int
+	// Remove removes all tags of movie with specified id.
+	Remove(id int)
 }
 
 type IndexFactory func(*config.Config) (Index, error)
@@ -46,6 +48,17 @@ func (i *SimpleIndex) Add(tag string, id int) {
 	}
 }
 
+func (i *SimpleIndex) Remove(id int) {
+	for key, ids := range i.idx {
+		if _, ok := ids[id]; ok {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(i.idx, key)
+			}
+		}
+	}
+}
+
 func (i *SimpleIndex) Find(tag string) []int {
 	lower := strings.ToLower(tag)
 	result := map[int]void{}
@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileName is information about movie which is parsed from name of its file, e.g. "Brave.2012.1080p.BluRay.mkv".
+type FileName struct {
+	Title      string
+	Year       int
+	Resolution int
+}
+
+var (
+	nameSeparators  = regexp.MustCompile(`[._\s\[\]()-]+`)
+	yearToken       = regexp.MustCompile(`^(19|20)\d\d$`)
+	resolutionToken = regexp.MustCompile(`^(\d{3,4})[pi]$`)
+	// releaseTokens end title of movie in name of file
+	releaseTokens = map[string]bool{
+		"hdr": true, "bluray": true, "bdrip": true, "brrip": true, "dvdrip": true, "hdrip": true,
+		"webrip": true, "web": true, "webdl": true, "hdtv": true, "remux": true, "x264": true, "x265": true, "h264": true,
+		"h265": true, "hevc": true, "xvid": true, "extended": true, "remastered": true, "unrated": true,
+	}
+)
+
+// ParseFileName parses title, year of release and vertical resolution from name of movie file. Title is lowercase
+// with words separated by single space, so names of different copies of movie may be compared.
+func ParseFileName(path string) (n FileName) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var tokens []string
+	titleEnded := false
+	for _, token := range nameSeparators.Split(strings.ToLower(name), -1) {
+		switch {
+		case token == "":
+		case resolutionToken.MatchString(token):
+			n.Resolution, _ = strconv.Atoi(token[:len(token)-1])
+			titleEnded = true
+		case token == "4k" || token == "uhd":
+			n.Resolution = 2160
+			titleEnded = true
+		case releaseTokens[token]:
+			titleEnded = true
+		case !titleEnded:
+			tokens = append(tokens, token)
+		}
+	}
+	// year is the last number which looks like year, title itself may start with or contain such number
+	title := tokens
+	for i := len(tokens) - 1; i > 0; i-- {
+		if yearToken.MatchString(tokens[i]) {
+			n.Year, _ = strconv.Atoi(tokens[i])
+			title = tokens[:i]
+			break
+		}
+	}
+	n.Title = strings.Join(title, " ")
+	return
+}
@@ -0,0 +1,15 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileName(t *testing.T) {
+	assert.Equal(t, FileName{Title: "brave", Year: 2012, Resolution: 1080}, ParseFileName("/movies/Brave.2012.1080p.BluRay.x264.mkv"))
+	assert.Equal(t, FileName{Title: "brave", Year: 2012, Resolution: 720}, ParseFileName("/media/usb/Brave (2012) [720p].avi"))
+	assert.Equal(t, FileName{Title: "blade runner 2049", Year: 2017, Resolution: 2160}, ParseFileName("Blade_Runner_2049_2017_4K_HDR.mkv"))
+	assert.Equal(t, FileName{Title: "2001 a space odyssey", Year: 1968}, ParseFileName("2001 A Space Odyssey 1968.mkv"))
+	assert.Equal(t, FileName{Title: "green mile"}, ParseFileName("green mile.mkv"))
+}
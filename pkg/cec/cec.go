@@ -0,0 +1,106 @@
+package cec
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+const seekStep = 30
+
+// imageViewOn asks TV (logical address 0) to turn on and switch to our input, we are the first playback device
+// (logical address 4).
+const imageViewOn = "tx 40:04"
+
+type Factory func(*config.Config) (EventSource, error)
+
+var eventSourceFactory Factory
+
+func CreateEventSource(conf *config.Config) (EventSource, error) {
+	return eventSourceFactory(conf)
+}
+
+// EventSource delivers names of keys pressed on TV remote, e.g. "play", "pause", "fast forward", and sends commands
+// to CEC bus.
+type EventSource interface {
+	Keys() <-chan string
+	Send(cmd string) error
+	Close() error
+}
+
+// Controller is set of player operations which may be triggered from TV remote.
+type Controller interface {
+	Play() (api.PlayerStatus, error)
+	Pause() (api.PlayerStatus, error)
+	PlayPause() (api.PlayerStatus, error)
+	Seek(offset int) (api.PlayerStatus, error)
+	Stop() (api.PlayerStatus, error)
+	VolumeUp() (float64, error)
+	VolumeDown() (float64, error)
+	NextInQueue() (api.Queue, error)
+	PreviousInQueue() (api.Queue, error)
+}
+
+var keyActions = map[string]func(Controller) error{
+	"play":         func(c Controller) (err error) { _, err = c.Play(); return },
+	"pause":        func(c Controller) (err error) { _, err = c.Pause(); return },
+	"select":       func(c Controller) (err error) { _, err = c.PlayPause(); return },
+	"stop":         func(c Controller) (err error) { _, err = c.Stop(); return },
+	"fast forward": func(c Controller) (err error) { _, err = c.Seek(seekStep); return },
+	"right":        func(c Controller) (err error) { _, err = c.Seek(seekStep); return },
+	"rewind":       func(c Controller) (err error) { _, err = c.Seek(-seekStep); return },
+	"left":         func(c Controller) (err error) { _, err = c.Seek(-seekStep); return },
+	"up":           func(c Controller) (err error) { _, err = c.VolumeUp(); return },
+	"down":         func(c Controller) (err error) { _, err = c.VolumeDown(); return },
+	"forward":      func(c Controller) (err error) { _, err = c.NextInQueue(); return },
+	"channel up":   func(c Controller) (err error) { _, err = c.NextInQueue(); return },
+	"backward":     func(c Controller) (err error) { _, err = c.PreviousInQueue(); return },
+	"channel down": func(c Controller) (err error) { _, err = c.PreviousInQueue(); return },
+}
+
+// Remote maps keys pressed on TV remote onto player operations. Remote also implements player.PlayListener to wake
+// up TV when playback is started.
+type Remote struct {
+	source EventSource
+	ctl    Controller
+}
+
+func CreateRemote(conf *config.Config, ctl Controller) (*Remote, error) {
+	source, err := CreateEventSource(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Remote{source: source, ctl: ctl}, nil
+}
+
+// Run handles key presses until event source is closed.
+func (r *Remote) Run() {
+	for key := range r.source.Keys() {
+		r.handle(key)
+	}
+}
+
+func (r *Remote) handle(key string) {
+	action, ok := keyActions[key]
+	if !ok {
+		log.WithFields(log.Fields{"key": key}).Debug("Ignore unsupported key")
+		return
+	}
+	if err := action(r.ctl); err != nil {
+		log.WithFields(log.Fields{"key": key, "err": err}).Warn("Unable handle key")
+	}
+}
+
+func (r *Remote) StartPlay(_ string) {
+	if err := r.source.Send(imageViewOn); err != nil {
+		log.WithFields(log.Fields{"err": err}).Warn("Unable turn on TV")
+	}
+}
+
+func (r *Remote) StopPlay(_ string) {
+}
+
+func (r *Remote) Close() error {
+	return r.source.Close()
+}
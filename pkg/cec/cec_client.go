@@ -0,0 +1,99 @@
+package cec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+const cecClientStopTimeout = 3 * time.Second
+
+var keyPressedRegexp = regexp.MustCompile(`key pressed: ([^(]+?) \(`)
+
+// CecClient runs cec-client from libcec as child process, reads key presses from its log and passes commands to its
+// standard input.
+type CecClient struct {
+	cmd   *exec.Cmd
+	mu    sync.Mutex
+	stdin io.WriteCloser
+	keys  chan string
+	done  chan struct{}
+}
+
+func init() {
+	eventSourceFactory = createCecClient
+}
+
+func createCecClient(conf *config.Config) (EventSource, error) {
+	// register as playback device with debug log level which includes key presses
+	args := []string{"-t", "p", "-o", "gomovies", "-d", "16"}
+	if conf.CecDevice != "" {
+		args = append(args, conf.CecDevice)
+	}
+	cmd := exec.Command("cec-client", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &CecClient{cmd: cmd, stdin: stdin, keys: make(chan string, 16), done: make(chan struct{})}
+	go c.read(stdout)
+	return c, nil
+}
+
+func (c *CecClient) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if key, ok := parseKey(scanner.Text()); ok {
+			c.keys <- key
+		}
+	}
+	err := c.cmd.Wait()
+	log.WithFields(log.Fields{"err": err}).Info("cec-client stopped")
+	close(c.keys)
+	close(c.done)
+}
+
+func (c *CecClient) Keys() <-chan string {
+	return c.keys
+}
+
+func (c *CecClient) Send(cmd string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := fmt.Fprintln(c.stdin, cmd)
+	return err
+}
+
+func (c *CecClient) Close() error {
+	if err := c.Send("q"); err != nil {
+		return c.cmd.Process.Kill()
+	}
+	select {
+	case <-c.done:
+		return nil
+	case <-time.After(cecClientStopTimeout):
+		return c.cmd.Process.Kill()
+	}
+}
+
+func parseKey(line string) (key string, ok bool) {
+	if m := keyPressedRegexp.FindStringSubmatch(line); m != nil {
+		key, ok = m[1], true
+	}
+	return
+}
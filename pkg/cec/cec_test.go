@@ -0,0 +1,118 @@
+package cec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func TestMapKeysOnPlayerOperations(t *testing.T) {
+	source := &fakeEventSource{keys: make(chan string, 10)}
+	ctl := &controllerMock{}
+	r := &Remote{source: source, ctl: ctl}
+	for _, key := range []string{"select", "fast forward", "rewind", "up", "channel up", "backward", "stop", "F1 (blue)"} {
+		source.keys <- key
+	}
+	close(source.keys)
+
+	r.Run()
+
+	assert.Equal(t, []string{"playpause", "seek 30", "seek -30", "volumeup", "next", "previous", "stop"}, ctl.calls)
+}
+
+func TestContinueWhenOperationFails(t *testing.T) {
+	source := &fakeEventSource{keys: make(chan string, 10)}
+	ctl := &controllerMock{err: fmt.Errorf("player is not started")}
+	r := &Remote{source: source, ctl: ctl}
+	source.keys <- "pause"
+	source.keys <- "play"
+	close(source.keys)
+
+	r.Run()
+
+	assert.Equal(t, []string{"pause", "play"}, ctl.calls)
+}
+
+func TestTurnOnTvWhenPlaybackStarted(t *testing.T) {
+	source := &fakeEventSource{}
+	r := &Remote{source: source, ctl: &controllerMock{}}
+
+	r.StartPlay("/movies/gladiator.mkv")
+
+	assert.Equal(t, []string{imageViewOn}, source.sent)
+}
+
+func TestParseKey(t *testing.T) {
+	key, ok := parseKey("DEBUG:   [           11523]	key pressed: fast forward (49, 0)")
+	assert.True(t, ok)
+	assert.Equal(t, "fast forward", key)
+
+	key, ok = parseKey("DEBUG:   [           11720]	key released: fast forward (49)")
+	assert.False(t, ok)
+
+	key, ok = parseKey("TRAFFIC: [           11523]	>> 01:44:49")
+	assert.False(t, ok)
+}
+
+type fakeEventSource struct {
+	keys chan string
+	sent []string
+}
+
+func (s *fakeEventSource) Keys() <-chan string { return s.keys }
+
+func (s *fakeEventSource) Send(cmd string) error {
+	s.sent = append(s.sent, cmd)
+	return nil
+}
+
+func (s *fakeEventSource) Close() error { return nil }
+
+type controllerMock struct {
+	calls []string
+	err   error
+}
+
+func (c *controllerMock) call(name string) error {
+	c.calls = append(c.calls, name)
+	return c.err
+}
+
+func (c *controllerMock) Play() (api.PlayerStatus, error) {
+	return api.PlayerStatus{}, c.call("play")
+}
+
+func (c *controllerMock) Pause() (api.PlayerStatus, error) {
+	return api.PlayerStatus{}, c.call("pause")
+}
+
+func (c *controllerMock) PlayPause() (api.PlayerStatus, error) {
+	return api.PlayerStatus{}, c.call("playpause")
+}
+
+func (c *controllerMock) Seek(offset int) (api.PlayerStatus, error) {
+	return api.PlayerStatus{}, c.call(fmt.Sprintf("seek %d", offset))
+}
+
+func (c *controllerMock) Stop() (api.PlayerStatus, error) {
+	return api.PlayerStatus{}, c.call("stop")
+}
+
+func (c *controllerMock) VolumeUp() (float64, error) {
+	return 1, c.call("volumeup")
+}
+
+func (c *controllerMock) VolumeDown() (float64, error) {
+	return 1, c.call("volumedown")
+}
+
+func (c *controllerMock) NextInQueue() (api.Queue, error) {
+	return api.Queue{}, c.call("next")
+}
+
+func (c *controllerMock) PreviousInQueue() (api.Queue, error) {
+	return api.Queue{}, c.call("previous")
+}
@@ -0,0 +1,170 @@
+// Package client is Go client of gomovies HTTP API. Server does not push events, so state of player and queue must be
+// polled, e.g. with Player.Status and Client.Queue.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// Client calls API of gomovies server. All methods accept context which cancels request.
+type Client struct {
+	addr   string
+	client *http.Client
+}
+
+// CreateClient creates client of server at addr, e.g. http://raspberrypi:8000. http.DefaultClient is used if client is
+// nil.
+func CreateClient(addr string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{addr: strings.TrimSuffix(addr, "/"), client: client}
+}
+
+// Error is error reported by server which has no more specific type.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NotFoundError is returned when movie, playlist, profile, timer, label, franchise or host does not exist.
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// ForbiddenError is returned when request is not allowed by the active profile or profile is locked with PIN.
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// DriveOfflineError is returned when movie is on drive which is plugged in but not mounted, see Client.MountDrive.
+type DriveOfflineError struct {
+	Drive   string
+	Message string
+}
+
+func (e *DriveOfflineError) Error() string {
+	return e.Message
+}
+
+// responseError converts api.MessagePayload sent by server into error.
+func responseError(resp *http.Response) error {
+	var msg api.MessagePayload
+	if json.NewDecoder(resp.Body).Decode(&msg) != nil || msg.Message == "" {
+		msg.Message = resp.Status
+	}
+	switch {
+	case msg.Code == "mount_drive":
+		return &DriveOfflineError{Drive: msg.Drive, Message: msg.Message}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{Message: msg.Message}
+	case resp.StatusCode == http.StatusForbidden:
+		return &ForbiddenError{Message: msg.Message}
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: msg.Message}
+}
+
+// do sends request and checks status of response, caller must close body of response if error is nil.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.addr+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// call sends body encoded as JSON unless it is nil and decodes JSON response into result if it is not nil.
+func (c *Client) call(ctx context.Context, method, path string, body, result interface{}) (err error) {
+	var r io.Reader
+	var contentType string
+	if body != nil {
+		var buf bytes.Buffer
+		if err = json.NewEncoder(&buf).Encode(body); err != nil {
+			return
+		}
+		r = &buf
+		contentType = "application/json"
+	}
+	resp, err := c.do(ctx, method, path, r, contentType)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	if result != nil {
+		err = json.NewDecoder(resp.Body).Decode(result)
+	}
+	return
+}
+
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+func langQuery(lang string) url.Values {
+	query := url.Values{}
+	if lang != "" {
+		query.Set("lang", lang)
+	}
+	return query
+}
+
+// FileURL returns url of file from movies' directories, e.g. poster or movie itself.
+func (c *Client) FileURL(path string) string {
+	return fmt.Sprintf("%s/file%s", c.addr, (&url.URL{Path: path}).EscapedPath())
+}
+
+func wrapFiles(files []string) (paths []api.MoviePath) {
+	paths = make([]api.MoviePath, len(files))
+	for i, f := range files {
+		paths[i] = api.MoviePath{File: f}
+	}
+	return
+}
+
+func unwrapFiles(paths []api.MoviePath) (files []string) {
+	files = make([]string, len(paths))
+	for i, p := range paths {
+		files[i] = p.File
+	}
+	return
+}
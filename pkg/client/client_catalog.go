@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// MovieQuery narrows down listing of movies. Details of movies are included only if Details is true, movies with
+// certification above Certification are excluded, e.g. "PG-13".
+type MovieQuery struct {
+	Lang          string
+	Details       bool
+	Certification string
+}
+
+func (q MovieQuery) values() url.Values {
+	query := langQuery(q.Lang)
+	query.Set("details", strconv.FormatBool(q.Details))
+	if q.Certification != "" {
+		query.Set("certification", q.Certification)
+	}
+	return query
+}
+
+// Movies lists catalog of server.
+func (c *Client) Movies(ctx context.Context, q MovieQuery) (movies []api.Movie, err error) {
+	err = c.call(ctx, http.MethodGet, withQuery("/api/list", q.values()), nil, &movies)
+	return
+}
+
+// Search finds movies by title, tag or person.
+func (c *Client) Search(ctx context.Context, text string, q MovieQuery) (movies []api.Movie, err error) {
+	query := q.values()
+	query.Set("q", text)
+	err = c.call(ctx, http.MethodGet, withQuery("/api/search", query), nil, &movies)
+	return
+}
+
+// PersonMovies finds movies where person is credited, role is one of "cast", "director", "writer" or empty for any.
+func (c *Client) PersonMovies(ctx context.Context, name, role, lang string) (movies []api.Movie, err error) {
+	query := langQuery(lang)
+	query.Set("name", name)
+	if role != "" {
+		query.Set("role", role)
+	}
+	err = c.call(ctx, http.MethodGet, withQuery("/api/person", query), nil, &movies)
+	return
+}
+
+// Update sets TMDb id, player options and overrides of movie, pin is PIN of the active profile, it is required when
+// the active profile is locked.
+func (c *Client) Update(ctx context.Context, m api.Movie, pin string) (updated api.Movie, err error) {
+	err = c.call(ctx, http.MethodPost, withQuery("/api/update", pinQuery(pin)), m, &updated)
+	return
+}
+
+// Refresh rescans movies' directories.
+func (c *Client) Refresh(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/api/refresh", nil, nil)
+}
+
+// Export writes catalog to w in format "json" or "csv".
+func (c *Client) Export(ctx context.Context, w io.Writer, format string) (err error) {
+	query := url.Values{}
+	query.Set("format", format)
+	resp, err := c.do(ctx, http.MethodGet, withQuery("/api/catalog/export", query), nil, "")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	_, err = io.Copy(w, resp.Body)
+	return
+}
+
+// Import merges catalog exported in format "json" or "csv" into catalog of server, pin is required when the active
+// profile is locked.
+func (c *Client) Import(ctx context.Context, r io.Reader, format, pin string) (result api.ImportResult, err error) {
+	contentType := "application/json"
+	if format == "csv" {
+		contentType = "text/csv"
+	}
+	query := pinQuery(pin)
+	query.Set("format", format)
+	resp, err := c.do(ctx, http.MethodPost, withQuery("/api/catalog/import", query), r, contentType)
+	if err != nil {
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return
+}
+
+// Drives lists drives with movies.
+func (c *Client) Drives(ctx context.Context) (drives []api.Drive, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/drives", nil, &drives)
+	return
+}
+
+// MountDrive mounts drive which is plugged in but not mounted and returns updated list of drives.
+func (c *Client) MountDrive(ctx context.Context, name string) (drives []api.Drive, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/drives/"+url.PathEscape(name)+"/mount", nil, &drives)
+	return
+}
+
+// Duplicates reports copies of the same movie, content of files is compared if byContent is true.
+func (c *Client) Duplicates(ctx context.Context, byContent bool) (report api.DuplicatesReport, err error) {
+	query := url.Values{}
+	if byContent {
+		query.Set("hash", "true")
+	}
+	err = c.call(ctx, http.MethodGet, withQuery("/api/duplicates", query), nil, &report)
+	return
+}
+
+// Labels lists user tags or collections with number of movies, group is catalog.TagGroup or catalog.CollectionGroup.
+func (c *Client) Labels(ctx context.Context, group string) (labels []api.Label, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/"+group, nil, &labels)
+	return
+}
+
+// Labelled lists movies which have label.
+func (c *Client) Labelled(ctx context.Context, group, name string) (movies []api.Movie, err error) {
+	err = c.call(ctx, http.MethodGet, labelPath(group, name), nil, &movies)
+	return
+}
+
+// RenameLabel renames label on all movies.
+func (c *Client) RenameLabel(ctx context.Context, group, name, newName string) error {
+	return c.call(ctx, http.MethodPut, labelPath(group, name), api.Label{Name: newName}, nil)
+}
+
+// DeleteLabel removes label from all movies.
+func (c *Client) DeleteLabel(ctx context.Context, group, name string) error {
+	return c.call(ctx, http.MethodDelete, labelPath(group, name), nil, nil)
+}
+
+// AddLabel puts label on movie.
+func (c *Client) AddLabel(ctx context.Context, group, name string, id int) (m api.Movie, err error) {
+	err = c.call(ctx, http.MethodPost, labelMoviesPath(group, name, id), nil, &m)
+	return
+}
+
+// RemoveLabel removes label from movie.
+func (c *Client) RemoveLabel(ctx context.Context, group, name string, id int) (m api.Movie, err error) {
+	err = c.call(ctx, http.MethodDelete, labelMoviesPath(group, name, id), nil, &m)
+	return
+}
+
+func labelPath(group, name string) string {
+	return "/api/" + group + "/" + url.PathEscape(name)
+}
+
+func labelMoviesPath(group, name string, id int) string {
+	query := url.Values{}
+	query.Set("id", strconv.Itoa(id))
+	return withQuery(labelPath(group, name)+"/movies", query)
+}
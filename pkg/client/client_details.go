@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// Details returns details of movie in language lang, server uses "en" if lang is empty.
+func (c *Client) Details(ctx context.Context, id int, lang string) (md api.MovieDetails, err error) {
+	query := langQuery(lang)
+	query.Set("id", strconv.Itoa(id))
+	err = c.call(ctx, http.MethodGet, withQuery("/api/details", query), nil, &md)
+	return
+}
+
+// SearchDetails searches TMDb for movies with title.
+func (c *Client) SearchDetails(ctx context.Context, title, lang string) (found []api.MovieDetails, err error) {
+	query := langQuery(lang)
+	query.Set("q", title)
+	err = c.call(ctx, http.MethodGet, withQuery("/api/details/search", query), nil, &found)
+	return
+}
+
+// Nfo returns details of movie as Kodi NFO file.
+func (c *Client) Nfo(ctx context.Context, id int, lang string) (nfo []byte, err error) {
+	resp, err := c.do(ctx, http.MethodGet, nfoPath(id, lang, false), nil, "")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	nfo, err = ioutil.ReadAll(resp.Body)
+	return
+}
+
+// ExportNfo saves details of movie as Kodi NFO file next to movie on server. Existing NFO file is replaced only if
+// overwrite is true.
+func (c *Client) ExportNfo(ctx context.Context, id int, lang string, overwrite bool) error {
+	return c.call(ctx, http.MethodPost, nfoPath(id, lang, overwrite), nil, nil)
+}
+
+func nfoPath(id int, lang string, overwrite bool) string {
+	query := langQuery(lang)
+	query.Set("id", strconv.Itoa(id))
+	if overwrite {
+		query.Set("overwrite", "true")
+	}
+	return withQuery("/api/details/nfo", query)
+}
+
+// Franchises lists TMDb collections which have movies in catalog.
+func (c *Client) Franchises(ctx context.Context, lang string) (franchises []api.Franchise, err error) {
+	err = c.call(ctx, http.MethodGet, withQuery("/api/franchises", langQuery(lang)), nil, &franchises)
+	return
+}
+
+// Franchise returns TMDb collection with parts which are in catalog and parts which are missing.
+func (c *Client) Franchise(ctx context.Context, id int, lang string) (f api.Franchise, err error) {
+	err = c.call(ctx, http.MethodGet, withQuery(franchisePath(id), langQuery(lang)), nil, &f)
+	return
+}
+
+// EnqueueFranchise adds parts of franchise which are in catalog to play queue in order of release.
+func (c *Client) EnqueueFranchise(ctx context.Context, id int, lang string) (queue []string, err error) {
+	var paths []api.MoviePath
+	if err = c.call(ctx, http.MethodPost, withQuery(franchisePath(id)+"/enqueue", langQuery(lang)), nil, &paths); err == nil {
+		queue = unwrapFiles(paths)
+	}
+	return
+}
+
+func franchisePath(id int) string {
+	return "/api/franchises/" + url.PathEscape(strconv.Itoa(id))
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// PlayMovie starts playing movie, field Host of playback selects peer or media renderer.
+func (c *Client) PlayMovie(ctx context.Context, playback api.Playback) (status api.PlayerStatus, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/play", playback, &status)
+	return
+}
+
+// Enqueue adds files to play queue, playback is started if nothing is playing.
+func (c *Client) Enqueue(ctx context.Context, files []string) (queue []string, err error) {
+	var paths []api.MoviePath
+	if err = c.call(ctx, http.MethodPost, "/api/enqueue", wrapFiles(files), &paths); err == nil {
+		queue = unwrapFiles(paths)
+	}
+	return
+}
+
+// Dequeue removes item at position from play queue.
+func (c *Client) Dequeue(ctx context.Context, position int) (queue []string, err error) {
+	var paths []api.MoviePath
+	if err = c.call(ctx, http.MethodPost, "/api/dequeue", api.Position{Position: position}, &paths); err == nil {
+		queue = unwrapFiles(paths)
+	}
+	return
+}
+
+func (c *Client) Queue(ctx context.Context) (queue api.Queue, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/queue", nil, &queue)
+	return
+}
+
+func (c *Client) ClearQueue(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/api/clearqueue", nil, nil)
+}
+
+// ShiftQueue is kept for compatibility with old servers, use JumpInQueue.
+func (c *Client) ShiftQueue(ctx context.Context, position int) (queue []string, err error) {
+	var paths []api.MoviePath
+	if err = c.call(ctx, http.MethodPost, "/api/shiftqueue", api.Position{Position: position}, &paths); err == nil {
+		queue = unwrapFiles(paths)
+	}
+	return
+}
+
+func (c *Client) NextInQueue(ctx context.Context) (queue api.Queue, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/queue/next", nil, &queue)
+	return
+}
+
+func (c *Client) PreviousInQueue(ctx context.Context) (queue api.Queue, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/queue/previous", nil, &queue)
+	return
+}
+
+// JumpInQueue starts playing item at position of play queue.
+func (c *Client) JumpInQueue(ctx context.Context, position int) (queue api.Queue, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/queue/jump", api.Position{Position: position}, &queue)
+	return
+}
+
+func (c *Client) QueueMode(ctx context.Context) (mode api.QueueMode, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/queue/mode", nil, &mode)
+	return
+}
+
+func (c *Client) SetQueueMode(ctx context.Context, mode api.QueueMode) (updated api.QueueMode, err error) {
+	err = c.call(ctx, http.MethodPut, "/api/queue/mode", mode, &updated)
+	return
+}
+
+// Peers lists other gomovies instances known to server, peer is available if its player responds.
+func (c *Client) Peers(ctx context.Context) (peers []api.Peer, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/peers", nil, &peers)
+	return
+}
+
+// PeerMovies lists movies of server together with movies of all available peers, host of each movie is set.
+func (c *Client) PeerMovies(ctx context.Context) (movies []api.Movie, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/peers/list", nil, &movies)
+	return
+}
+
+// Renderers discovers DLNA/UPnP media renderers, id of renderer may be used as host of player.
+func (c *Client) Renderers(ctx context.Context) (renderers []api.Renderer, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/renderers", nil, &renderers)
+	return
+}
+
+// Player controls player of server or player of host, which is name of peer or id of media renderer.
+type Player struct {
+	c    *Client
+	host string
+}
+
+// Player returns player of host, player of server itself is returned if host is empty.
+func (c *Client) Player(host string) *Player {
+	return &Player{c: c, host: host}
+}
+
+func (p *Player) path(action string) string {
+	query := url.Values{}
+	if p.host != "" {
+		query.Set("host", p.host)
+	}
+	return withQuery("/api/player/"+action, query)
+}
+
+func (p *Player) status(ctx context.Context, method, action string, body interface{}) (status api.PlayerStatus, err error) {
+	err = p.c.call(ctx, method, p.path(action), body, &status)
+	return
+}
+
+func (p *Player) streams(ctx context.Context, method, action string, body interface{}) (streams []api.Stream, err error) {
+	err = p.c.call(ctx, method, p.path(action), body, &streams)
+	return
+}
+
+func (p *Player) volume(ctx context.Context, method, action string) (float64, error) {
+	var v api.Volume
+	err := p.c.call(ctx, method, p.path(action), nil, &v)
+	return v.Volume, err
+}
+
+func (p *Player) Status(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodGet, "status", nil)
+}
+
+func (p *Player) Play(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "play", nil)
+}
+
+func (p *Player) Pause(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "pause", nil)
+}
+
+func (p *Player) PlayPause(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "playpause", nil)
+}
+
+func (p *Player) Stop(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "stop", nil)
+}
+
+func (p *Player) Replay(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "replay", nil)
+}
+
+// Seek moves playback by offset in seconds, negative offset moves backward.
+func (p *Player) Seek(ctx context.Context, offset int) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "seek", api.Position{Position: offset})
+}
+
+// SetPosition moves playback to position in seconds.
+func (p *Player) SetPosition(ctx context.Context, position int) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "position", api.Position{Position: position})
+}
+
+func (p *Player) ToggleMute(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "togglemute", nil)
+}
+
+func (p *Player) ToggleSubtitles(ctx context.Context) (api.PlayerStatus, error) {
+	return p.status(ctx, http.MethodPost, "togglesubtitles", nil)
+}
+
+func (p *Player) AudioTracks(ctx context.Context) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodGet, "audios", nil)
+}
+
+func (p *Player) NextAudioTrack(ctx context.Context) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodPost, "nextaudiotrack", nil)
+}
+
+func (p *Player) PreviousAudioTrack(ctx context.Context) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodPost, "previousaudiotrack", nil)
+}
+
+func (p *Player) SelectAudio(ctx context.Context, index int) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodPost, "audio", api.TrackIndex{Index: index})
+}
+
+func (p *Player) Subtitles(ctx context.Context) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodGet, "subtitles", nil)
+}
+
+func (p *Player) NextSubtitle(ctx context.Context) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodPost, "nextsubtitle", nil)
+}
+
+func (p *Player) PreviousSubtitle(ctx context.Context) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodPost, "previoussubtitle", nil)
+}
+
+func (p *Player) SelectSubtitle(ctx context.Context, index int) ([]api.Stream, error) {
+	return p.streams(ctx, http.MethodPost, "subtitle", api.TrackIndex{Index: index})
+}
+
+func (p *Player) Volume(ctx context.Context) (float64, error) {
+	return p.volume(ctx, http.MethodGet, "volume")
+}
+
+func (p *Player) VolumeUp(ctx context.Context) (float64, error) {
+	return p.volume(ctx, http.MethodPost, "volumeup")
+}
+
+func (p *Player) VolumeDown(ctx context.Context) (float64, error) {
+	return p.volume(ctx, http.MethodPost, "volumedown")
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (c *Client) Playlists(ctx context.Context) (playlists []api.Playlist, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/playlists", nil, &playlists)
+	return
+}
+
+func (c *Client) Playlist(ctx context.Context, name string) (p api.Playlist, err error) {
+	err = c.call(ctx, http.MethodGet, playlistPath(name), nil, &p)
+	return
+}
+
+func (c *Client) CreatePlaylist(ctx context.Context, p api.Playlist) (created api.Playlist, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/playlists", p, &created)
+	return
+}
+
+// UpdatePlaylist replaces items and play mode of playlist p.Name.
+func (c *Client) UpdatePlaylist(ctx context.Context, p api.Playlist) (updated api.Playlist, err error) {
+	err = c.call(ctx, http.MethodPut, playlistPath(p.Name), p, &updated)
+	return
+}
+
+func (c *Client) DeletePlaylist(ctx context.Context, name string) error {
+	return c.call(ctx, http.MethodDelete, playlistPath(name), nil, nil)
+}
+
+// InsertIntoPlaylist inserts movies with ids at position, movies are appended if position is nil.
+func (c *Client) InsertIntoPlaylist(ctx context.Context, name string, position *int, ids []int) (p api.Playlist, err error) {
+	err = c.call(ctx, http.MethodPost, playlistPath(name)+"/items", api.PlaylistItems{Position: position, Items: ids}, &p)
+	return
+}
+
+func (c *Client) RemoveFromPlaylist(ctx context.Context, name string, position int) (p api.Playlist, err error) {
+	err = c.call(ctx, http.MethodDelete, playlistPath(name)+"/items", api.Position{Position: position}, &p)
+	return
+}
+
+func (c *Client) MoveInPlaylist(ctx context.Context, name string, from, to int) (p api.Playlist, err error) {
+	err = c.call(ctx, http.MethodPost, playlistPath(name)+"/move", api.PlaylistMove{From: from, To: to}, &p)
+	return
+}
+
+// PlayPlaylist replaces play queue with playlist and starts playing it.
+func (c *Client) PlayPlaylist(ctx context.Context, name string) (queue []string, err error) {
+	var paths []api.MoviePath
+	if err = c.call(ctx, http.MethodPost, playlistPath(name)+"/play", nil, &paths); err == nil {
+		queue = unwrapFiles(paths)
+	}
+	return
+}
+
+func playlistPath(name string) string {
+	return "/api/playlists/" + url.PathEscape(name)
+}
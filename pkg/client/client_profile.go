@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (c *Client) Profiles(ctx context.Context) (profiles []api.Profile, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/profiles", nil, &profiles)
+	return
+}
+
+func (c *Client) Profile(ctx context.Context, name string) (p api.Profile, err error) {
+	err = c.call(ctx, http.MethodGet, profilePath(name, ""), nil, &p)
+	return
+}
+
+// CreateProfile saves new profile, pin is PIN of the active profile, it is required when the active profile is locked
+// and may be empty otherwise. The same applies to UpdateProfile and DeleteProfile.
+func (c *Client) CreateProfile(ctx context.Context, p api.Profile, pin string) (saved api.Profile, err error) {
+	err = c.call(ctx, http.MethodPost, withQuery("/api/profiles", pinQuery(pin)), p, &saved)
+	return
+}
+
+func (c *Client) UpdateProfile(ctx context.Context, p api.Profile, pin string) (saved api.Profile, err error) {
+	err = c.call(ctx, http.MethodPut, profilePath(p.Name, pin), p, &saved)
+	return
+}
+
+func (c *Client) DeleteProfile(ctx context.Context, name, pin string) error {
+	return c.call(ctx, http.MethodDelete, profilePath(name, pin), nil, nil)
+}
+
+func (c *Client) ActiveProfile(ctx context.Context) (p api.Profile, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/profiles/active", nil, &p)
+	return
+}
+
+// ActivateProfile switches the active profile, PIN of profile is required if it is locked.
+func (c *Client) ActivateProfile(ctx context.Context, sw api.ProfileSwitch) (active api.Profile, err error) {
+	err = c.call(ctx, http.MethodPut, "/api/profiles/active", sw, &active)
+	return
+}
+
+func profilePath(name, pin string) string {
+	return withQuery("/api/profiles/"+url.PathEscape(name), pinQuery(pin))
+}
+
+func pinQuery(pin string) url.Values {
+	query := url.Values{}
+	if pin != "" {
+		query.Set("pin", pin)
+	}
+	return query
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/server/servertest"
+)
+
+var movies = []api.Movie{
+	{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv", Available: true},
+	{Id: 2, File: "/movies/up.mkv", Title: "up.mkv", Available: true},
+	{Id: 3, File: "/media/archive/cars.mkv", Title: "cars.mkv", DriveName: "archive"},
+}
+
+func newServer(conf *config.Config) (*servertest.Server, *Client) {
+	if conf == nil {
+		conf = testConfig()
+	}
+	server := servertest.NewServerWithConfig(conf, movies...)
+	return server, CreateClient(server.URL+"/", server.Client())
+}
+
+func testConfig() *config.Config {
+	return &config.Config{WebPort: 8000, MaxRequestSize: 16 << 20, DetailsLangs: []string{"en"}}
+}
+
+type denyAll struct{}
+
+func (denyAll) AllowsMovie(api.Movie) bool { return false }
+func (denyAll) AllowsFile(string) bool     { return false }
+
+func TestMovies(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+
+	result, err := c.Movies(context.Background(), MovieQuery{Lang: "en"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result))
+	assert.Equal(t, "brave.mkv", result[0].Title)
+}
+
+func TestSearch(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+
+	result, err := c.Search(context.Background(), "UP", MovieQuery{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, "/movies/up.mkv", result[0].File)
+}
+
+func TestPlayAndEnqueue(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+	ctx := context.Background()
+
+	status, err := c.PlayMovie(ctx, api.Playback{File: "/movies/brave.mkv", Position: 60})
+	assert.Nil(t, err)
+	assert.Equal(t, api.PlayerStatus{File: "/movies/brave.mkv", Position: 60}, status)
+
+	queue, err := c.Enqueue(ctx, []string{"/movies/up.mkv"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/movies/brave.mkv", "/movies/up.mkv"}, queue)
+
+	status, err = c.Player("").Pause(ctx)
+	assert.Nil(t, err)
+	assert.True(t, status.Paused)
+	assert.Equal(t, []string{"play /movies/brave.mkv", "pause"}, server.Player.Actions())
+}
+
+func TestPlayerOfHost(t *testing.T) {
+	bedroom, _ := newServer(nil)
+	defer bedroom.Close()
+	conf := testConfig()
+	conf.Peers = map[string]string{"bedroom": bedroom.URL}
+	server, c := newServer(conf)
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err := c.PlayMovie(ctx, api.Playback{File: "/movies/up.mkv", Host: "bedroom"})
+	assert.Nil(t, err)
+	status, err := c.Player("bedroom").Pause(ctx)
+
+	assert.Nil(t, err)
+	assert.True(t, status.Paused)
+	assert.Equal(t, []string{"play /movies/up.mkv", "pause"}, bedroom.Player.Actions())
+	assert.Empty(t, server.Player.Actions())
+}
+
+func TestLabels(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+	ctx := context.Background()
+
+	m, err := c.AddLabel(ctx, "tags", "sci fi", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sci fi"}, m.Tags)
+
+	err = c.DeleteLabel(ctx, "tags", "sci fi")
+	assert.Nil(t, err)
+	labelled, _ := server.Catalog.Get(2)
+	assert.Empty(t, labelled.Tags)
+}
+
+func TestAddTorrent(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+
+	err := c.AddTorrent(context.Background(), []byte("d8:announce"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("d8:announce")}, server.Torrent.Added())
+}
+
+func TestExportAndImport(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+
+	var buf bytes.Buffer
+	err := c.Export(context.Background(), &buf, "csv")
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "/movies/brave.mkv")
+
+	result, err := c.Import(context.Background(), &buf, "csv", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.Matched)
+	assert.Empty(t, result.Unmatched)
+}
+
+func TestErrorsOfServerAreTyped(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+	server.Catalog.AddDrive(api.Drive{Name: "archive", Attached: true})
+	ctx := context.Background()
+
+	err := c.DeleteLabel(ctx, "tags", "drama")
+	assert.Equal(t, &NotFoundError{Message: "unknown tags: drama"}, err)
+
+	_, err = c.PlayMovie(ctx, api.Playback{File: "/media/archive/cars.mkv"})
+	assert.Equal(t, &DriveOfflineError{Drive: "archive", Message: "mount drive archive to play /media/archive/cars.mkv"}, err)
+
+	_, err = c.Update(ctx, api.Movie{Id: 7, TMDbId: 98}, "")
+	assert.Equal(t, &Error{StatusCode: http.StatusBadRequest, Message: "unknown movie, id: 7, title: "}, err)
+
+	server.Services.Catalog.SetRestriction(denyAll{})
+	_, err = c.PlayMovie(ctx, api.Playback{File: "/movies/brave.mkv"})
+	assert.Equal(t, &ForbiddenError{Message: "movie /movies/brave.mkv is restricted by the active profile"}, err)
+}
+
+func TestRequestIsCancelledWithContext(t *testing.T) {
+	server, c := newServer(nil)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.PlayMovie(ctx, api.Playback{File: "/movies/brave.mkv"})
+
+	assert.NotNil(t, err)
+	assert.Empty(t, server.Player.Actions())
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (c *Client) Timers(ctx context.Context) (timers []api.Timer, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/timers", nil, &timers)
+	return
+}
+
+func (c *Client) Timer(ctx context.Context, id int) (t api.Timer, err error) {
+	err = c.call(ctx, http.MethodGet, timerPath(id), nil, &t)
+	return
+}
+
+func (c *Client) CreateTimer(ctx context.Context, t api.Timer) (created api.Timer, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/timers", t, &created)
+	return
+}
+
+func (c *Client) UpdateTimer(ctx context.Context, t api.Timer) (updated api.Timer, err error) {
+	err = c.call(ctx, http.MethodPut, timerPath(t.Id), t, &updated)
+	return
+}
+
+func (c *Client) DeleteTimer(ctx context.Context, id int) error {
+	return c.call(ctx, http.MethodDelete, timerPath(id), nil, nil)
+}
+
+func timerPath(id int) string {
+	return "/api/timers/" + strconv.Itoa(id)
+}
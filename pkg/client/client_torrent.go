@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// AddTorrent adds content of torrent file to torrent client of server.
+func (c *Client) AddTorrent(ctx context.Context, torrent []byte) error {
+	return c.call(ctx, http.MethodPost, "/api/torrent/add", api.TorrentFile{Content: base64.StdEncoding.EncodeToString(torrent)}, nil)
+}
+
+func (c *Client) Torrents(ctx context.Context) (downloads []api.TorrentDownload, err error) {
+	err = c.call(ctx, http.MethodGet, "/api/torrent/list", nil, &downloads)
+	return
+}
+
+func (c *Client) StopTorrent(ctx context.Context, d api.TorrentDownload) (stopped api.TorrentDownload, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/torrent/stop", d, &stopped)
+	return
+}
+
+func (c *Client) StartTorrent(ctx context.Context, d api.TorrentDownload) (started api.TorrentDownload, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/torrent/start", d, &started)
+	return
+}
+
+// DeleteTorrent removes download from torrent client and returns remaining downloads.
+func (c *Client) DeleteTorrent(ctx context.Context, d api.TorrentDownload) (downloads []api.TorrentDownload, err error) {
+	err = c.call(ctx, http.MethodPost, "/api/torrent/delete", d, &downloads)
+	return
+}
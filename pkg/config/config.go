@@ -2,23 +2,57 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/util"
 )
 
 type Config struct {
-	Dirs                  []string `json:"dirs"`
-	DetailsLangs          []string `json:"details_langs"`
-	TorrentRemoteCtrlAddr string   `json:"torrent_remote_ctrl_addr"`
-	TMDbApiKey            string   `json:"tmdb_api_key"`
-	TMDbPosterSmall       string   `json:"tmdb_poster_small"`
-	TMDbPosterLarge       string   `json:"tmdb_poster_large"`
-	VideoFileExts         []string `json:"video_file_exts"`
-	WebPort               int      `json:"web_port"`
+	AutoMount             bool                         `json:"auto_mount"`
+	CecEnabled            bool                         `json:"cec_enabled"`
+	CecDevice             string                       `json:"cec_device"`
+	CertificationCountry  string                       `json:"certification_country"`
+	Dirs                  []string                     `json:"dirs"`
+	DetailsLangs          []string                     `json:"details_langs"`
+	MaxRequestSize        int64                        `json:"max_request_size"`
+	MediaServer           bool                         `json:"media_server"`
+	MediaServerName       string                       `json:"media_server_name"`
+	OMDbApiKey            string                       `json:"omdb_api_key"`
+	Peers                 map[string]string            `json:"peers"`
+	Player                api.PlayerOptions            `json:"player"`
+	PlayerProfiles        map[string]api.PlayerOptions `json:"player_profiles"`
+	TorrentRemoteCtrlAddr string                       `json:"torrent_remote_ctrl_addr"`
+	TMDbApiKey            string                       `json:"tmdb_api_key"`
+	TMDbPosterSmall       string                       `json:"tmdb_poster_small"`
+	TMDbPosterLarge       string                       `json:"tmdb_poster_large"`
+	TMDbProfile           string                       `json:"tmdb_profile"`
+	VideoFileExts         []string                     `json:"video_file_exts"`
+	WebPort               int                          `json:"web_port"`
 }
 
+// LocalHost is name of this instance in the list of peers.
+const LocalHost = "local"
+
+var audioOutputs = []string{"hdmi", "local", "both", "alsa"}
+var subtitleAligns = []string{"left", "center"}
+var aspectModes = []string{"letterbox", "fill", "stretch"}
+
 func LoadConfig() (*Config, error) {
-	return loadConfig(filepath.Join(ConfDir(), "config.json"))
+	return loadConfig(ConfFile())
+}
+
+// LoadConfigFile reads and validates configuration from the given file instead of configuration directory.
+func LoadConfigFile(path string) (*Config, error) {
+	return loadConfig(path)
+}
+
+// ConfFile returns path to configuration file in configuration directory.
+func ConfFile() string {
+	return filepath.Join(ConfDir(), "config.json")
 }
 
 func loadConfig(path string) (conf *Config, err error) {
@@ -48,12 +82,68 @@ func loadConfig(path string) (conf *Config, err error) {
 	if conf.TMDbPosterLarge == "" {
 		conf.TMDbPosterLarge = "w500"
 	}
+	if conf.MaxRequestSize == 0 {
+		conf.MaxRequestSize = 16 << 20
+	}
+	if conf.MediaServerName == "" {
+		conf.MediaServerName = "gomovies"
+	}
+	if conf.CertificationCountry == "" {
+		conf.CertificationCountry = "US"
+	}
+	if conf.TMDbProfile == "" {
+		conf.TMDbProfile = "w185"
+	}
 	if len(conf.DetailsLangs) == 0 {
 		conf.DetailsLangs = []string{"en"}
 	}
+	if err = validatePlayerOptions("player", conf.Player); err != nil {
+		return
+	}
+	for name, profile := range conf.PlayerProfiles {
+		if err = validatePlayerOptions(fmt.Sprintf("player_profiles.%s", name), profile); err != nil {
+			return
+		}
+	}
+	for name, addr := range conf.Peers {
+		if name == "" || name == LocalHost {
+			err = fmt.Errorf("peers: invalid peer name '%s'", name)
+			return
+		}
+		if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+			err = fmt.Errorf("peers.%s: invalid address '%s', http or https url expected", name, addr)
+			return
+		}
+	}
 	return
 }
 
+// ValidatePlayerOptions checks launch options of video player which may come from configuration or from catalog.
+func ValidatePlayerOptions(opts api.PlayerOptions) error {
+	return validatePlayerOptions("player_options", opts)
+}
+
+func validatePlayerOptions(name string, opts api.PlayerOptions) error {
+	// alsa output may be followed by device name, e.g. alsa:hw:1,0
+	output := strings.SplitN(opts.AudioOutput, ":", 2)[0]
+	if output != "" && !util.Contains(audioOutputs, output) {
+		return fmt.Errorf("%s: invalid audio output '%s', supported: %s", name, opts.AudioOutput, strings.Join(audioOutputs, ", "))
+	}
+	if opts.SubtitleAlign != "" && !util.Contains(subtitleAligns, opts.SubtitleAlign) {
+		return fmt.Errorf("%s: invalid subtitle align '%s', supported: %s", name, opts.SubtitleAlign, strings.Join(subtitleAligns, ", "))
+	}
+	if opts.AspectMode != "" && !util.Contains(aspectModes, opts.AspectMode) {
+		return fmt.Errorf("%s: invalid aspect mode '%s', supported: %s", name, opts.AspectMode, strings.Join(aspectModes, ", "))
+	}
+	if opts.SubtitleFontSize < 0 {
+		return fmt.Errorf("%s: subtitle font size must not be negative", name)
+	}
+	if opts.Display < 0 {
+		return fmt.Errorf("%s: display number must not be negative", name)
+	}
+	return nil
+}
+
 func ConfDir() string {
 	confDir := os.Getenv("GO_MOVIES_HOME")
 	if confDir == "" {
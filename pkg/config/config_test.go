@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
 )
 
 func TestConfigDirPathNonEmpty(t *testing.T) {
@@ -65,6 +67,17 @@ func TestConfigHasDefaultTMDbPosterLarge(t *testing.T) {
 	assert.Equal(t, "w500", config.TMDbPosterLarge)
 }
 
+func TestConfigHasDefaultMaxRequestSize(t *testing.T) {
+	dir := os.Getenv("TMPDIR")
+	configPath := filepath.Join(dir, "config.json")
+	mustCreateConfigFileWithContent("{}", configPath)
+
+	config, err := loadConfig(configPath)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(16<<20), config.MaxRequestSize)
+}
+
 func TestConfigureConfigDirWithEnvVariable(t *testing.T) {
 	dir := filepath.Join(os.Getenv("TMPDIR"), "somewhere")
 	err := os.Setenv("GO_MOVIES_HOME", dir)
@@ -112,6 +125,45 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, []string{"ua"}, config.DetailsLangs)
 }
 
+func TestLoadPlayerOptions(t *testing.T) {
+	json := `{
+		"player": {"audio_output": "alsa:hw:1,0", "subtitle_font_size": 40, "subtitle_align": "left", "aspect_mode": "fill", "display": 5},
+		"player_profiles": {"surround": {"audio_output": "hdmi", "passthrough": true}}
+	}`
+	dir := os.Getenv("TMPDIR")
+	configPath := filepath.Join(dir, "config.json")
+	mustCreateConfigFileWithContent(json, configPath)
+
+	config, err := loadConfig(configPath)
+
+	passthrough := true
+	assert.Nil(t, err)
+	assert.Equal(t, api.PlayerOptions{AudioOutput: "alsa:hw:1,0", SubtitleFontSize: 40, SubtitleAlign: "left", AspectMode: "fill", Display: 5}, config.Player)
+	assert.Equal(t, map[string]api.PlayerOptions{"surround": {AudioOutput: "hdmi", Passthrough: &passthrough}}, config.PlayerProfiles)
+}
+
+func TestLoadConfigFailsWhenPlayerOptionsInvalid(t *testing.T) {
+	dir := os.Getenv("TMPDIR")
+	configPath := filepath.Join(dir, "config.json")
+	mustCreateConfigFileWithContent(`{"player_profiles": {"tv": {"audio_output": "spdif"}}}`, configPath)
+
+	_, err := loadConfig(configPath)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "player_profiles.tv: invalid audio output 'spdif', supported: hdmi, local, both, alsa", err.Error())
+}
+
+func TestLoadConfigFailsWhenPeerAddressInvalid(t *testing.T) {
+	dir := os.Getenv("TMPDIR")
+	configPath := filepath.Join(dir, "config.json")
+	mustCreateConfigFileWithContent(`{"peers": {"bedroom": "192.168.0.20:8000"}}`, configPath)
+
+	_, err := loadConfig(configPath)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "peers.bedroom: invalid address '192.168.0.20:8000', http or https url expected", err.Error())
+}
+
 func mustCreateConfigFileWithContent(content, configPath string) {
 	if err := ioutil.WriteFile(configPath, []byte(content), 0644); err != nil {
 		log.Fatal(err)
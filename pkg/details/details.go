@@ -10,27 +10,41 @@ import (
 	"path/filepath"
 )
 
+// NotFoundError is returned when there are no local files with details of movie.
+type NotFoundError struct {
+	Path string
+	Lang string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("there is no details for movie %s, lang %s", e.Path, e.Lang)
+}
+
+// GetDetails reads details of movie from local files. Details are looked for in our own JSON files, either dedicated
+// to movie or shared by all movies in directory, and then in Kodi NFO file. Artwork found next to movie file is
+// preferred over posters referenced in details.
 func GetDetails(path, lang string) (mov api.MovieDetails, err error) {
 	detailsFile := fmt.Sprintf("%s.%s.%s", path, lang, "json")
 	exists := false
 	if exists, err = file.Exists(detailsFile); exists && err == nil {
 		mov, err = loadDetails(detailsFile)
-		if err == nil {
-			log.WithFields(log.Fields{"movie_path": path, "title": mov.OriginalTitle, "lang": lang, "file": detailsFile}).Info("Found details in local file")
-		}
 	} else if err == nil {
 		detailsFile = filepath.Join(filepath.Dir(path), fmt.Sprintf("gomovies-details.%s.json", lang))
 		if exists, err = file.Exists(detailsFile); exists && err == nil {
 			mov, err = loadDetails(detailsFile)
-			if err == nil {
-				log.WithFields(log.Fields{"movie_path": path, "title": mov.OriginalTitle, "lang": lang, "file": detailsFile}).Info("Found details in local file")
+		} else if err == nil {
+			if detailsFile, exists, err = findNfo(path); exists && err == nil {
+				mov, err = loadNfo(detailsFile)
 			}
 		}
 	}
 	if err != nil {
 		log.WithFields(log.Fields{"movie_path": path, "lang": lang, "err": err}).Error("Error occurred while loading details from local file")
 	} else if !exists {
-		err = fmt.Errorf("there is no details for movie %s, lang %s", path, lang)
+		err = &NotFoundError{Path: path, Lang: lang}
+	} else {
+		addLocalArtwork(path, &mov)
+		log.WithFields(log.Fields{"movie_path": path, "title": mov.OriginalTitle, "lang": lang, "file": detailsFile}).Info("Found details in local file")
 	}
 	return
 }
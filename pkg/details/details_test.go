@@ -3,6 +3,7 @@ package details
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -93,3 +94,110 @@ func mustRemoveFiles(paths ...string) {
 		}
 	}
 }
+
+func TestGetDetailsFromKodiNfo(t *testing.T) {
+	dir := filepath.Join(moviesDir, "brave")
+	mustCreateDir(dir)
+	nfo := filepath.Join(dir, "movie.nfo")
+	poster := filepath.Join(dir, "poster.jpg")
+	mustWriteFile(nfo, `<?xml version="1.0" encoding="UTF-8" standalone="yes" ?>
+<movie>
+  <title>Brave</title>
+  <plot>Determined to make her own path in life, Princess Merida defies a custom...</plot>
+  <runtime>93</runtime>
+  <year>2012</year>
+  <genre>Animation</genre>
+  <genre>Adventure</genre>
+  <uniqueid type="imdb">tt1217209</uniqueid>
+  <uniqueid type="tmdb" default="true">62177</uniqueid>
+  <actor>
+    <name>Kelly Macdonald</name>
+    <role>Merida (voice)</role>
+  </actor>
+</movie>`)
+	mustWriteFile(poster, "")
+	defer func() { mustRemoveFiles(nfo, poster) }()
+
+	md, err := GetDetails(filepath.Join(dir, "brave.mkv"), "en")
+
+	assert.Nil(t, err)
+	posterUrl := "/file" + filepath.ToSlash(poster)
+	expected := api.MovieDetails{
+		Cast:           []api.Actor{{Name: "Kelly Macdonald", Character: "Merida (voice)"}},
+		Genres:         []string{"Animation", "Adventure"},
+		ImdbId:         "tt1217209",
+		OriginalTitle:  "Brave",
+		Overview:       "Determined to make her own path in life, Princess Merida defies a custom...",
+		PosterSmallUrl: posterUrl,
+		PosterLargeUrl: posterUrl,
+		ReleaseDate:    "2012",
+		Runtime:        93,
+		Title:          "Brave",
+		TMDbId:         62177,
+	}
+	assert.Equal(t, expected, md)
+}
+
+func TestWriteNfo(t *testing.T) {
+	dir := filepath.Join(moviesDir, "gladiator")
+	mustCreateDir(dir)
+	movie := filepath.Join(dir, "gladiator.mkv")
+	defer func() { mustRemoveFiles(NfoFile(movie)) }()
+	md := api.MovieDetails{
+		Cast:           []api.Actor{{Name: "Russell Crowe", Character: "Maximus"}},
+		Certification:  "R",
+		CollectionName: "Gladiator Collection",
+		Directors:      []api.Crew{{Name: "Ridley Scott", Job: "Director"}},
+		Genres:         []string{"Action", "Drama"},
+		ImdbId:         "tt0172495",
+		OriginalTitle:  "Gladiator",
+		Overview:       "In the year 180, the death of emperor Marcus Aurelius throws the Roman Empire into chaos...",
+		ReleaseDate:    "2000-05-01",
+		Runtime:        155,
+		Title:          "Gladiator",
+		TMDbId:         98,
+		Trailers:       []string{"owK1qxDselE"},
+		VoteAverage:    8.2,
+		VoteCount:      15000,
+		Writers:        []api.Crew{{Name: "David Franzoni", Job: "Writer"}},
+	}
+
+	err := WriteNfo(movie, md, false)
+	assert.Nil(t, err)
+	loaded, err := GetDetails(movie, "en")
+
+	assert.Nil(t, err)
+	assert.Equal(t, md, loaded)
+}
+
+func TestWriteNfoDoesNotOverwriteExistingFile(t *testing.T) {
+	dir := filepath.Join(moviesDir, "brave")
+	mustCreateDir(dir)
+	movie := filepath.Join(dir, "brave.mkv")
+	nfo := NfoFile(movie)
+	defer func() { mustRemoveFiles(nfo, nfo+".1") }()
+	mustWriteFile(nfo, "<movie><title>Brave</title></movie>")
+
+	err := WriteNfo(movie, api.MovieDetails{Title: "Brave (2012)"}, false)
+
+	assert.Equal(t, &NfoExistsError{File: nfo}, err)
+	loaded, err := GetDetails(movie, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "Brave", loaded.Title)
+
+	err = WriteNfo(movie, api.MovieDetails{Title: "Brave (2012)"}, true)
+
+	assert.Nil(t, err)
+	loaded, err = GetDetails(movie, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "Brave (2012)", loaded.Title)
+	backup, err := ioutil.ReadFile(nfo + ".1")
+	assert.Nil(t, err)
+	assert.Equal(t, "<movie><title>Brave</title></movie>", string(backup))
+}
+
+func mustWriteFile(path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,284 @@
+package details
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/file"
+)
+
+// nfoMovie is movie description in format of Kodi (XBMC) NFO file.
+type nfoMovie struct {
+	XMLName       xml.Name      `xml:"movie"`
+	Title         string        `xml:"title"`
+	OriginalTitle string        `xml:"originaltitle,omitempty"`
+	Plot          string        `xml:"plot,omitempty"`
+	Tagline       string        `xml:"tagline,omitempty"`
+	Runtime       int           `xml:"runtime,omitempty"`
+	Year          int           `xml:"year,omitempty"`
+	Premiered     string        `xml:"premiered,omitempty"`
+	Id            string        `xml:"id,omitempty"`
+	TMDbId        int           `xml:"tmdbid,omitempty"`
+	ImdbId        string        `xml:"imdbid,omitempty"`
+	UniqueIds     []nfoUniqueId `xml:"uniqueid"`
+	Set           *nfoSet       `xml:"set"`
+	Genres        []string      `xml:"genre"`
+	Countries     []string      `xml:"country"`
+	Studios       []string      `xml:"studio"`
+	Directors     []string      `xml:"director"`
+	Credits       []string      `xml:"credits"`
+	Mpaa          string        `xml:"mpaa,omitempty"`
+	Ratings       []nfoRating   `xml:"ratings>rating"`
+	Trailer       string        `xml:"trailer,omitempty"`
+	Thumbs        []nfoThumb    `xml:"thumb"`
+	Actors        []nfoActor    `xml:"actor"`
+}
+
+// nfoSet is collection which movie belongs to.
+type nfoSet struct {
+	Name string `xml:"name"`
+}
+
+type nfoRating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr,omitempty"`
+	Default bool    `xml:"default,attr,omitempty"`
+	Value   float64 `xml:"value"`
+	Votes   int     `xml:"votes,omitempty"`
+}
+
+// youTubeTrailer is trailer url which is played by YouTube add-on of Kodi.
+const youTubeTrailer = "plugin://plugin.video.youtube/?action=play_video&videoid="
+
+type nfoUniqueId struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+type nfoThumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	URL    string `xml:",chardata"`
+}
+
+type nfoActor struct {
+	Name  string `xml:"name"`
+	Role  string `xml:"role,omitempty"`
+	Thumb string `xml:"thumb,omitempty"`
+}
+
+// NfoFile returns path of NFO file which Kodi uses for movie file, e.g. "/movies/brave.nfo" for "/movies/brave.mkv".
+func NfoFile(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".nfo"
+}
+
+// NfoExistsError is returned when NFO file of movie already exists and is not allowed to be overwritten.
+type NfoExistsError struct {
+	File string
+}
+
+func (e *NfoExistsError) Error() string {
+	return fmt.Sprintf("NFO file %s already exists", e.File)
+}
+
+// WriteNfo saves details of movie in NFO file next to movie file. Existing NFO file is replaced only if overwrite is
+// true, its previous content is kept in "<name>.nfo.1".
+func WriteNfo(path string, md api.MovieDetails, overwrite bool) (err error) {
+	nfoFile := NfoFile(path)
+	if !overwrite {
+		var exists bool
+		if exists, err = file.Exists(nfoFile); err != nil {
+			return
+		} else if exists {
+			return &NfoExistsError{File: nfoFile}
+		}
+	}
+	var data []byte
+	if data, err = MarshalNfo(md); err != nil {
+		return
+	}
+	return file.WriteAtomic(nfoFile, 0644, 1, func(w io.Writer) error {
+		_, e := w.Write(data)
+		return e
+	})
+}
+
+// MarshalNfo creates NFO document which describes movie.
+func MarshalNfo(md api.MovieDetails) ([]byte, error) {
+	nfo := nfoMovie{
+		Title:         md.Title,
+		OriginalTitle: md.OriginalTitle,
+		Plot:          md.Overview,
+		Tagline:       md.TagLine,
+		Runtime:       md.Runtime,
+		Premiered:     md.ReleaseDate,
+		TMDbId:        md.TMDbId,
+		ImdbId:        md.ImdbId,
+		Genres:        md.Genres,
+		Countries:     md.Countries,
+		Studios:       md.Companies,
+		Mpaa:          md.Certification,
+	}
+	if md.CollectionName != "" {
+		nfo.Set = &nfoSet{Name: md.CollectionName}
+	}
+	if md.VoteCount > 0 {
+		nfo.Ratings = []nfoRating{{Name: "themoviedb", Max: 10, Default: true, Value: md.VoteAverage, Votes: md.VoteCount}}
+	}
+	if len(md.Trailers) > 0 {
+		nfo.Trailer = youTubeTrailer + md.Trailers[0]
+	}
+	if len(md.ReleaseDate) >= 4 {
+		nfo.Year, _ = strconv.Atoi(md.ReleaseDate[:4])
+	}
+	if md.TMDbId != 0 {
+		nfo.UniqueIds = append(nfo.UniqueIds, nfoUniqueId{Type: "tmdb", Default: true, Value: strconv.Itoa(md.TMDbId)})
+	}
+	if md.ImdbId != "" {
+		nfo.UniqueIds = append(nfo.UniqueIds, nfoUniqueId{Type: "imdb", Value: md.ImdbId})
+	}
+	if md.PosterLargeUrl != "" && !strings.HasPrefix(md.PosterLargeUrl, "/") {
+		nfo.Thumbs = append(nfo.Thumbs, nfoThumb{Aspect: "poster", URL: md.PosterLargeUrl})
+	}
+	for _, c := range md.Directors {
+		nfo.Directors = append(nfo.Directors, c.Name)
+	}
+	for _, c := range md.Writers {
+		nfo.Credits = append(nfo.Credits, c.Name)
+	}
+	for _, a := range md.Cast {
+		nfo.Actors = append(nfo.Actors, nfoActor{Name: a.Name, Role: a.Character, Thumb: a.ProfileUrl})
+	}
+	data, err := xml.MarshalIndent(nfo, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func loadNfo(filename string) (md api.MovieDetails, err error) {
+	var f *os.File
+	if f, err = os.Open(filename); err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := f.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	var nfo nfoMovie
+	if err = xml.NewDecoder(f).Decode(&nfo); err != nil {
+		err = fmt.Errorf("invalid NFO file %s: %s", filename, err)
+		return
+	}
+	md = api.MovieDetails{
+		Title:         nfo.Title,
+		OriginalTitle: nfo.OriginalTitle,
+		Overview:      nfo.Plot,
+		TagLine:       nfo.Tagline,
+		Runtime:       nfo.Runtime,
+		ReleaseDate:   nfo.Premiered,
+		TMDbId:        nfo.TMDbId,
+		ImdbId:        nfo.ImdbId,
+		Genres:        nfo.Genres,
+		Countries:     nfo.Countries,
+		Companies:     nfo.Studios,
+		Certification: strings.TrimPrefix(nfo.Mpaa, "Rated "),
+	}
+	if nfo.Set != nil {
+		md.CollectionName = strings.TrimSpace(nfo.Set.Name)
+	}
+	for _, r := range nfo.Ratings {
+		if r.Default || md.VoteCount == 0 {
+			md.VoteAverage = r.Value
+			md.VoteCount = r.Votes
+		}
+	}
+	if strings.HasPrefix(nfo.Trailer, youTubeTrailer) {
+		md.Trailers = []string{strings.TrimPrefix(nfo.Trailer, youTubeTrailer)}
+	}
+	if md.OriginalTitle == "" {
+		md.OriginalTitle = nfo.Title
+	}
+	if md.ReleaseDate == "" && nfo.Year != 0 {
+		md.ReleaseDate = strconv.Itoa(nfo.Year)
+	}
+	for _, id := range nfo.UniqueIds {
+		switch id.Type {
+		case "tmdb":
+			if md.TMDbId == 0 {
+				md.TMDbId, _ = strconv.Atoi(strings.TrimSpace(id.Value))
+			}
+		case "imdb":
+			if md.ImdbId == "" {
+				md.ImdbId = strings.TrimSpace(id.Value)
+			}
+		}
+	}
+	if md.ImdbId == "" && strings.HasPrefix(nfo.Id, "tt") {
+		md.ImdbId = nfo.Id
+	}
+	for _, t := range nfo.Thumbs {
+		if (t.Aspect == "poster" || t.Aspect == "") && md.PosterLargeUrl == "" {
+			md.PosterLargeUrl = strings.TrimSpace(t.URL)
+			md.PosterSmallUrl = md.PosterLargeUrl
+		}
+	}
+	for _, name := range nfo.Directors {
+		md.Directors = append(md.Directors, api.Crew{Name: name, Job: "Director"})
+	}
+	for _, name := range nfo.Credits {
+		md.Writers = append(md.Writers, api.Crew{Name: name, Job: "Writer"})
+	}
+	for _, a := range nfo.Actors {
+		md.Cast = append(md.Cast, api.Actor{Name: a.Name, Character: a.Role, ProfileUrl: a.Thumb})
+	}
+	return
+}
+
+// findNfo looks for NFO file of movie, Kodi accepts either file with the same name as movie file or "movie.nfo" in
+// directory of movie.
+func findNfo(path string) (string, bool, error) {
+	for _, nfo := range []string{NfoFile(path), filepath.Join(filepath.Dir(path), "movie.nfo")} {
+		if exists, err := file.Exists(nfo); err != nil || exists {
+			return nfo, exists, err
+		}
+	}
+	return "", false, nil
+}
+
+// addLocalArtwork sets poster and backdrop if artwork is found next to movie file. Kodi names artwork either after
+// movie file, e.g. "brave-poster.jpg", or after its type, e.g. "poster.jpg". Local artwork is served by "/file/"
+// handler.
+func addLocalArtwork(path string, md *api.MovieDetails) {
+	if poster := findArtwork(path, "poster", "folder"); poster != "" {
+		md.PosterSmallUrl = poster
+		md.PosterLargeUrl = poster
+	}
+	if fanart := findArtwork(path, "fanart"); fanart != "" {
+		md.BackdropUrl = fanart
+	}
+}
+
+func findArtwork(path string, kinds ...string) string {
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, kind := range kinds {
+		for _, ext := range []string{".jpg", ".png"} {
+			for _, name := range []string{base + "-" + kind + ext, kind + ext} {
+				candidate := filepath.Join(dir, name)
+				if exists, err := file.Exists(candidate); exists && err == nil {
+					return "/file" + (&url.URL{Path: candidate}).EscapedPath()
+				}
+			}
+		}
+	}
+	return ""
+}
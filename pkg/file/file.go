@@ -2,7 +2,13 @@ package file
 
 import (
 	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 func Exists(path string) (bool, error) {
@@ -35,3 +41,189 @@ func ReadLines(file string, lineHandler func(string) bool) (err error) {
 	}
 	return
 }
+
+// hashedBytes is size of head and tail of file which are hashed by PartialHash.
+const hashedBytes = 64 * 1024
+
+// PartialHash identifies content of file by its size and hash of its head and tail, it is cheap enough for big video
+// files and different copies of the same file get the same hash.
+func PartialHash(path string) (hash string, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := f.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	var info os.FileInfo
+	if info, err = f.Stat(); err != nil {
+		return
+	}
+	h := sha1.New()
+	if _, err = io.CopyN(h, f, hashedBytes); err != nil && err != io.EOF {
+		return
+	}
+	if info.Size() > hashedBytes {
+		if _, err = f.Seek(-min(info.Size()-hashedBytes, hashedBytes), io.SeekEnd); err != nil {
+			return
+		}
+		if _, err = io.Copy(h, f); err != nil {
+			return
+		}
+	}
+	err = nil
+	hash = fmt.Sprintf("%d-%x", info.Size(), h.Sum(nil))
+	return
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Backups configures previous versions of file kept by WriteAtomicWithBackups.
+type Backups struct {
+	// Count is number of kept versions, zero disables backups
+	Count int
+	// Interval is minimal age of the newest backup, file is replaced without backup until the newest backup gets older
+	Interval time.Duration
+	// Valid checks old content before it is kept, e.g. corrupted file must not push out good backups
+	Valid func(r io.Reader) bool
+}
+
+// WriteAtomic replaces content of file so that file contains either old or new content even after crash or power cut.
+// New content is written to temporary file in the same directory, synced to disk and renamed over file. If backups is
+// greater than zero, old content is kept in files "<path>.1" (the newest) ... "<path>.<backups>".
+func WriteAtomic(path string, perm os.FileMode, backups int, write func(w io.Writer) error) error {
+	return WriteAtomicWithBackups(path, perm, Backups{Count: backups}, write)
+}
+
+// WriteAtomicWithBackups is WriteAtomic which keeps old content only if it is valid and the newest backup is old enough.
+func WriteAtomicWithBackups(path string, perm os.FileMode, backups Backups, write func(w io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	var f *os.File
+	if f, err = ioutil.TempFile(dir, filepath.Base(path)+".tmp"); err != nil {
+		return
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+	if err = write(f); err == nil {
+		err = f.Sync()
+	}
+	if clsErr := f.Close(); clsErr != nil && err == nil {
+		err = clsErr
+	}
+	if err != nil {
+		return
+	}
+	if err = os.Chmod(tmp, perm); err != nil {
+		return
+	}
+	if backups.Count > 0 {
+		var rotate bool
+		if rotate, err = backupNeeded(path, backups); err != nil {
+			return
+		}
+		if rotate {
+			if err = rotateBackups(path, backups.Count); err != nil {
+				return
+			}
+		}
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return
+	}
+	return syncDir(dir)
+}
+
+// Backup returns name of n-th backup of file created by WriteAtomic, the first one is the newest.
+func Backup(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// backupNeeded checks whether current content of file should be kept as the newest backup.
+func backupNeeded(path string, backups Backups) (bool, error) {
+	if exists, err := Exists(path); err != nil || !exists {
+		return false, err
+	}
+	if backups.Interval > 0 {
+		info, err := os.Stat(Backup(path, 1))
+		if err == nil && time.Since(info.ModTime()) < backups.Interval {
+			return false, nil
+		} else if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	if backups.Valid == nil {
+		return true, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return backups.Valid(f), nil
+}
+
+func rotateBackups(path string, backups int) error {
+	if exists, err := Exists(path); err != nil || !exists {
+		return err
+	}
+	for n := backups - 1; n > 0; n-- {
+		if err := os.Rename(Backup(path, n), Backup(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// file itself stays in place until new content is renamed over it, so there is no moment when file is missing
+	newest := Backup(path, 1)
+	if err := os.Remove(newest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(path, newest); err == nil {
+		return nil
+	}
+	// file system may not support hard links
+	return copyFile(path, newest)
+}
+
+func copyFile(src, dst string) (err error) {
+	var in, out *os.File
+	if in, err = os.Open(src); err != nil {
+		return
+	}
+	defer in.Close()
+	var info os.FileInfo
+	if info, err = in.Stat(); err != nil {
+		return
+	}
+	if out, err = os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode()); err != nil {
+		return
+	}
+	if _, err = io.Copy(out, in); err == nil {
+		err = out.Sync()
+	}
+	if clsErr := out.Close(); clsErr != nil && err == nil {
+		err = clsErr
+	}
+	return
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if clsErr := d.Close(); clsErr != nil && err == nil {
+		err = clsErr
+	}
+	return err
+}
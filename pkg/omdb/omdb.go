@@ -0,0 +1,91 @@
+package omdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/util"
+)
+
+// Movie is response of The Open Movie Database (OMDb), unknown values are "N/A".
+type Movie struct {
+	Title      string `json:"Title"`
+	Year       string `json:"Year"`
+	Rated      string `json:"Rated"`
+	Released   string `json:"Released"`
+	Runtime    string `json:"Runtime"`
+	Genre      string `json:"Genre"`
+	Director   string `json:"Director"`
+	Writer     string `json:"Writer"`
+	Actors     string `json:"Actors"`
+	Plot       string `json:"Plot"`
+	Country    string `json:"Country"`
+	Poster     string `json:"Poster"`
+	ImdbRating string `json:"imdbRating"`
+	ImdbVotes  string `json:"imdbVotes"`
+	ImdbId     string `json:"imdbID"`
+	Response   string `json:"Response"`
+	Error      string `json:"Error"`
+}
+
+// NotAvailable is value of field which is unknown to OMDb.
+const NotAvailable = "N/A"
+
+const baseUrl = "https://www.omdbapi.com/"
+
+// requestInterval keeps requests under the limit of free OMDb plan.
+const requestInterval = 100 * time.Millisecond
+
+type OMDb struct {
+	apiKey  string
+	apiUrl  string
+	client  *http.Client
+	limiter *util.RateLimiter
+}
+
+func CreateOMDb(apiKey string) *OMDb {
+	return CreateOMDbWithUrl(apiKey, baseUrl)
+}
+
+// CreateOMDbWithUrl creates client of OMDb compatible API at specified url.
+func CreateOMDbWithUrl(apiKey, apiUrl string) *OMDb {
+	return &OMDb{
+		apiKey:  apiKey,
+		apiUrl:  apiUrl,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		limiter: util.CreateRateLimiter(requestInterval),
+	}
+}
+
+// GetMovie returns movie by IMDb id, e.g. "tt0111161".
+func (o *OMDb) GetMovie(imdbId string) (mov Movie, err error) {
+	o.limiter.Wait()
+	reqUrl := fmt.Sprintf("%s?apikey=%s&i=%s&plot=full", o.apiUrl, url.QueryEscape(o.apiKey), url.QueryEscape(imdbId))
+	var resp *http.Response
+	if resp, err = o.client.Get(reqUrl); err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil {
+			log.Warn(clsErr)
+		}
+	}()
+	if resp.StatusCode/100 != 2 {
+		err = fmt.Errorf("error: %s", resp.Status)
+		return
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&mov); err != nil {
+		return
+	}
+	if mov.Response != "True" {
+		err = fmt.Errorf("error: %s", mov.Error)
+		return
+	}
+	log.WithFields(log.Fields{"imdb_id": imdbId, "title": mov.Title}).Info("Found details in OMDb")
+	return
+}
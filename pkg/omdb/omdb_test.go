@@ -0,0 +1,72 @@
+package omdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMovie(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "123", r.URL.Query().Get("apikey"))
+		assert.Equal(t, "tt0112573", r.URL.Query().Get("i"))
+		_, _ = w.Write([]byte(movieResponse))
+	}))
+	defer srv.Close()
+
+	mov, err := CreateOMDbWithUrl("123", srv.URL).GetMovie("tt0112573")
+
+	assert.Nil(t, err)
+	expected := Movie{
+		Title:      "Braveheart",
+		Year:       "1995",
+		Rated:      "R",
+		Released:   "24 May 1995",
+		Runtime:    "178 min",
+		Genre:      "Biography, Drama, History",
+		Director:   "Mel Gibson",
+		Writer:     "Randall Wallace",
+		Actors:     "Mel Gibson, Sophie Marceau",
+		Plot:       "Scottish warrior William Wallace leads his countrymen in a rebellion.",
+		Country:    "United States",
+		Poster:     NotAvailable,
+		ImdbRating: "8.3",
+		ImdbVotes:  "1,076,018",
+		ImdbId:     "tt0112573",
+		Response:   "True",
+	}
+	assert.Equal(t, expected, mov)
+}
+
+func TestGetMovieError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Response":"False","Error":"Incorrect IMDb ID."}`))
+	}))
+	defer srv.Close()
+
+	_, err := CreateOMDbWithUrl("123", srv.URL).GetMovie("tt0")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "error: Incorrect IMDb ID.", err.Error())
+}
+
+const movieResponse = `{
+  "Title": "Braveheart",
+  "Year": "1995",
+  "Rated": "R",
+  "Released": "24 May 1995",
+  "Runtime": "178 min",
+  "Genre": "Biography, Drama, History",
+  "Director": "Mel Gibson",
+  "Writer": "Randall Wallace",
+  "Actors": "Mel Gibson, Sophie Marceau",
+  "Plot": "Scottish warrior William Wallace leads his countrymen in a rebellion.",
+  "Country": "United States",
+  "Poster": "N/A",
+  "imdbRating": "8.3",
+  "imdbVotes": "1,076,018",
+  "imdbID": "tt0112573",
+  "Response": "True"
+}`
@@ -21,7 +21,7 @@ type Player interface {
 	NextSubtitle() error
 	Pause() error
 	Play() error
-	PlayMovie(path string) error
+	PlayMovie(path string, opts LaunchOptions) error
 	PlayPause() error
 	PreviousAudioTrack() error
 	PreviousSubtitle() error
@@ -41,6 +41,12 @@ type Player interface {
 	Observable
 }
 
+// LaunchOptions describes how player should start playing a movie.
+type LaunchOptions struct {
+	Position time.Duration
+	Options  api.PlayerOptions
+}
+
 type Observable interface {
 	AddListener(l PlayListener)
 }
@@ -57,3 +63,15 @@ type PlayListener interface {
 	StartPlayListener
 	StopPlayListener
 }
+
+// CrashPlayListener may be implemented by PlayListener to be notified when player exits unexpectedly.
+// StopPlay is called after CrashPlay.
+type CrashPlayListener interface {
+	CrashPlay(path string, err error)
+}
+
+// CompletePlayListener may be implemented by PlayListener to be notified when playback reaches end of file.
+// It is not called when playback is stopped by user. StopPlay is called after CompletePlay.
+type CompletePlayListener interface {
+	CompletePlay(path string)
+}
@@ -0,0 +1,267 @@
+package player
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/upnp"
+)
+
+const dlnaVolumeStep = 5
+
+var errNotSupportedByRenderer = errors.New("not supported by media renderer")
+
+// DLNAPlayer plays movies on UPnP MediaRenderer, e.g. smart TV. Renderer gets movie by url, see MediaURL. Selection of
+// audio tracks and subtitles is not supported. Listeners are notified only about playback started or stopped through
+// this player.
+type DLNAPlayer struct {
+	device    upnp.Device
+	client    *http.Client
+	mediaURL  MediaURL
+	mu        sync.Mutex
+	current   string
+	listeners []PlayListener
+}
+
+// MediaURL returns url which renderer may use to get movie file.
+type MediaURL func(path string) (string, error)
+
+func CreateDLNAPlayer(device upnp.Device, client *http.Client, mediaURL MediaURL) *DLNAPlayer {
+	return &DLNAPlayer{device: device, client: client, mediaURL: mediaURL}
+}
+
+func (p *DLNAPlayer) AudioTracks() ([]api.Stream, error) {
+	return []api.Stream{}, nil
+}
+
+func (p *DLNAPlayer) NextAudioTrack() error {
+	return errNotSupportedByRenderer
+}
+
+func (p *DLNAPlayer) NextSubtitle() error {
+	return errNotSupportedByRenderer
+}
+
+func (p *DLNAPlayer) Pause() error {
+	return p.transport("Pause")
+}
+
+func (p *DLNAPlayer) Play() error {
+	return p.transport("Play", upnp.Arg{Name: "Speed", Value: "1"})
+}
+
+func (p *DLNAPlayer) PlayMovie(path string, opts LaunchOptions) (err error) {
+	var u string
+	if u, err = p.mediaURL(path); err != nil {
+		return
+	}
+	if err = p.transport("SetAVTransportURI", upnp.Arg{Name: "CurrentURI", Value: u}, upnp.Arg{Name: "CurrentURIMetaData", Value: metadata(path, u)}); err != nil {
+		return
+	}
+	if err = p.Play(); err != nil {
+		return
+	}
+	if opts.Position > 0 {
+		if err = p.SetPosition(opts.Position); err != nil {
+			return
+		}
+	}
+	p.mu.Lock()
+	p.current = path
+	listeners := append([]PlayListener{}, p.listeners...)
+	p.mu.Unlock()
+	for _, l := range listeners {
+		l.StartPlay(path)
+	}
+	return
+}
+
+func (p *DLNAPlayer) PlayPause() error {
+	state, err := p.transportState()
+	if err != nil {
+		return err
+	}
+	if state == "PLAYING" {
+		return p.Pause()
+	}
+	return p.Play()
+}
+
+func (p *DLNAPlayer) PreviousAudioTrack() error {
+	return errNotSupportedByRenderer
+}
+
+func (p *DLNAPlayer) PreviousSubtitle() error {
+	return errNotSupportedByRenderer
+}
+
+func (p *DLNAPlayer) ReplayCurrent() error {
+	return p.SetPosition(0)
+}
+
+func (p *DLNAPlayer) Seek(offset time.Duration) error {
+	out, err := p.transportInfo("GetPositionInfo")
+	if err != nil {
+		return err
+	}
+	return p.SetPosition(upnp.ParseDuration(out["RelTime"]) + offset)
+}
+
+func (p *DLNAPlayer) SelectAudio(int) error {
+	return errNotSupportedByRenderer
+}
+
+func (p *DLNAPlayer) SelectSubtitle(int) error {
+	return errNotSupportedByRenderer
+}
+
+func (p *DLNAPlayer) SetPosition(position time.Duration) error {
+	return p.transport("Seek", upnp.Arg{Name: "Unit", Value: "REL_TIME"}, upnp.Arg{Name: "Target", Value: upnp.FormatDuration(position)})
+}
+
+func (p *DLNAPlayer) Status() (status api.PlayerStatus, err error) {
+	var state string
+	if state, err = p.transportState(); err != nil {
+		return
+	}
+	if state == "STOPPED" || state == "NO_MEDIA_PRESENT" {
+		status.Stopped = true
+		return
+	}
+	var out map[string]string
+	if out, err = p.transportInfo("GetPositionInfo"); err != nil {
+		return
+	}
+	p.mu.Lock()
+	status.File = p.current
+	p.mu.Unlock()
+	status.Paused = state == "PAUSED_PLAYBACK"
+	status.Duration = int(upnp.ParseDuration(out["TrackDuration"]) / time.Second)
+	status.Position = int(upnp.ParseDuration(out["RelTime"]) / time.Second)
+	if p.device.RenderingControlURL != "" {
+		status.Muted, err = p.muted()
+	}
+	return
+}
+
+func (p *DLNAPlayer) Stop() error {
+	if err := p.transport("Stop"); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	path := p.current
+	p.current = ""
+	listeners := append([]PlayListener{}, p.listeners...)
+	p.mu.Unlock()
+	if path != "" {
+		for _, l := range listeners {
+			l.StopPlay(path)
+		}
+	}
+	return nil
+}
+
+func (p *DLNAPlayer) Subtitles() ([]api.Stream, error) {
+	return []api.Stream{}, nil
+}
+
+func (p *DLNAPlayer) ToggleMute() error {
+	muted, err := p.muted()
+	if err != nil {
+		return err
+	}
+	mute := "1"
+	if muted {
+		mute = "0"
+	}
+	_, err = p.rendering("SetMute", upnp.Arg{Name: "Channel", Value: "Master"}, upnp.Arg{Name: "DesiredMute", Value: mute})
+	return err
+}
+
+func (p *DLNAPlayer) ToggleSubtitles() error {
+	return errNotSupportedByRenderer
+}
+
+// Volume returns volume in range from 0 to 1.
+func (p *DLNAPlayer) Volume() (float64, error) {
+	v, err := p.volume()
+	return float64(v) / 100, err
+}
+
+func (p *DLNAPlayer) VolumeDown() error {
+	return p.changeVolume(-dlnaVolumeStep)
+}
+
+func (p *DLNAPlayer) VolumeUp() error {
+	return p.changeVolume(dlnaVolumeStep)
+}
+
+func (p *DLNAPlayer) AddListener(l PlayListener) {
+	p.mu.Lock()
+	p.listeners = append(p.listeners, l)
+	p.mu.Unlock()
+}
+
+func (p *DLNAPlayer) changeVolume(delta int) error {
+	v, err := p.volume()
+	if err != nil {
+		return err
+	}
+	v += delta
+	if v < 0 {
+		v = 0
+	} else if v > 100 {
+		v = 100
+	}
+	_, err = p.rendering("SetVolume", upnp.Arg{Name: "Channel", Value: "Master"}, upnp.Arg{Name: "DesiredVolume", Value: strconv.Itoa(v)})
+	return err
+}
+
+func (p *DLNAPlayer) volume() (int, error) {
+	out, err := p.rendering("GetVolume", upnp.Arg{Name: "Channel", Value: "Master"})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out["CurrentVolume"])
+}
+
+func (p *DLNAPlayer) muted() (bool, error) {
+	out, err := p.rendering("GetMute", upnp.Arg{Name: "Channel", Value: "Master"})
+	if err != nil {
+		return false, err
+	}
+	return out["CurrentMute"] == "1" || out["CurrentMute"] == "true", nil
+}
+
+func (p *DLNAPlayer) transportState() (string, error) {
+	out, err := p.transportInfo("GetTransportInfo")
+	return out["CurrentTransportState"], err
+}
+
+func (p *DLNAPlayer) transport(action string, args ...upnp.Arg) error {
+	_, err := p.transportInfo(action, args...)
+	return err
+}
+
+func (p *DLNAPlayer) transportInfo(action string, args ...upnp.Arg) (map[string]string, error) {
+	args = append([]upnp.Arg{{Name: "InstanceID", Value: "0"}}, args...)
+	return upnp.Call(p.client, p.device.AVTransportURL, upnp.AVTransportService, action, args...)
+}
+
+func (p *DLNAPlayer) rendering(action string, args ...upnp.Arg) (map[string]string, error) {
+	if p.device.RenderingControlURL == "" {
+		return nil, errNotSupportedByRenderer
+	}
+	args = append([]upnp.Arg{{Name: "InstanceID", Value: "0"}}, args...)
+	return upnp.Call(p.client, p.device.RenderingControlURL, upnp.RenderingControlService, action, args...)
+}
+
+// metadata creates DIDL-Lite description of movie, some renderers refuse to play media without metadata.
+func metadata(path, u string) string {
+	return upnp.DIDL([]upnp.Object{{Id: "0", ParentId: "-1", Title: filepath.Base(path), URL: u}})
+}
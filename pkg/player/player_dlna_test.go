@@ -0,0 +1,83 @@
+package player
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/upnp"
+	"github.com/andrew00x/gomovies/pkg/upnp/upnptest"
+)
+
+func TestDLNAPlayMovie(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+	p := createTestDLNAPlayer(t, r)
+	l := &listenerMock{}
+	p.AddListener(l)
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{Position: time.Duration(90) * time.Second})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://192.168.0.10:8000/file/movies/gladiator.mkv", r.URI)
+	assert.Equal(t, "PLAYING", r.State)
+	assert.Equal(t, "0:01:30", r.Position)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv"}, l.all())
+}
+
+func TestDLNAStatus(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+	p := createTestDLNAPlayer(t, r)
+	assert.Nil(t, p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{}))
+	r.Position = "0:10:00"
+
+	assert.Nil(t, p.PlayPause())
+	status, err := p.Status()
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.PlayerStatus{File: "/movies/gladiator.mkv", Duration: 6000, Position: 600, Paused: true}, status)
+}
+
+func TestDLNASeekAndVolume(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+	p := createTestDLNAPlayer(t, r)
+	assert.Nil(t, p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{}))
+	r.Position = "0:10:00"
+
+	assert.Nil(t, p.Seek(time.Duration(-30)*time.Second))
+	assert.Nil(t, p.VolumeUp())
+	v, err := p.Volume()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "0:09:30", r.Position)
+	assert.Equal(t, 0.55, v)
+}
+
+func TestDLNAStop(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+	p := createTestDLNAPlayer(t, r)
+	l := &listenerMock{}
+	p.AddListener(l)
+	assert.Nil(t, p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{}))
+
+	assert.Nil(t, p.Stop())
+	status, err := p.Status()
+
+	assert.Nil(t, err)
+	assert.True(t, status.Stopped)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv", "stop /movies/gladiator.mkv"}, l.all())
+}
+
+func createTestDLNAPlayer(t *testing.T, r *upnptest.Renderer) *DLNAPlayer {
+	d, err := upnp.FetchDevice(http.DefaultClient, r.Location())
+	assert.Nil(t, err)
+	return CreateDLNAPlayer(d, http.DefaultClient, func(path string) (string, error) {
+		return "http://192.168.0.10:8000/file" + path, nil
+	})
+}
@@ -1,13 +1,17 @@
 package player
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/godbus/dbus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/andrew00x/gomovies/pkg/api"
@@ -15,35 +19,125 @@ import (
 	"github.com/andrew00x/omxcontrol"
 )
 
-type OMXPlayer struct {
-	process      *os.Process
-	control      *omxcontrol.OmxCtrl
-	listeners    []PlayListener
-	muted        bool
-	subtitlesOff bool
+type playerState int
+
+const (
+	stateIdle playerState = iota
+	stateStarting
+	statePlaying
+	stateStopping
+	stateCrashed
+)
+
+func (s playerState) String() string {
+	switch s {
+	case stateStarting:
+		return "starting"
+	case statePlaying:
+		return "playing"
+	case stateStopping:
+		return "stopping"
+	case stateCrashed:
+		return "crashed"
+	default:
+		return "idle"
+	}
 }
 
+// process is running instance of omxplayer.
+type process interface {
+	Pid() int
+	// Wait blocks until process exits, error is returned if process did not exit normally.
+	Wait() error
+	Terminate() error
+	Kill() error
+}
+
+// control is a subset of omxcontrol.OmxCtrl which is used by OMXPlayer.
+type control interface {
+	Action(action omxcontrol.KeyboardAction) error
+	AudioTracks() ([]omxcontrol.Stream, error)
+	CanControl() (bool, error)
+	Duration() (time.Duration, error)
+	Mute() error
+	Pause() error
+	Play() error
+	PlayPause() error
+	PlaybackStatus() (omxcontrol.Status, error)
+	Playing() (string, error)
+	Position() (time.Duration, error)
+	Seek(offset time.Duration) error
+	SelectAudio(index int) (bool, error)
+	SelectSubtitle(index int) (bool, error)
+	SetPosition(position time.Duration) error
+	Subtitles() ([]omxcontrol.Stream, error)
+	Unmute() error
+	Volume() (float64, error)
+}
+
+// playback holds state of single omxplayer process.
+type playback struct {
+	path    string
+	process process
+	// done is closed when process exits
+	done chan struct{}
+	// announced is set when StartPlay listeners are notified
+	announced bool
+}
+
+// OMXPlayer manages lifecycle of omxplayer process: idle -> starting -> playing -> stopping -> idle.
+// If process exits unexpectedly while playing player goes to crashed state and listeners are notified about crash.
+// All methods are safe for concurrent use.
+type OMXPlayer struct {
+	mu             sync.Mutex
+	state          playerState
+	current        *playback
+	control        control
+	listeners      []PlayListener
+	muted          bool
+	subtitlesOff   bool
+	startProcess   func(args []string) (process, error)
+	connectControl func() (control, error)
+	controlTimeout time.Duration
+	stopTimeout    time.Duration
+}
+
+const defaultControlTimeout = time.Duration(20) * time.Second
+const defaultStopTimeout = time.Duration(5) * time.Second
+const controlRetryMinDelay = time.Duration(100) * time.Millisecond
+const controlRetryMaxDelay = time.Second
+
 func init() {
 	playerFactory = func(conf *config.Config) (Player, error) {
-		return &OMXPlayer{}, nil
+		return createOMXPlayer(), nil
+	}
+}
+
+func createOMXPlayer() *OMXPlayer {
+	return &OMXPlayer{
+		startProcess:   startOmxplayer,
+		connectControl: connectOmxControl,
+		controlTimeout: defaultControlTimeout,
+		stopTimeout:    defaultStopTimeout,
 	}
 }
 
 var controlNotSetup = errors.New("omxplayer does not play anything at the moment or control is not setup")
 
 func (p *OMXPlayer) AddListener(l PlayListener) {
+	p.mu.Lock()
 	p.listeners = append(p.listeners, l)
+	p.mu.Unlock()
 }
 
 func (p *OMXPlayer) AudioTracks() (audios []api.Stream, err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
+	err = p.withControl(func(c control) (e error) {
 		var controlAudios []omxcontrol.Stream
-		controlAudios, err = control.AudioTracks()
-		if err == nil {
+		if controlAudios, e = c.AudioTracks(); e == nil {
 			audios = convertToApiStreams(controlAudios)
 		}
-	}
+		return
+	})
 	return
 }
 
@@ -55,63 +149,67 @@ func (p *OMXPlayer) NextSubtitle() error {
 	return p.action(omxcontrol.ActionNextSubtitle)
 }
 
-func (p *OMXPlayer) Pause() (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.Pause()
-	}
-	return
+func (p *OMXPlayer) Pause() error {
+	return p.withControl(func(c control) error { return c.Pause() })
 }
 
-func (p *OMXPlayer) Play() (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.Play()
-	}
-	return
+func (p *OMXPlayer) Play() error {
+	return p.withControl(func(c control) error { return c.Play() })
 }
 
-func (p *OMXPlayer) PlayMovie(path string) (err error) {
+func (p *OMXPlayer) PlayMovie(path string, opts LaunchOptions) (err error) {
 	if stpErr := p.Stop(); stpErr != nil {
 		log.WithFields(log.Fields{"err": stpErr}).Error("Error occurred while stopping player")
 	}
-	err = p.start(path)
-	if err == nil {
-		var control *omxcontrol.OmxCtrl
-		control, err = setupControl()
-		if err == nil {
-			p.control = control
-		} else {
-			log.WithFields(log.Fields{"err": err}).Error("Error occurred while setup DBus connection to omxplayer")
-			if stpErr := p.Stop(); stpErr != nil {
-				log.WithFields(log.Fields{"err": stpErr}).Error("Error occurred while trying to stop player after unsuccessful start")
-			}
-		}
+	var pb *playback
+	if pb, err = p.start(path, opts); err != nil {
+		return
 	}
-	if err == nil {
-		for _, l := range p.listeners {
-			l.StartPlay(path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.controlTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-pb.done:
+			cancel()
+		case <-ctx.Done():
 		}
-		go func() {
-			if _, waitErr := p.process.Wait(); waitErr != nil {
-				log.WithFields(log.Fields{"err": waitErr}).Error("Player process ended with error")
-			}
-			for _, l := range p.listeners {
-				l.StopPlay(path)
-			}
-		}()
+	}()
+	var c control
+	if c, err = p.setupControl(ctx); err != nil {
+		select {
+		case <-pb.done:
+			err = fmt.Errorf("omxplayer exited before control is setup, last error: %v", err)
+		default:
+		}
+		log.WithFields(log.Fields{"err": err}).Error("Error occurred while setup DBus connection to omxplayer")
+		if stpErr := p.Stop(); stpErr != nil {
+			log.WithFields(log.Fields{"err": stpErr}).Error("Error occurred while trying to stop player after unsuccessful start")
+		}
+		return
 	}
-	return
-}
 
-func (p *OMXPlayer) PlayPause() (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.PlayPause()
+	p.mu.Lock()
+	if p.current != pb || p.state != stateStarting {
+		p.mu.Unlock()
+		return fmt.Errorf("playback of %s was interrupted while starting", path)
+	}
+	p.control = c
+	p.state = statePlaying
+	pb.announced = true
+	listeners := p.listenersCopy()
+	p.mu.Unlock()
+
+	for _, l := range listeners {
+		l.StartPlay(path)
 	}
 	return
 }
 
+func (p *OMXPlayer) PlayPause() error {
+	return p.withControl(func(c control) error { return c.PlayPause() })
+}
+
 func (p *OMXPlayer) PreviousAudioTrack() error {
 	return p.action(omxcontrol.ActionPreviousAudio)
 }
@@ -120,144 +218,166 @@ func (p *OMXPlayer) PreviousSubtitle() error {
 	return p.action(omxcontrol.ActionPreviousSubtitle)
 }
 
-func (p *OMXPlayer) ReplayCurrent() (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.SetPosition(0)
-	}
-	return
+func (p *OMXPlayer) ReplayCurrent() error {
+	return p.withControl(func(c control) error { return c.SetPosition(0) })
 }
 
-func (p *OMXPlayer) Seek(offset time.Duration) (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.Seek(offset)
-	}
-	return
+func (p *OMXPlayer) Seek(offset time.Duration) error {
+	return p.withControl(func(c control) error { return c.Seek(offset) })
 }
 
-func (p *OMXPlayer) SelectAudio(index int) (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
+func (p *OMXPlayer) SelectAudio(index int) error {
+	return p.withControl(func(c control) (err error) {
 		var ok bool
-		if ok, err = control.SelectAudio(index); ok {
-		} else {
+		if ok, err = c.SelectAudio(index); err == nil && !ok {
 			err = fmt.Errorf("audio track %d was not selected", index)
 		}
-	}
-	return
+		return
+	})
 }
 
-func (p *OMXPlayer) SelectSubtitle(index int) (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
+func (p *OMXPlayer) SelectSubtitle(index int) error {
+	return p.withControl(func(c control) (err error) {
 		var ok bool
-		if ok, err = control.SelectSubtitle(index); ok {
-		} else {
+		if ok, err = c.SelectSubtitle(index); err == nil && !ok {
 			err = fmt.Errorf("subtitle %d was not selected", index)
 		}
-	}
-	return
+		return
+	})
 }
 
-func (p *OMXPlayer) SetPosition(position time.Duration) (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.SetPosition(position)
-	}
-	return
+func (p *OMXPlayer) SetPosition(position time.Duration) error {
+	return p.withControl(func(c control) error { return c.SetPosition(position) })
 }
 
 func (p *OMXPlayer) Status() (status api.PlayerStatus, err error) {
 	status.Stopped = true
-	control := p.control
-	if control != nil {
-		if ready, e := control.CanControl(); ready && e == nil {
-			var file string
-			var position, duration time.Duration
-			var pbs omxcontrol.Status
-			var audios []omxcontrol.Stream
-			var subs []omxcontrol.Stream
-			file, err = control.Playing()
-			if err != nil {
-				return
-			}
-			position, err = control.Position()
-			if err != nil {
-				return
-			}
-			duration, err = control.Duration()
-			if err != nil {
-				return
-			}
-			pbs, err = control.PlaybackStatus()
-			if err != nil {
-				return
-			}
-			audios, err = control.AudioTracks()
-			if err != nil {
-				return
-			}
-			subs, err = control.Subtitles()
-			if err == nil {
-				status.File = file
-				status.Position = int(position / time.Second)
-				status.Duration = int(duration / time.Second)
-				status.Paused = pbs == omxcontrol.Paused
-				status.Muted = p.muted
-				status.SubtitlesOff = p.subtitlesOff
-				status.ActiveAudioTrack = findActive(audios)
-				status.ActiveSubtitle = findActive(subs)
-				status.Stopped = false
-			}
-		}
+	p.mu.Lock()
+	ready := p.state == statePlaying && p.control != nil
+	p.mu.Unlock()
+	if !ready {
+		return
 	}
+	err = p.withControl(func(c control) (e error) {
+		var file string
+		var position, duration time.Duration
+		var pbs omxcontrol.Status
+		var audios []omxcontrol.Stream
+		var subs []omxcontrol.Stream
+		if file, e = c.Playing(); e != nil {
+			return
+		}
+		if position, e = c.Position(); e != nil {
+			return
+		}
+		if duration, e = c.Duration(); e != nil {
+			return
+		}
+		if pbs, e = c.PlaybackStatus(); e != nil {
+			return
+		}
+		if audios, e = c.AudioTracks(); e != nil {
+			return
+		}
+		if subs, e = c.Subtitles(); e != nil {
+			return
+		}
+		p.mu.Lock()
+		status.Muted = p.muted
+		status.SubtitlesOff = p.subtitlesOff
+		p.mu.Unlock()
+		status.File = file
+		status.Position = int(position / time.Second)
+		status.Duration = int(duration / time.Second)
+		status.Paused = pbs == omxcontrol.Paused
+		status.ActiveAudioTrack = findActive(audios)
+		status.ActiveSubtitle = findActive(subs)
+		status.Stopped = false
+		return
+	})
 	return
 }
 
-func (p *OMXPlayer) Stop() error {
-	return p.quit()
+func (p *OMXPlayer) Stop() (err error) {
+	p.mu.Lock()
+	pb := p.current
+	if pb == nil {
+		p.mu.Unlock()
+		return
+	}
+	p.state = stateStopping
+	p.mu.Unlock()
+
+	log.WithFields(log.Fields{"PID": pb.process.Pid()}).Info("kill omxplayer")
+	if err = pb.process.Terminate(); err != nil {
+		log.WithFields(log.Fields{"PID": pb.process.Pid(), "err": err}).Warn("Unable terminate omxplayer")
+	}
+	select {
+	case <-pb.done:
+		err = nil
+	case <-time.After(p.stopTimeout):
+		log.WithFields(log.Fields{"PID": pb.process.Pid()}).Warn("omxplayer does not stop, kill it")
+		if err = pb.process.Kill(); err == nil {
+			<-pb.done
+		} else {
+			// process is abandoned, otherwise player refuses to start anything until restart
+			log.WithFields(log.Fields{"PID": pb.process.Pid(), "err": err}).Error("Unable kill omxplayer")
+			p.mu.Lock()
+			if p.current == pb {
+				p.state = stateCrashed
+				p.current = nil
+				p.control = nil
+			}
+			p.mu.Unlock()
+		}
+	}
+	return
 }
 
 func (p *OMXPlayer) Subtitles() (subtitles []api.Stream, err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
+	err = p.withControl(func(c control) (e error) {
 		var controlSubtitles []omxcontrol.Stream
-		controlSubtitles, err = control.Subtitles()
-		if err == nil {
+		if controlSubtitles, e = c.Subtitles(); e == nil {
 			subtitles = convertToApiStreams(controlSubtitles)
 		}
-	}
+		return
+	})
 	return
 }
 
-func (p *OMXPlayer) ToggleMute() (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		if p.muted {
-			err = control.Unmute()
-		} else {
-			err = control.Mute()
+func (p *OMXPlayer) ToggleMute() error {
+	p.mu.Lock()
+	muted := p.muted
+	p.mu.Unlock()
+	err := p.withControl(func(c control) error {
+		if muted {
+			return c.Unmute()
 		}
-	}
+		return c.Mute()
+	})
 	if err == nil {
-		p.muted = !p.muted
+		p.mu.Lock()
+		p.muted = !muted
+		p.mu.Unlock()
 	}
-	return
+	return err
 }
 
-func (p *OMXPlayer) ToggleSubtitles() (err error) {
-	if err = p.action(omxcontrol.ActionToggleSubtitle); err == nil {
+func (p *OMXPlayer) ToggleSubtitles() error {
+	err := p.action(omxcontrol.ActionToggleSubtitle)
+	if err == nil {
+		p.mu.Lock()
 		p.subtitlesOff = !p.subtitlesOff
+		p.mu.Unlock()
 	}
-	return
+	return err
 }
 
 func (p *OMXPlayer) Volume() (vol float64, err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		vol, err = control.Volume()
-	}
+	err = p.withControl(func(c control) (e error) {
+		vol, e = c.Volume()
+		return
+	})
 	return
 }
 
@@ -269,74 +389,261 @@ func (p *OMXPlayer) VolumeUp() error {
 	return p.action(omxcontrol.ActionIncreaseVolume)
 }
 
-func (p *OMXPlayer) action(actionCode omxcontrol.KeyboardAction) (err error) {
-	var control *omxcontrol.OmxCtrl
-	if control, err = p.mustHaveControl(); err == nil {
-		err = control.Action(actionCode)
+func (p *OMXPlayer) action(actionCode omxcontrol.KeyboardAction) error {
+	return p.withControl(func(c control) error { return c.Action(actionCode) })
+}
+
+// withControl runs f with current control. If f fails because of broken DBus connection control is reconnected and f
+// is retried once.
+func (p *OMXPlayer) withControl(f func(c control) error) error {
+	c, err := p.mustHaveControl()
+	if err != nil {
+		return err
 	}
-	return
+	if err = f(c); err != nil && isDisconnected(err) {
+		log.WithFields(log.Fields{"err": err}).Warn("Lost DBus connection to omxplayer, try to reconnect")
+		if reconnected, ok := p.reconnect(c); ok {
+			err = f(reconnected)
+		}
+	}
+	return err
 }
 
-func (p *OMXPlayer) quit() (err error) {
-	process := p.process
-	if process != nil {
-		log.WithFields(log.Fields{"PID": process.Pid}).Info("kill omxplayer")
-		var pgid int
-		if pgid, err = syscall.Getpgid(process.Pid); err != nil {
-			return
+// reconnect replaces broken control. Lock is not held while talking to DBus, so hung DBus does not block Stop and
+// watch of process.
+func (p *OMXPlayer) reconnect(broken control) (c control, ok bool) {
+	p.mu.Lock()
+	playing, current := p.state == statePlaying, p.control
+	p.mu.Unlock()
+	if !playing {
+		return
+	}
+	if current != broken {
+		// already reconnected by concurrent call
+		return current, current != nil
+	}
+	var err error
+	var ready bool
+	if c, err = p.connectControl(); err == nil {
+		if ready, err = c.CanControl(); err == nil && !ready {
+			err = errors.New("omxplayer is not ready to be controlled")
 		}
-		if err = syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
-			return
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Unable reconnect to omxplayer")
+		return nil, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != statePlaying {
+		return nil, false
+	}
+	if p.control != broken {
+		// reconnected by concurrent call while connecting
+		return p.control, p.control != nil
+	}
+	p.control = c
+	log.Info("Reconnected to omxplayer")
+	return c, true
+}
+
+// isDisconnected reports whether err is caused by a transport problem rather than by error replied by omxplayer.
+func isDisconnected(err error) bool {
+	switch err.(type) {
+	case dbus.Error, *dbus.Error:
+		return false
+	}
+	return true
+}
+
+func (p *OMXPlayer) setupControl(ctx context.Context) (c control, err error) {
+	delay := controlRetryMinDelay
+	for attempt := 1; ; attempt++ {
+		if c, err = p.connectControl(); err == nil {
+			var ready bool
+			if ready, err = c.CanControl(); err == nil && ready {
+				log.WithFields(log.Fields{"attempts": attempt}).Info("Setup omxplayer control")
+				return
+			} else if err == nil {
+				err = errors.New("omxplayer is not ready to be controlled")
+			}
 		}
-		_, _ = process.Wait()
-		p.process = nil
-		p.control = nil
-		p.muted = false
-		p.subtitlesOff = false
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("unable setup omxplayer control after %d attempts, last error: %v", attempt, err)
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > controlRetryMaxDelay {
+			delay = controlRetryMaxDelay
+		}
+	}
+}
+
+func (p *OMXPlayer) start(path string, opts LaunchOptions) (pb *playback, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil {
+		return nil, fmt.Errorf("omxplayer is %s, unable start play %s", p.state, path)
+	}
+	args := omxplayerArgs(path, opts)
+	var proc process
+	if proc, err = p.startProcess(args); err != nil {
+		return
 	}
+	log.WithFields(log.Fields{"PID": proc.Pid(), "file": path, "args": args}).Info("Started omxplayer")
+	pb = &playback{path: path, process: proc, done: make(chan struct{})}
+	p.current = pb
+	p.control = nil
+	p.muted = false
+	p.subtitlesOff = false
+	p.state = stateStarting
+	go p.watch(pb)
 	return
 }
 
-func setupControl() (control *omxcontrol.OmxCtrl, err error) {
-	attempts := 10
-	retryDelay := time.Duration(2) * time.Second
-	var ready bool
-	for i := 1; ; i++ {
-		time.Sleep(retryDelay)
-		control, err = omxcontrol.Create()
-		if err != nil {
-			continue
+// watch waits for omxplayer process to exit and notifies listeners.
+func (p *OMXPlayer) watch(pb *playback) {
+	waitErr := pb.process.Wait()
+
+	p.mu.Lock()
+	// playback may be abandoned by Stop if process could not be killed, state belongs to other playback then
+	owner := p.current == pb
+	crashed := owner && waitErr != nil && p.state == statePlaying
+	completed := owner && waitErr == nil && p.state == statePlaying
+	if crashed {
+		p.state = stateCrashed
+		log.WithFields(log.Fields{"PID": pb.process.Pid(), "file": pb.path, "err": waitErr}).Error("omxplayer crashed")
+	} else if waitErr != nil {
+		log.WithFields(log.Fields{"PID": pb.process.Pid(), "err": waitErr}).Debug("omxplayer process ended with error")
+	}
+	if owner {
+		if !crashed {
+			p.state = stateIdle
 		}
-		ready, err = control.CanControl()
-		if err == nil && ready {
-			log.WithFields(log.Fields{"attempts": i}).Info("Setup omxplayer control")
-			return
+		p.current = nil
+		p.control = nil
+		p.muted = false
+		p.subtitlesOff = false
+	}
+	listeners := p.listenersCopy()
+	p.mu.Unlock()
+	close(pb.done)
+
+	if !pb.announced {
+		return
+	}
+	if crashed {
+		for _, l := range listeners {
+			if cl, ok := l.(CrashPlayListener); ok {
+				cl.CrashPlay(pb.path, waitErr)
+			}
 		}
-		if i > attempts {
-			break
+	} else if completed {
+		for _, l := range listeners {
+			if cl, ok := l.(CompletePlayListener); ok {
+				cl.CompletePlay(pb.path)
+			}
 		}
 	}
-	err = fmt.Errorf("unable setup omxplayer control after %d attempts, last error: %v", attempts, err)
+	for _, l := range listeners {
+		l.StopPlay(pb.path)
+	}
+}
+
+func (p *OMXPlayer) listenersCopy() []PlayListener {
+	return append([]PlayListener{}, p.listeners...)
+}
+
+func (p *OMXPlayer) mustHaveControl() (c control, err error) {
+	p.mu.Lock()
+	c = p.control
+	p.mu.Unlock()
+	if c == nil {
+		err = controlNotSetup
+	}
 	return
 }
 
-func (p *OMXPlayer) start(path string) (err error) {
-	cmd := exec.Command("/usr/bin/omxplayer", "-b", path)
+type osProcess struct {
+	*os.Process
+}
+
+func startOmxplayer(args []string) (process, error) {
+	cmd := exec.Command("/usr/bin/omxplayer", args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	err = cmd.Start()
-	if err == nil {
-		p.process = cmd.Process
-		log.WithFields(log.Fields{"PID": p.process.Pid, "file": path}).Info("Started omxplayer")
+	if err := cmd.Start(); err != nil {
+		return nil, err
 	}
-	return
+	return &osProcess{cmd.Process}, nil
 }
 
-func (p *OMXPlayer) mustHaveControl() (control *omxcontrol.OmxCtrl, err error) {
-	control = p.control
-	if control == nil {
-		err = controlNotSetup
+func (p *osProcess) Pid() int {
+	return p.Process.Pid
+}
+
+func (p *osProcess) Wait() error {
+	state, err := p.Process.Wait()
+	if err == nil && !state.Success() {
+		err = fmt.Errorf("omxplayer exited: %s", state.String())
 	}
-	return
+	return err
+}
+
+func (p *osProcess) Terminate() error {
+	return p.signalGroup(syscall.SIGTERM)
+}
+
+func (p *osProcess) Kill() error {
+	return p.signalGroup(syscall.SIGKILL)
+}
+
+// signalGroup sends signal to all processes in group since omxplayer is a script that starts omxplayer.bin.
+func (p *osProcess) signalGroup(sig syscall.Signal) error {
+	pgid, err := syscall.Getpgid(p.Process.Pid)
+	if err != nil {
+		return err
+	}
+	return syscall.Kill(-pgid, sig)
+}
+
+func connectOmxControl() (control, error) {
+	c, err := omxcontrol.Create()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func omxplayerArgs(path string, opts LaunchOptions) []string {
+	args := []string{"-b"}
+	o := opts.Options
+	if o.AudioOutput != "" {
+		args = append(args, "-o", o.AudioOutput)
+	}
+	if o.Passthrough != nil && *o.Passthrough {
+		args = append(args, "-p")
+	}
+	if o.SubtitleFontSize > 0 {
+		args = append(args, "--font-size", strconv.Itoa(o.SubtitleFontSize))
+	}
+	if o.SubtitleAlign != "" {
+		args = append(args, "--align", o.SubtitleAlign)
+	}
+	if o.AspectMode != "" {
+		args = append(args, "--aspect-mode", o.AspectMode)
+	}
+	if o.Display > 0 {
+		args = append(args, "--display", strconv.Itoa(o.Display))
+	}
+	if opts.Position > 0 {
+		args = append(args, "--pos", formatPosition(opts.Position))
+	}
+	return append(args, path)
+}
+
+func formatPosition(position time.Duration) string {
+	seconds := int(position / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
 }
 
 func findActive(streams []omxcontrol.Stream) int {
@@ -0,0 +1,334 @@
+package player
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/omxcontrol"
+)
+
+func TestOmxplayerArgsWithoutOptions(t *testing.T) {
+	args := omxplayerArgs("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Equal(t, []string{"-b", "/movies/gladiator.mkv"}, args)
+}
+
+func TestOmxplayerArgs(t *testing.T) {
+	passthrough := true
+	opts := LaunchOptions{
+		Position: time.Duration(3725) * time.Second,
+		Options: api.PlayerOptions{
+			AudioOutput:      "both",
+			Passthrough:      &passthrough,
+			SubtitleFontSize: 40,
+			SubtitleAlign:    "left",
+			AspectMode:       "letterbox",
+			Display:          5,
+		},
+	}
+	args := omxplayerArgs("/movies/gladiator.mkv", opts)
+	expected := []string{
+		"-b",
+		"-o", "both",
+		"-p",
+		"--font-size", "40",
+		"--align", "left",
+		"--aspect-mode", "letterbox",
+		"--display", "5",
+		"--pos", "01:02:05",
+		"/movies/gladiator.mkv",
+	}
+	assert.Equal(t, expected, args)
+}
+
+func TestOmxplayerArgsSkipsDisabledPassthrough(t *testing.T) {
+	passthrough := false
+	args := omxplayerArgs("/movies/gladiator.mkv", LaunchOptions{Options: api.PlayerOptions{Passthrough: &passthrough}})
+	assert.Equal(t, []string{"-b", "/movies/gladiator.mkv"}, args)
+}
+
+func TestPlayMovieNotifiesListeners(t *testing.T) {
+	proc := newFakeProcess()
+	p := createFakeOMXPlayer(proc, &fakeControl{ready: true})
+	l := &listenerMock{}
+	p.AddListener(l)
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, statePlaying, p.state)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv"}, l.all())
+
+	err = p.Stop()
+	assert.Nil(t, err)
+	assert.True(t, proc.terminated)
+	assert.Equal(t, stateIdle, p.state)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv", "stop /movies/gladiator.mkv"}, l.all())
+}
+
+func TestNotifyListenersWhenPlayerCrashed(t *testing.T) {
+	proc := newFakeProcess()
+	p := createFakeOMXPlayer(proc, &fakeControl{ready: true})
+	l := &listenerMock{stopped: make(chan bool, 1)}
+	p.AddListener(l)
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+
+	proc.exit <- errors.New("omxplayer exited: signal: segmentation fault")
+	<-l.stopped
+
+	assert.Equal(t, stateCrashed, p.state)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv", "crash /movies/gladiator.mkv: omxplayer exited: signal: segmentation fault", "stop /movies/gladiator.mkv"}, l.all())
+	status, err := p.Status()
+	assert.Nil(t, err)
+	assert.True(t, status.Stopped)
+}
+
+func TestNotifyListenersWhenPlaybackCompleted(t *testing.T) {
+	proc := newFakeProcess()
+	p := createFakeOMXPlayer(proc, &fakeControl{ready: true})
+	l := &listenerMock{stopped: make(chan bool, 1)}
+	p.AddListener(l)
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+
+	proc.exit <- nil
+	<-l.stopped
+
+	assert.Equal(t, stateIdle, p.state)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv", "complete /movies/gladiator.mkv", "stop /movies/gladiator.mkv"}, l.all())
+}
+
+func TestPlayMovieFailsFastWhenProcessExitsBeforeControlSetup(t *testing.T) {
+	proc := newFakeProcess()
+	p := createFakeOMXPlayer(proc, nil)
+	p.controlTimeout = time.Minute
+	l := &listenerMock{}
+	p.AddListener(l)
+	go func() {
+		time.Sleep(time.Duration(50) * time.Millisecond)
+		proc.exit <- errors.New("omxplayer exited: exit status 1")
+	}()
+
+	start := time.Now()
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Duration(5)*time.Second)
+	assert.Equal(t, stateIdle, p.state)
+	assert.Empty(t, l.all())
+}
+
+func TestPlayMovieFailsWhenControlIsNotReadyInTime(t *testing.T) {
+	proc := newFakeProcess()
+	p := createFakeOMXPlayer(proc, &fakeControl{ready: false})
+	p.controlTimeout = time.Duration(300) * time.Millisecond
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+
+	assert.NotNil(t, err)
+	assert.True(t, proc.terminated)
+	assert.Equal(t, stateIdle, p.state)
+}
+
+func TestPlayerStartsAgainWhenStuckProcessCanNotBeKilled(t *testing.T) {
+	stuck := newFakeProcess()
+	stuck.stuck = true
+	next := newFakeProcess()
+	p := createFakeOMXPlayer(stuck, &fakeControl{ready: true})
+	p.stopTimeout = time.Duration(50) * time.Millisecond
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+
+	err = p.Stop()
+	assert.NotNil(t, err)
+	assert.Equal(t, stateCrashed, p.state)
+	assert.Nil(t, p.current)
+
+	p.startProcess = func(args []string) (process, error) { return next, nil }
+	err = p.PlayMovie("/movies/brave.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, statePlaying, p.state)
+	assert.Equal(t, "/movies/brave.mkv", p.current.path)
+}
+
+func TestReconnectControlDoesNotBlockStop(t *testing.T) {
+	proc := newFakeProcess()
+	broken := &fakeControl{ready: true, err: errors.New("dbus: connection closed by user")}
+	p := createFakeOMXPlayer(proc, broken)
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+
+	connecting := make(chan bool)
+	release := make(chan bool)
+	p.connectControl = func() (control, error) {
+		connecting <- true
+		<-release
+		return &fakeControl{ready: true}, nil
+	}
+	paused := make(chan error)
+	go func() { paused <- p.Pause() }()
+	<-connecting
+
+	err = p.Stop()
+	assert.Nil(t, err)
+	assert.Equal(t, stateIdle, p.state)
+
+	close(release)
+	assert.NotNil(t, <-paused)
+}
+
+func TestReconnectControlWhenConnectionLost(t *testing.T) {
+	proc := newFakeProcess()
+	broken := &fakeControl{ready: true, err: errors.New("dbus: connection closed by user")}
+	p := createFakeOMXPlayer(proc, broken)
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+
+	fresh := &fakeControl{ready: true}
+	p.connectControl = func() (control, error) { return fresh, nil }
+	err = p.Pause()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pause"}, broken.calls)
+	assert.Equal(t, []string{"pause"}, fresh.calls)
+	assert.Equal(t, fresh, p.control)
+}
+
+func TestDoNotReconnectWhenOmxplayerRepliesWithError(t *testing.T) {
+	proc := newFakeProcess()
+	c := &fakeControl{ready: true, err: dbus.Error{Name: "org.freedesktop.DBus.Error.InvalidArgs"}}
+	p := createFakeOMXPlayer(proc, c)
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{})
+	assert.Nil(t, err)
+
+	connected := 0
+	p.connectControl = func() (control, error) { connected++; return &fakeControl{ready: true}, nil }
+	err = p.Pause()
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, connected)
+}
+
+func TestControlIsNotAvailableWhenNothingPlays(t *testing.T) {
+	p := createFakeOMXPlayer(newFakeProcess(), &fakeControl{ready: true})
+	err := p.Pause()
+	assert.Equal(t, controlNotSetup, err)
+}
+
+func createFakeOMXPlayer(proc *fakeProcess, c *fakeControl) *OMXPlayer {
+	p := createOMXPlayer()
+	p.startProcess = func(args []string) (process, error) { return proc, nil }
+	p.connectControl = func() (control, error) {
+		if c == nil {
+			return nil, errors.New("no omxplayer on dbus")
+		}
+		return c, nil
+	}
+	p.stopTimeout = time.Second
+	return p
+}
+
+type fakeProcess struct {
+	mu         sync.Mutex
+	exit       chan error
+	terminated bool
+	// stuck process ignores Terminate and can't be killed
+	stuck bool
+}
+
+func newFakeProcess() *fakeProcess {
+	return &fakeProcess{exit: make(chan error, 1)}
+}
+
+func (p *fakeProcess) Pid() int    { return 42 }
+func (p *fakeProcess) Wait() error { return <-p.exit }
+
+func (p *fakeProcess) Terminate() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stuck {
+		return errors.New("operation not permitted")
+	}
+	if !p.terminated {
+		p.terminated = true
+		p.exit <- errors.New("omxplayer exited: signal: terminated")
+	}
+	return nil
+}
+
+func (p *fakeProcess) Kill() error {
+	return p.Terminate()
+}
+
+type fakeControl struct {
+	ready bool
+	err   error
+	calls []string
+}
+
+func (c *fakeControl) CanControl() (bool, error) { return c.ready, nil }
+
+func (c *fakeControl) call(name string) error {
+	c.calls = append(c.calls, name)
+	return c.err
+}
+
+func (c *fakeControl) Action(omxcontrol.KeyboardAction) error    { return c.call("action") }
+func (c *fakeControl) AudioTracks() ([]omxcontrol.Stream, error) { return nil, c.call("audios") }
+func (c *fakeControl) Duration() (time.Duration, error)          { return 0, c.call("duration") }
+func (c *fakeControl) Mute() error                               { return c.call("mute") }
+func (c *fakeControl) Pause() error                              { return c.call("pause") }
+func (c *fakeControl) Play() error                               { return c.call("play") }
+func (c *fakeControl) PlayPause() error                          { return c.call("playpause") }
+func (c *fakeControl) PlaybackStatus() (omxcontrol.Status, error) {
+	return omxcontrol.Playing, c.call("status")
+}
+func (c *fakeControl) Playing() (string, error)                { return "", c.call("playing") }
+func (c *fakeControl) Position() (time.Duration, error)        { return 0, c.call("position") }
+func (c *fakeControl) Seek(time.Duration) error                { return c.call("seek") }
+func (c *fakeControl) SelectAudio(int) (bool, error)           { return true, c.call("audio") }
+func (c *fakeControl) SelectSubtitle(int) (bool, error)        { return true, c.call("subtitle") }
+func (c *fakeControl) SetPosition(time.Duration) error         { return c.call("setposition") }
+func (c *fakeControl) Subtitles() ([]omxcontrol.Stream, error) { return nil, c.call("subtitles") }
+func (c *fakeControl) Unmute() error                           { return c.call("unmute") }
+func (c *fakeControl) Volume() (float64, error)                { return 1, c.call("volume") }
+
+type listenerMock struct {
+	mu      sync.Mutex
+	events  []string
+	stopped chan bool
+}
+
+func (l *listenerMock) add(event string) {
+	l.mu.Lock()
+	l.events = append(l.events, event)
+	l.mu.Unlock()
+}
+
+func (l *listenerMock) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.events...)
+}
+
+func (l *listenerMock) StartPlay(path string) { l.add("start " + path) }
+
+func (l *listenerMock) StopPlay(path string) {
+	l.add("stop " + path)
+	if l.stopped != nil {
+		l.stopped <- true
+	}
+}
+
+func (l *listenerMock) CrashPlay(path string, err error) { l.add("crash " + path + ": " + err.Error()) }
+
+func (l *listenerMock) CompletePlay(path string) { l.add("complete " + path) }
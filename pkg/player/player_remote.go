@@ -0,0 +1,187 @@
+package player
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// RemotePlayer controls player of another gomovies instance through its HTTP API. Launch options are not passed to
+// remote instance, it applies its own configuration. Listeners are notified only about playback started or stopped
+// through this player.
+type RemotePlayer struct {
+	addr      string
+	client    *http.Client
+	mu        sync.Mutex
+	current   string
+	listeners []PlayListener
+}
+
+func CreateRemotePlayer(addr string, client *http.Client) *RemotePlayer {
+	return &RemotePlayer{addr: strings.TrimSuffix(addr, "/"), client: client}
+}
+
+func (p *RemotePlayer) AudioTracks() (audios []api.Stream, err error) {
+	err = p.call("/api/player/audios", nil, &audios)
+	return
+}
+
+func (p *RemotePlayer) NextAudioTrack() error {
+	return p.call("/api/player/nextaudiotrack", nil, nil)
+}
+
+func (p *RemotePlayer) NextSubtitle() error {
+	return p.call("/api/player/nextsubtitle", nil, nil)
+}
+
+func (p *RemotePlayer) Pause() error {
+	return p.call("/api/player/pause", nil, nil)
+}
+
+func (p *RemotePlayer) Play() error {
+	return p.call("/api/player/play", nil, nil)
+}
+
+func (p *RemotePlayer) PlayMovie(path string, opts LaunchOptions) error {
+	playback := api.Playback{File: path, Position: int(opts.Position / time.Second)}
+	if err := p.call("/api/play", playback, nil); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.current = path
+	listeners := append([]PlayListener{}, p.listeners...)
+	p.mu.Unlock()
+	for _, l := range listeners {
+		l.StartPlay(path)
+	}
+	return nil
+}
+
+func (p *RemotePlayer) PlayPause() error {
+	return p.call("/api/player/playpause", nil, nil)
+}
+
+func (p *RemotePlayer) PreviousAudioTrack() error {
+	return p.call("/api/player/previousaudiotrack", nil, nil)
+}
+
+func (p *RemotePlayer) PreviousSubtitle() error {
+	return p.call("/api/player/previoussubtitle", nil, nil)
+}
+
+func (p *RemotePlayer) ReplayCurrent() error {
+	return p.call("/api/player/replay", nil, nil)
+}
+
+func (p *RemotePlayer) Seek(offset time.Duration) error {
+	return p.call("/api/player/seek", api.Position{Position: int(offset / time.Second)}, nil)
+}
+
+func (p *RemotePlayer) SelectAudio(index int) error {
+	return p.call("/api/player/audio", api.TrackIndex{Index: index}, nil)
+}
+
+func (p *RemotePlayer) SelectSubtitle(index int) error {
+	return p.call("/api/player/subtitle", api.TrackIndex{Index: index}, nil)
+}
+
+func (p *RemotePlayer) SetPosition(position time.Duration) error {
+	return p.call("/api/player/position", api.Position{Position: int(position / time.Second)}, nil)
+}
+
+func (p *RemotePlayer) Status() (status api.PlayerStatus, err error) {
+	err = p.call("/api/player/status", nil, &status)
+	return
+}
+
+func (p *RemotePlayer) Stop() error {
+	if err := p.call("/api/player/stop", nil, nil); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	path := p.current
+	p.current = ""
+	listeners := append([]PlayListener{}, p.listeners...)
+	p.mu.Unlock()
+	if path != "" {
+		for _, l := range listeners {
+			l.StopPlay(path)
+		}
+	}
+	return nil
+}
+
+func (p *RemotePlayer) Subtitles() (subtitles []api.Stream, err error) {
+	err = p.call("/api/player/subtitles", nil, &subtitles)
+	return
+}
+
+func (p *RemotePlayer) ToggleMute() error {
+	return p.call("/api/player/togglemute", nil, nil)
+}
+
+func (p *RemotePlayer) ToggleSubtitles() error {
+	return p.call("/api/player/togglesubtitles", nil, nil)
+}
+
+func (p *RemotePlayer) Volume() (float64, error) {
+	var v api.Volume
+	err := p.call("/api/player/volume", nil, &v)
+	return v.Volume, err
+}
+
+func (p *RemotePlayer) VolumeDown() error {
+	return p.call("/api/player/volumedown", nil, nil)
+}
+
+func (p *RemotePlayer) VolumeUp() error {
+	return p.call("/api/player/volumeup", nil, nil)
+}
+
+func (p *RemotePlayer) AddListener(l PlayListener) {
+	p.mu.Lock()
+	p.listeners = append(p.listeners, l)
+	p.mu.Unlock()
+}
+
+// call sends request to remote instance, request is GET if it only reads result and POST otherwise, e.g. actions of
+// player. Response is decoded into result if it is not nil.
+func (p *RemotePlayer) call(path string, body interface{}, result interface{}) (err error) {
+	var resp *http.Response
+	if body != nil || result == nil {
+		var buf bytes.Buffer
+		if body != nil {
+			if err = json.NewEncoder(&buf).Encode(body); err != nil {
+				return
+			}
+		}
+		resp, err = p.client.Post(p.addr+path, "application/json", &buf)
+	} else {
+		resp, err = p.client.Get(p.addr + path)
+	}
+	if err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		var msg api.MessagePayload
+		if json.NewDecoder(resp.Body).Decode(&msg) != nil || msg.Message == "" {
+			msg.Message = resp.Status
+		}
+		return fmt.Errorf("%s: %s", p.addr, msg.Message)
+	}
+	if result != nil {
+		err = json.NewDecoder(resp.Body).Decode(result)
+	}
+	return
+}
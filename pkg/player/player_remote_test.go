@@ -0,0 +1,62 @@
+package player
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func TestRemotePlayMovie(t *testing.T) {
+	var received api.Playback
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/play", r.URL.Path)
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+		_ = json.NewEncoder(w).Encode(api.PlayerStatus{File: received.File})
+	}))
+	defer server.Close()
+	p := CreateRemotePlayer(server.URL, server.Client())
+	l := &listenerMock{}
+	p.AddListener(l)
+
+	err := p.PlayMovie("/movies/gladiator.mkv", LaunchOptions{Position: time.Duration(90) * time.Second})
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.Playback{File: "/movies/gladiator.mkv", Position: 90}, received)
+	assert.Equal(t, []string{"start /movies/gladiator.mkv"}, l.all())
+}
+
+func TestRemoteStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/player/status", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+		_ = json.NewEncoder(w).Encode(api.PlayerStatus{File: "/movies/gladiator.mkv", Position: 30})
+	}))
+	defer server.Close()
+	p := CreateRemotePlayer(server.URL+"/", server.Client())
+
+	status, err := p.Status()
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.PlayerStatus{File: "/movies/gladiator.mkv", Position: 30}, status)
+}
+
+func TestRemotePlayerReturnsErrorMessageOfPeer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(api.MessagePayload{Message: "player is not started"})
+	}))
+	defer server.Close()
+	p := CreateRemotePlayer(server.URL, server.Client())
+
+	err := p.Pause()
+
+	assert.NotNil(t, err)
+	assert.Equal(t, server.URL+": player is not started", err.Error())
+}
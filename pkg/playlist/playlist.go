@@ -0,0 +1,42 @@
+package playlist
+
+import (
+	"fmt"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+const (
+	RepeatOff = ""
+	RepeatOne = "one"
+	RepeatAll = "all"
+)
+
+type Factory func(*config.Config) (Store, error)
+
+var storeFactory Factory
+
+func CreateStore(conf *config.Config) (Store, error) {
+	return storeFactory(conf)
+}
+
+// Store keeps named playlists. Playlist refers movies by their ids in catalog.
+type Store interface {
+	All() []api.Playlist
+	Get(name string) (api.Playlist, bool)
+	Create(p api.Playlist) (api.Playlist, error)
+	Update(p api.Playlist) (api.Playlist, error)
+	Delete(name string) error
+	Insert(name string, pos int, ids []int) (api.Playlist, error)
+	Remove(name string, pos int) (api.Playlist, error)
+	Move(name string, from, to int) (api.Playlist, error)
+}
+
+type NotFoundError struct {
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("unknown playlist: %s", e.Name)
+}
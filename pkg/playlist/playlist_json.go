@@ -0,0 +1,196 @@
+package playlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/file"
+	"github.com/andrew00x/gomovies/pkg/util"
+)
+
+type JsonStore struct {
+	mu        sync.RWMutex
+	playlists map[string]*api.Playlist
+}
+
+var playlistsFile string
+
+func init() {
+	playlistsFile = filepath.Join(config.ConfDir(), "playlists.json")
+	storeFactory = createJsonStore
+}
+
+func createJsonStore(_ *config.Config) (Store, error) {
+	s := &JsonStore{}
+	playlists, err := readPlaylists()
+	if err != nil {
+		return nil, err
+	}
+	s.playlists = playlists
+	return s, nil
+}
+
+func (s *JsonStore) All() []api.Playlist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]api.Playlist, 0, len(s.playlists))
+	for _, p := range s.playlists {
+		result = append(result, copyPlaylist(p))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *JsonStore) Get(name string) (p api.Playlist, found bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if stored, ok := s.playlists[name]; ok {
+		p, found = copyPlaylist(stored), true
+	}
+	return
+}
+
+func (s *JsonStore) Create(p api.Playlist) (api.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := validate(p); err != nil {
+		return api.Playlist{}, err
+	}
+	if _, ok := s.playlists[p.Name]; ok {
+		return api.Playlist{}, fmt.Errorf("playlist %s already exists", p.Name)
+	}
+	created := copyPlaylist(&p)
+	created.Movies = nil
+	s.playlists[p.Name] = &created
+	return s.saveAndGet(p.Name)
+}
+
+func (s *JsonStore) Update(p api.Playlist) (api.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := validate(p); err != nil {
+		return api.Playlist{}, err
+	}
+	if _, ok := s.playlists[p.Name]; !ok {
+		return api.Playlist{}, &NotFoundError{p.Name}
+	}
+	updated := copyPlaylist(&p)
+	updated.Movies = nil
+	s.playlists[p.Name] = &updated
+	return s.saveAndGet(p.Name)
+}
+
+func (s *JsonStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.playlists[name]; !ok {
+		return &NotFoundError{name}
+	}
+	delete(s.playlists, name)
+	return s.save()
+}
+
+func (s *JsonStore) Insert(name string, pos int, ids []int) (api.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.playlists[name]
+	if !ok {
+		return api.Playlist{}, &NotFoundError{name}
+	}
+	if pos < 0 || pos > len(p.Items) {
+		pos = len(p.Items)
+	}
+	items := make([]int, 0, len(p.Items)+len(ids))
+	items = append(items, p.Items[:pos]...)
+	items = append(items, ids...)
+	p.Items = append(items, p.Items[pos:]...)
+	return s.saveAndGet(name)
+}
+
+func (s *JsonStore) Remove(name string, pos int) (api.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.playlists[name]
+	if !ok {
+		return api.Playlist{}, &NotFoundError{name}
+	}
+	if pos < 0 || pos >= len(p.Items) {
+		return api.Playlist{}, fmt.Errorf("invalid position %d in playlist %s", pos, name)
+	}
+	p.Items = append(p.Items[:pos], p.Items[pos+1:]...)
+	return s.saveAndGet(name)
+}
+
+func (s *JsonStore) Move(name string, from, to int) (api.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.playlists[name]
+	if !ok {
+		return api.Playlist{}, &NotFoundError{name}
+	}
+	if from < 0 || from >= len(p.Items) || to < 0 || to >= len(p.Items) {
+		return api.Playlist{}, fmt.Errorf("unable move item from %d to %d in playlist %s", from, to, name)
+	}
+	id := p.Items[from]
+	p.Items = append(p.Items[:from], p.Items[from+1:]...)
+	p.Items = append(p.Items[:to], append([]int{id}, p.Items[to:]...)...)
+	return s.saveAndGet(name)
+}
+
+func (s *JsonStore) saveAndGet(name string) (api.Playlist, error) {
+	if err := s.save(); err != nil {
+		return api.Playlist{}, err
+	}
+	return copyPlaylist(s.playlists[name]), nil
+}
+
+func (s *JsonStore) save() error {
+	return file.WriteAtomic(playlistsFile, 0644, 0, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s.playlists)
+	})
+}
+
+func readPlaylists() (playlists map[string]*api.Playlist, err error) {
+	playlists = make(map[string]*api.Playlist)
+	var exists bool
+	if exists, err = file.Exists(playlistsFile); exists && err == nil {
+		var f *os.File
+		if f, err = os.Open(playlistsFile); err != nil {
+			return
+		}
+		defer func() {
+			if clsErr := f.Close(); clsErr != nil {
+				err = clsErr
+			}
+		}()
+		parser := json.NewDecoder(f)
+		err = parser.Decode(&playlists)
+	}
+	return
+}
+
+func validate(p api.Playlist) error {
+	if p.Name == "" || strings.Contains(p.Name, "/") {
+		return fmt.Errorf("invalid playlist name '%s'", p.Name)
+	}
+	if !util.Contains([]string{RepeatOff, RepeatOne, RepeatAll}, p.Repeat) {
+		return fmt.Errorf("invalid repeat mode '%s', supported: %s, %s", p.Repeat, RepeatOne, RepeatAll)
+	}
+	return nil
+}
+
+func copyPlaylist(p *api.Playlist) api.Playlist {
+	c := *p
+	c.Items = append([]int{}, p.Items...)
+	return c
+}
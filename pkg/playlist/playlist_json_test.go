@@ -0,0 +1,128 @@
+package playlist
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func TestCreatePlaylist(t *testing.T) {
+	store := setup()
+
+	created, err := store.Create(api.Playlist{Name: "evening", Items: []int{1, 2}, Repeat: RepeatAll})
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.Playlist{Name: "evening", Items: []int{1, 2}, Repeat: RepeatAll}, created)
+	assert.Equal(t, map[string]*api.Playlist{"evening": {Name: "evening", Items: []int{1, 2}, Repeat: RepeatAll}}, mustReadPlaylistsFile())
+}
+
+func TestCreatePlaylistFailsWhenPlaylistExists(t *testing.T) {
+	store := setup()
+	store.playlists["evening"] = &api.Playlist{Name: "evening"}
+
+	_, err := store.Create(api.Playlist{Name: "evening"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "playlist evening already exists", err.Error())
+}
+
+func TestCreatePlaylistFailsWhenRepeatModeInvalid(t *testing.T) {
+	store := setup()
+
+	_, err := store.Create(api.Playlist{Name: "evening", Repeat: "twice"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid repeat mode 'twice', supported: one, all", err.Error())
+}
+
+func TestLoadPlaylists(t *testing.T) {
+	store := setup()
+	_, err := store.Create(api.Playlist{Name: "evening", Items: []int{1, 2}, Shuffle: true})
+	assert.Nil(t, err)
+
+	loaded, err := createJsonStore(nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []api.Playlist{{Name: "evening", Items: []int{1, 2}, Shuffle: true}}, loaded.All())
+}
+
+func TestInsertIntoPlaylist(t *testing.T) {
+	store := setup()
+	store.playlists["evening"] = &api.Playlist{Name: "evening", Items: []int{1, 2, 3}}
+
+	p, err := store.Insert("evening", 1, []int{7, 8})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 7, 8, 2, 3}, p.Items)
+
+	p, err = store.Insert("evening", -1, []int{9})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 7, 8, 2, 3, 9}, p.Items)
+}
+
+func TestRemoveFromPlaylist(t *testing.T) {
+	store := setup()
+	store.playlists["evening"] = &api.Playlist{Name: "evening", Items: []int{1, 2, 3}}
+
+	p, err := store.Remove("evening", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 3}, p.Items)
+
+	_, err = store.Remove("evening", 2)
+	assert.NotNil(t, err)
+}
+
+func TestMoveInPlaylist(t *testing.T) {
+	store := setup()
+	store.playlists["evening"] = &api.Playlist{Name: "evening", Items: []int{1, 2, 3, 4}}
+
+	p, err := store.Move("evening", 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 3, 1, 4}, p.Items)
+
+	p, err = store.Move("evening", 3, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4, 2, 3, 1}, p.Items)
+}
+
+func TestDeletePlaylist(t *testing.T) {
+	store := setup()
+	store.playlists["evening"] = &api.Playlist{Name: "evening", Items: []int{1, 2, 3}}
+
+	err := store.Delete("evening")
+	assert.Nil(t, err)
+	assert.Empty(t, store.All())
+
+	err = store.Delete("evening")
+	assert.Equal(t, &NotFoundError{"evening"}, err)
+}
+
+func setup() *JsonStore {
+	dir := filepath.Join(os.Getenv("TMPDIR"), "PlaylistTest")
+	if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		log.Fatal(err)
+	}
+	playlistsFile = filepath.Join(dir, "playlists.json")
+	return &JsonStore{playlists: make(map[string]*api.Playlist)}
+}
+
+func mustReadPlaylistsFile() map[string]*api.Playlist {
+	f, err := os.Open(playlistsFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	var playlists map[string]*api.Playlist
+	if err = json.NewDecoder(f).Decode(&playlists); err != nil {
+		log.Fatal(err)
+	}
+	return playlists
+}
@@ -0,0 +1,36 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+type Factory func(*config.Config) (Store, error)
+
+var storeFactory Factory
+
+func CreateStore(conf *config.Config) (Store, error) {
+	return storeFactory(conf)
+}
+
+// Store keeps profiles with content restrictions and name of the active profile. PIN of profile is kept as is, it is
+// up to caller to hash it.
+type Store interface {
+	All() []api.Profile
+	Get(name string) (api.Profile, bool)
+	Save(p api.Profile) (api.Profile, error)
+	Delete(name string) error
+	// Active returns name of the active profile, empty string is returned if there is no active profile
+	Active() string
+	SetActive(name string) error
+}
+
+type NotFoundError struct {
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("unknown profile: %s", e.Name)
+}
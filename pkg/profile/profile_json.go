@@ -0,0 +1,142 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/file"
+)
+
+type JsonStore struct {
+	mu       sync.RWMutex
+	profiles map[string]*api.Profile
+	active   string
+}
+
+// profilesData is content of profiles file.
+type profilesData struct {
+	Active   string        `json:"active,omitempty"`
+	Profiles []api.Profile `json:"profiles"`
+}
+
+var profilesFile string
+
+func init() {
+	profilesFile = filepath.Join(config.ConfDir(), "profiles.json")
+	storeFactory = createJsonStore
+}
+
+func createJsonStore(_ *config.Config) (Store, error) {
+	data, err := readProfiles()
+	if err != nil {
+		return nil, err
+	}
+	s := &JsonStore{profiles: make(map[string]*api.Profile)}
+	for i := range data.Profiles {
+		p := data.Profiles[i]
+		s.profiles[p.Name] = &p
+	}
+	if _, ok := s.profiles[data.Active]; ok {
+		s.active = data.Active
+	}
+	return s, nil
+}
+
+func (s *JsonStore) All() []api.Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.all()
+}
+
+func (s *JsonStore) Get(name string) (p api.Profile, found bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if stored, ok := s.profiles[name]; ok {
+		p, found = *stored, true
+	}
+	return
+}
+
+func (s *JsonStore) Save(p api.Profile) (api.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.Name == "" {
+		return api.Profile{}, errors.New("name of profile is required")
+	}
+	s.profiles[p.Name] = &p
+	if err := s.save(); err != nil {
+		return api.Profile{}, err
+	}
+	return p, nil
+}
+
+func (s *JsonStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[name]; !ok {
+		return &NotFoundError{name}
+	}
+	delete(s.profiles, name)
+	if s.active == name {
+		s.active = ""
+	}
+	return s.save()
+}
+
+func (s *JsonStore) Active() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active
+}
+
+func (s *JsonStore) SetActive(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[name]; !ok && name != "" {
+		return &NotFoundError{name}
+	}
+	s.active = name
+	return s.save()
+}
+
+func (s *JsonStore) all() []api.Profile {
+	result := make([]api.Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *JsonStore) save() error {
+	return file.WriteAtomic(profilesFile, 0600, 0, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(profilesData{Active: s.active, Profiles: s.all()})
+	})
+}
+
+func readProfiles() (data profilesData, err error) {
+	var exists bool
+	if exists, err = file.Exists(profilesFile); exists && err == nil {
+		var f *os.File
+		if f, err = os.Open(profilesFile); err != nil {
+			return
+		}
+		defer func() {
+			if clsErr := f.Close(); clsErr != nil {
+				err = clsErr
+			}
+		}()
+		parser := json.NewDecoder(f)
+		err = parser.Decode(&data)
+	}
+	return
+}
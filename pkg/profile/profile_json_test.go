@@ -0,0 +1,81 @@
+package profile
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func TestSaveProfile(t *testing.T) {
+	store := setup()
+
+	saved, err := store.Save(api.Profile{Name: "kids", MaxCertification: "PG"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.Profile{Name: "kids", MaxCertification: "PG"}, saved)
+	p, found := store.Get("kids")
+	assert.True(t, found)
+	assert.Equal(t, saved, p)
+}
+
+func TestSaveProfileFailsWithoutName(t *testing.T) {
+	store := setup()
+
+	_, err := store.Save(api.Profile{MaxCertification: "PG"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "name of profile is required", err.Error())
+}
+
+func TestDeleteActiveProfile(t *testing.T) {
+	store := setup()
+	_, err := store.Save(api.Profile{Name: "kids", MaxCertification: "PG"})
+	assert.Nil(t, err)
+	assert.Nil(t, store.SetActive("kids"))
+
+	err = store.Delete("kids")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", store.Active())
+	assert.Equal(t, "unknown profile: kids", store.Delete("kids").Error())
+}
+
+func TestLoadProfiles(t *testing.T) {
+	store := setup()
+	_, err := store.Save(api.Profile{Name: "kids", MaxCertification: "PG", BlockedGenres: []string{"Horror"}})
+	assert.Nil(t, err)
+	_, err = store.Save(api.Profile{Name: "teens", MaxCertification: "PG-13"})
+	assert.Nil(t, err)
+	assert.Nil(t, store.SetActive("teens"))
+
+	loaded, err := createJsonStore(nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, store.All(), loaded.All())
+	assert.Equal(t, "teens", loaded.Active())
+}
+
+func TestActivateUnknownProfile(t *testing.T) {
+	store := setup()
+
+	err := store.SetActive("kids")
+
+	assert.Equal(t, &NotFoundError{"kids"}, err)
+}
+
+func setup() *JsonStore {
+	dir := filepath.Join(os.Getenv("TMPDIR"), "ProfileTest")
+	if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		log.Fatal(err)
+	}
+	profilesFile = filepath.Join(dir, "profiles.json")
+	return &JsonStore{profiles: make(map[string]*api.Profile)}
+}
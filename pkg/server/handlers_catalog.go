@@ -0,0 +1,220 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/catalog"
+	"github.com/andrew00x/gomovies/pkg/service"
+)
+
+func (s *Server) allMovies(w http.ResponseWriter, r *http.Request) {
+	result, err := s.withDetails(s.catalog.All(), r)
+	writeJsonResponse(result, err, w)
+}
+
+func (s *Server) searchMovies(w http.ResponseWriter, r *http.Request) {
+	result, err := s.withDetails(s.catalog.Find(r.URL.Query().Get("q")), r)
+	writeJsonResponse(result, err, w)
+}
+
+// withDetails filters movies by query parameter "certification" and adds details to them. Details are omitted with
+// query parameter details=false, movies are only marked as having details then.
+func (s *Server) withDetails(movies []api.Movie, r *http.Request) (result []api.Movie, err error) {
+	query := r.URL.Query()
+	lang := langParam(r)
+	details, e := strconv.ParseBool(query.Get("details"))
+	if e != nil {
+		details = true
+	}
+	result = movies
+	if max := query.Get("certification"); max != "" {
+		if result, err = s.details.FilterByCertification(result, lang, max); err != nil {
+			return
+		}
+	}
+	l := len(result)
+	for i := 0; i < l; i++ {
+		m := &result[i]
+		md, found, e := s.details.MovieDetails(*m, lang, s.isDetailsLoaded())
+		if e == nil && found {
+			m.DetailsAvailable = true
+			if details {
+				m.Details = &md
+			}
+		}
+	}
+	return
+}
+
+func (s *Server) personMovies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("name")
+	role := query.Get("role")
+	if name == "" {
+		writeJsonResponse(nil, fmt.Errorf("name of person is required"), w)
+		return
+	}
+	if role != "" && role != service.RoleCast && role != service.RoleDirector && role != service.RoleWriter {
+		writeJsonResponse(nil, fmt.Errorf("invalid role: %s", role), w)
+		return
+	}
+	lang := langParam(r)
+	result := make([]api.Movie, 0)
+	for _, m := range s.catalog.Find(name) {
+		if s.details.Credited(m, lang, name, role) {
+			md, _, _ := s.details.MovieDetails(m, lang, false)
+			m.DetailsAvailable = true
+			m.Details = &md
+			result = append(result, m)
+		}
+	}
+	writeJsonResponse(result, nil, w)
+}
+
+func (s *Server) updateMovie(w http.ResponseWriter, r *http.Request) {
+	var movie api.Movie
+	err := s.checkUnlocked(r)
+	if err == nil {
+		err = json.NewDecoder(r.Body).Decode(&movie)
+	}
+	if err == nil {
+		movie, err = s.catalog.Update(movie)
+	}
+	writeJsonResponse(movie, err, w)
+}
+
+func (s *Server) refresh(w http.ResponseWriter, _ *http.Request) {
+	err := s.catalog.Refresh()
+	if err == nil {
+		s.setDetailsLoaded(false)
+		go s.LoadDetails()
+	}
+	writeJsonResponse(nil, err, w)
+}
+
+func (s *Server) exportCatalog(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	var buf bytes.Buffer
+	if err := s.catalog.Export(&buf, format); err != nil {
+		writeJsonResponse(nil, err, w)
+		return
+	}
+	if format == catalog.CsvFormat {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		format = catalog.JsonFormat
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=catalog.%s", format))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error occurred while write response")
+	}
+}
+
+func (s *Server) importCatalog(w http.ResponseWriter, r *http.Request) {
+	var result api.ImportResult
+	err := s.checkUnlocked(r)
+	if err == nil {
+		result, err = s.catalog.Import(r.Body, r.URL.Query().Get("format"))
+	}
+	writeJsonResponse(result, err, w)
+}
+
+func (s *Server) drives(w http.ResponseWriter, _ *http.Request) {
+	result, err := s.catalog.Drives()
+	writeJsonResponse(result, err, w)
+}
+
+func (s *Server) mountDrive(w http.ResponseWriter, r *http.Request) {
+	if err := s.catalog.MountDrive(param(r, "name")); err != nil {
+		writeJsonResponse(nil, err, w)
+		return
+	}
+	result, err := s.catalog.Drives()
+	writeJsonResponse(result, err, w)
+}
+
+// duplicatesReport reports copies of the same movie, content of files is compared with query parameter hash=true.
+func (s *Server) duplicatesReport(w http.ResponseWriter, r *http.Request) {
+	byContent, _ := strconv.ParseBool(r.URL.Query().Get("hash"))
+	writeJsonResponse(s.duplicates.Report(byContent), nil, w)
+}
+
+// labels lists user tags or collections with number of movies.
+func (s *Server) labels(group string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJsonResponse(s.catalog.Labels(group), nil, w)
+	}
+}
+
+func (s *Server) labelled(group string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJsonResponse(s.catalog.Labelled(group, param(r, "name")), nil, w)
+	}
+}
+
+func (s *Server) renameLabel(group string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var entity api.Label
+		parser := json.NewDecoder(r.Body)
+		err := parser.Decode(&entity)
+		if err == nil {
+			err = s.catalog.RenameLabel(group, param(r, "name"), entity.Name)
+		}
+		writeJsonResponse(nil, err, w)
+	}
+}
+
+func (s *Server) deleteLabel(group string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJsonResponse(nil, s.catalog.DeleteLabel(group, param(r, "name")), w)
+	}
+}
+
+// addLabel handles requests to /api/{tags|collections}/{name}/movies?id=<movie id>.
+func (s *Server) addLabel(group string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var m api.Movie
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err == nil {
+			m, err = s.catalog.AddLabel(group, int(id), param(r, "name"))
+		}
+		writeJsonResponse(m, err, w)
+	}
+}
+
+func (s *Server) removeLabel(group string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var m api.Movie
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err == nil {
+			m, err = s.catalog.RemoveLabel(group, int(id), param(r, "name"))
+		}
+		writeJsonResponse(m, err, w)
+	}
+}
+
+// langParam returns language requested with query parameter "lang", English is used by default.
+// checkUnlocked checks PIN in query parameter "pin" if the active profile is locked. Changes of catalog are allowed only
+// with PIN, otherwise restrictions of profile might be lifted, e.g. by editing genres of movie.
+func (s *Server) checkUnlocked(r *http.Request) error {
+	if s.profiles == nil {
+		return nil
+	}
+	return s.profiles.CheckUnlocked(r.URL.Query().Get("pin"))
+}
+
+func langParam(r *http.Request) string {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = "en"
+	}
+	return lang
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (s *Server) movieDetails(w http.ResponseWriter, r *http.Request) {
+	var md api.MovieDetails
+	m, err := s.movieParam(r)
+	if err == nil {
+		md, _, err = s.details.MovieDetails(m, langParam(r), true)
+	}
+	writeJsonResponse(md, err, w)
+}
+
+func (s *Server) searchDetails(w http.ResponseWriter, r *http.Request) {
+	result, err := s.details.SearchDetails(r.URL.Query().Get("q"), langParam(r))
+	writeJsonResponse(result, err, w)
+}
+
+func (s *Server) detailsNfo(w http.ResponseWriter, r *http.Request) {
+	m, err := s.movieParam(r)
+	if err != nil {
+		writeJsonResponse(nil, err, w)
+		return
+	}
+	var data []byte
+	if data, err = s.details.Nfo(m, langParam(r)); err != nil {
+		writeJsonResponse(nil, err, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, strings.TrimSuffix(filepath.Base(m.File), filepath.Ext(m.File))+".nfo"))
+	if _, err = w.Write(data); err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error occurred while write response")
+	}
+}
+
+func (s *Server) exportNfo(w http.ResponseWriter, r *http.Request) {
+	m, err := s.movieParam(r)
+	if err == nil {
+		err = s.details.ExportNfo(m, langParam(r), r.URL.Query().Get("overwrite") == "true")
+	}
+	writeJsonResponse(nil, err, w)
+}
+
+func (s *Server) franchises(w http.ResponseWriter, r *http.Request) {
+	writeJsonResponse(s.details.Franchises(s.catalog.All(), langParam(r)), nil, w)
+}
+
+func (s *Server) franchise(w http.ResponseWriter, r *http.Request) {
+	f, err := s.franchiseParam(r)
+	writeJsonResponse(f, err, w)
+}
+
+// enqueueFranchise adds parts of franchise which are in catalog to play queue in order of release.
+func (s *Server) enqueueFranchise(w http.ResponseWriter, r *http.Request) {
+	var queue []string
+	f, err := s.franchiseParam(r)
+	if err == nil {
+		var files []string
+		for _, p := range f.Parts {
+			if p.File != "" {
+				files = append(files, p.File)
+			}
+		}
+		queue, err = s.player.Enqueue(files)
+	}
+	writeJsonResponse(wrapFiles(queue), err, w)
+}
+
+// movieParam finds movie by id passed in query parameter "id".
+func (s *Server) movieParam(r *http.Request) (m api.Movie, err error) {
+	var id int64
+	if id, err = strconv.ParseInt(r.URL.Query().Get("id"), 10, 64); err != nil {
+		return
+	}
+	var found bool
+	if m, found = s.catalog.Get(int(id)); !found {
+		err = newErrResponse(fmt.Errorf("invalid movie id: %d", id), http.StatusNotFound)
+	}
+	return
+}
+
+func (s *Server) franchiseParam(r *http.Request) (f api.Franchise, err error) {
+	var id int64
+	if id, err = strconv.ParseInt(param(r, "id"), 10, 64); err != nil {
+		return
+	}
+	f, err = s.details.Franchise(int(id), s.catalog.All(), langParam(r))
+	return
+}
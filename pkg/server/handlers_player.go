@@ -0,0 +1,287 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/service"
+)
+
+type playerHandler func(srv *service.PlayerService, w http.ResponseWriter, r *http.Request)
+
+// targetPlayer finds player by name of peer or by id of media renderer.
+func (s *Server) targetPlayer(host string) (*service.PlayerService, error) {
+	srv, err := s.peers.Player(host)
+	if _, ok := err.(*service.UnknownHostError); ok {
+		srv, err = s.renderers.Player(host)
+	}
+	return srv, err
+}
+
+// withPlayer resolves player which request is addressed to by query parameter "host", player of this instance is
+// used if parameter is not set.
+func (s *Server) withPlayer(h playerHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		srv, err := s.targetPlayer(r.URL.Query().Get("host"))
+		if err != nil {
+			writeJsonResponse(nil, newErrResponse(err, http.StatusNotFound), w)
+			return
+		}
+		h(srv, w, r)
+	}
+}
+
+func (s *Server) playMovie(w http.ResponseWriter, r *http.Request) {
+	var entity api.Playback
+	var status api.PlayerStatus
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		var srv *service.PlayerService
+		if srv, err = s.targetPlayer(entity.Host); err == nil {
+			status, err = srv.PlayMovie(entity)
+		} else {
+			err = newErrResponse(err, http.StatusNotFound)
+		}
+	}
+	writeJsonResponse(status, err, w)
+}
+
+func (s *Server) queue(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.player.Queue(), nil, w)
+}
+
+func (s *Server) enqueue(w http.ResponseWriter, r *http.Request) {
+	var entity []api.MoviePath
+	var queue []string
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		queue, err = s.player.Enqueue(unwrapFiles(entity))
+	}
+	writeJsonResponse(wrapFiles(queue), err, w)
+}
+
+func (s *Server) dequeue(w http.ResponseWriter, r *http.Request) {
+	var entity api.Position
+	var queue []string
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		queue, err = s.player.Dequeue(entity.Position)
+	}
+	writeJsonResponse(wrapFiles(queue), err, w)
+}
+
+func (s *Server) clearQueue(_ http.ResponseWriter, _ *http.Request) {
+	s.player.ClearQueue()
+}
+
+// shiftQueue is kept for compatibility with old clients, it works as jumpInQueue but responds with list of files.
+func (s *Server) shiftQueue(w http.ResponseWriter, r *http.Request) {
+	var entity api.Position
+	var queue api.Queue
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		queue, err = s.player.JumpInQueue(entity.Position)
+	}
+	writeJsonResponse(queue.Items, err, w)
+}
+
+func (s *Server) nextInQueue(w http.ResponseWriter, _ *http.Request) {
+	queue, err := s.player.NextInQueue()
+	writeJsonResponse(queue, err, w)
+}
+
+func (s *Server) previousInQueue(w http.ResponseWriter, _ *http.Request) {
+	queue, err := s.player.PreviousInQueue()
+	writeJsonResponse(queue, err, w)
+}
+
+func (s *Server) jumpInQueue(w http.ResponseWriter, r *http.Request) {
+	var entity api.Position
+	var queue api.Queue
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		queue, err = s.player.JumpInQueue(entity.Position)
+	}
+	writeJsonResponse(queue, err, w)
+}
+
+func (s *Server) queueMode(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.player.Queue().Mode, nil, w)
+}
+
+func (s *Server) setQueueMode(w http.ResponseWriter, r *http.Request) {
+	var entity api.QueueMode
+	var queue api.Queue
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		if queue, err = s.player.SetQueueMode(entity); err != nil {
+			err = newErrResponse(err, 400)
+		}
+	}
+	writeJsonResponse(queue.Mode, err, w)
+}
+
+func (s *Server) allPeers(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.peers.Peers(), nil, w)
+}
+
+func (s *Server) allPeerMovies(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.peers.AllMovies(), nil, w)
+}
+
+func (s *Server) allRenderers(w http.ResponseWriter, _ *http.Request) {
+	result, err := s.renderers.Discover()
+	writeJsonResponse(result, err, w)
+}
+
+func audios(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	audios, err := srv.AudioTracks()
+	writeJsonResponse(audios, err, w)
+}
+
+func nextAudioTrack(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	audios, err := srv.NextAudioTrack()
+	writeJsonResponse(audios, err, w)
+}
+
+func nextSubtitle(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	subtitles, err := srv.NextSubtitle()
+	writeJsonResponse(subtitles, err, w)
+}
+
+func pause(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	status, err := srv.Pause()
+	writeJsonResponse(status, err, w)
+}
+
+func play(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	status, err := srv.Play()
+	writeJsonResponse(status, err, w)
+}
+
+func playPause(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	status, err := srv.PlayPause()
+	writeJsonResponse(status, err, w)
+}
+
+func previousAudioTrack(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	audios, err := srv.PreviousAudioTrack()
+	writeJsonResponse(audios, err, w)
+}
+
+func previousSubtitle(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	subtitles, err := srv.PreviousSubtitle()
+	writeJsonResponse(subtitles, err, w)
+}
+
+func replayCurrent(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	status, err := srv.ReplayCurrent()
+	writeJsonResponse(status, err, w)
+}
+
+func seek(srv *service.PlayerService, w http.ResponseWriter, r *http.Request) {
+	var entity api.Position
+	parser := json.NewDecoder(r.Body)
+	var status api.PlayerStatus
+	var err error
+	if err = parser.Decode(&entity); err == nil {
+		status, err = srv.Seek(entity.Position)
+	}
+	writeJsonResponse(status, err, w)
+}
+
+func selectAudio(srv *service.PlayerService, w http.ResponseWriter, r *http.Request) {
+	var entity api.TrackIndex
+	parser := json.NewDecoder(r.Body)
+	var audios []api.Stream
+	var err error
+	if err = parser.Decode(&entity); err == nil {
+		audios, err = srv.SelectAudio(entity.Index)
+	}
+	writeJsonResponse(audios, err, w)
+}
+
+func selectSubtitle(srv *service.PlayerService, w http.ResponseWriter, r *http.Request) {
+	var entity api.TrackIndex
+	parser := json.NewDecoder(r.Body)
+	var subtitles []api.Stream
+	var err error
+	if err = parser.Decode(&entity); err == nil {
+		subtitles, err = srv.SelectSubtitle(entity.Index)
+	}
+	writeJsonResponse(subtitles, err, w)
+}
+
+func setPosition(srv *service.PlayerService, w http.ResponseWriter, r *http.Request) {
+	var entity api.Position
+	parser := json.NewDecoder(r.Body)
+	var status api.PlayerStatus
+	var err error
+	if err = parser.Decode(&entity); err == nil {
+		status, err = srv.SetPosition(entity.Position)
+	}
+	writeJsonResponse(status, err, w)
+}
+
+func status(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	st, err := srv.Status()
+	writeJsonResponse(st, err, w)
+}
+
+func stop(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	status, err := srv.Stop()
+	writeJsonResponse(status, err, w)
+}
+
+func subtitles(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	subtitles, err := srv.Subtitles()
+	writeJsonResponse(subtitles, err, w)
+}
+
+func toggleSubtitles(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	st, err := srv.ToggleSubtitles()
+	writeJsonResponse(st, err, w)
+}
+
+func toggleMute(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	st, err := srv.ToggleMute()
+	writeJsonResponse(st, err, w)
+}
+
+func volume(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	v, err := srv.Volume()
+	writeJsonResponse(api.Volume{Volume: v}, err, w)
+}
+
+func volumeDown(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	v, err := srv.VolumeDown()
+	writeJsonResponse(api.Volume{Volume: v}, err, w)
+}
+
+func volumeUp(srv *service.PlayerService, w http.ResponseWriter, _ *http.Request) {
+	v, err := srv.VolumeUp()
+	writeJsonResponse(api.Volume{Volume: v}, err, w)
+}
+
+func wrapFiles(files []string) (paths []api.MoviePath) {
+	paths = make([]api.MoviePath, len(files))
+	for i, f := range files {
+		paths[i] = api.MoviePath{File: f}
+	}
+	return
+}
+
+func unwrapFiles(paths []api.MoviePath) (files []string) {
+	files = make([]string, len(paths))
+	for i, p := range paths {
+		files[i] = p.File
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (s *Server) allPlaylists(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.playlists.All(), nil, w)
+}
+
+func (s *Server) createPlaylist(w http.ResponseWriter, r *http.Request) {
+	var entity api.Playlist
+	var created api.Playlist
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		created, err = s.playlists.Create(entity)
+	}
+	writeJsonResponse(created, err, w)
+}
+
+func (s *Server) playlist(w http.ResponseWriter, r *http.Request) {
+	p, err := s.playlists.Get(param(r, "name"))
+	writeJsonResponse(p, err, w)
+}
+
+func (s *Server) updatePlaylist(w http.ResponseWriter, r *http.Request) {
+	var entity api.Playlist
+	var updated api.Playlist
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		entity.Name = param(r, "name")
+		updated, err = s.playlists.Update(entity)
+	}
+	writeJsonResponse(updated, err, w)
+}
+
+func (s *Server) deletePlaylist(w http.ResponseWriter, r *http.Request) {
+	writeJsonResponse(nil, s.playlists.Delete(param(r, "name")), w)
+}
+
+func (s *Server) insertPlaylistItems(w http.ResponseWriter, r *http.Request) {
+	var entity api.PlaylistItems
+	var p api.Playlist
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		p, err = s.playlists.Insert(param(r, "name"), entity.Position, entity.Items)
+	}
+	writeJsonResponse(p, err, w)
+}
+
+func (s *Server) removePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	var entity api.Position
+	var p api.Playlist
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		p, err = s.playlists.Remove(param(r, "name"), entity.Position)
+	}
+	writeJsonResponse(p, err, w)
+}
+
+func (s *Server) movePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	var entity api.PlaylistMove
+	var p api.Playlist
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		p, err = s.playlists.Move(param(r, "name"), entity.From, entity.To)
+	}
+	writeJsonResponse(p, err, w)
+}
+
+// playPlaylist replaces content of play queue with movies from playlist.
+func (s *Server) playPlaylist(w http.ResponseWriter, r *http.Request) {
+	queue, err := s.playlists.Play(param(r, "name"))
+	writeJsonResponse(wrapFiles(queue), err, w)
+}
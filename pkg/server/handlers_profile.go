@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// Changes of profiles require PIN of the active profile in query parameter "pin" if the active profile is locked.
+
+func (s *Server) allProfiles(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.profiles.All(), nil, w)
+}
+
+func (s *Server) createProfile(w http.ResponseWriter, r *http.Request) {
+	var entity api.Profile
+	var saved api.Profile
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		saved, err = s.profiles.Save(entity, r.URL.Query().Get("pin"))
+	}
+	writeJsonResponse(saved, err, w)
+}
+
+func (s *Server) activeProfile(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.profiles.Active(), nil, w)
+}
+
+func (s *Server) activateProfile(w http.ResponseWriter, r *http.Request) {
+	var entity api.ProfileSwitch
+	var active api.Profile
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		active, err = s.profiles.Activate(entity)
+	}
+	writeJsonResponse(active, err, w)
+}
+
+func (s *Server) profile(w http.ResponseWriter, r *http.Request) {
+	p, err := s.profiles.Get(param(r, "name"))
+	writeJsonResponse(p, err, w)
+}
+
+func (s *Server) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var entity api.Profile
+	var saved api.Profile
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		entity.Name = param(r, "name")
+		saved, err = s.profiles.Save(entity, r.URL.Query().Get("pin"))
+	}
+	writeJsonResponse(saved, err, w)
+}
+
+func (s *Server) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	writeJsonResponse(nil, s.profiles.Delete(param(r, "name"), r.URL.Query().Get("pin")), w)
+}
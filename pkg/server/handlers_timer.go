@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (s *Server) allTimers(w http.ResponseWriter, _ *http.Request) {
+	writeJsonResponse(s.scheduler.All(), nil, w)
+}
+
+func (s *Server) createTimer(w http.ResponseWriter, r *http.Request) {
+	var entity api.Timer
+	var created api.Timer
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&entity); err == nil {
+		created, err = s.scheduler.Create(entity)
+	}
+	writeJsonResponse(created, err, w)
+}
+
+func (s *Server) timer(w http.ResponseWriter, r *http.Request) {
+	var t api.Timer
+	id, err := timerParam(r)
+	if err == nil {
+		t, err = s.scheduler.Get(id)
+	}
+	writeJsonResponse(t, err, w)
+}
+
+func (s *Server) updateTimer(w http.ResponseWriter, r *http.Request) {
+	var entity api.Timer
+	var updated api.Timer
+	id, err := timerParam(r)
+	if err == nil {
+		parser := json.NewDecoder(r.Body)
+		if err = parser.Decode(&entity); err == nil {
+			entity.Id = id
+			updated, err = s.scheduler.Update(entity)
+		}
+	}
+	writeJsonResponse(updated, err, w)
+}
+
+func (s *Server) deleteTimer(w http.ResponseWriter, r *http.Request) {
+	id, err := timerParam(r)
+	if err == nil {
+		err = s.scheduler.Delete(id)
+	}
+	writeJsonResponse(nil, err, w)
+}
+
+func timerParam(r *http.Request) (id int, err error) {
+	if id, err = strconv.Atoi(param(r, "id")); err != nil {
+		err = newErrResponse(fmt.Errorf("invalid timer id: %s", param(r, "id")), http.StatusNotFound)
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+func (s *Server) torrentAddFile(w http.ResponseWriter, r *http.Request) {
+	var torrent api.TorrentFile
+	var err error
+	parser := json.NewDecoder(r.Body)
+	if err = parser.Decode(&torrent); err == nil {
+		var file []byte
+		if file, err = base64.StdEncoding.DecodeString(torrent.Content); err == nil {
+			err = s.torrent.AddFile(file)
+		}
+	}
+	writeJsonResponse(nil, err, w)
+}
+
+func (s *Server) torrentListDownloads(w http.ResponseWriter, _ *http.Request) {
+	d, err := s.torrent.Torrents()
+	writeJsonResponse(d, err, w)
+}
+
+func (s *Server) torrentStop(w http.ResponseWriter, r *http.Request) {
+	var d api.TorrentDownload
+	var err error
+	if d, err = parseTorrentDownload(r); err == nil {
+		err = s.torrent.Stop(d)
+	}
+	if err == nil {
+		d.Stopped = true
+	}
+	writeJsonResponse(d, err, w)
+}
+
+func (s *Server) torrentStart(w http.ResponseWriter, r *http.Request) {
+	var d api.TorrentDownload
+	var err error
+	if d, err = parseTorrentDownload(r); err == nil {
+		err = s.torrent.Start(d)
+	}
+	if err == nil {
+		d.Stopped = false
+	}
+	writeJsonResponse(d, err, w)
+}
+
+// torrentDelete deletes download and responds with remaining downloads.
+func (s *Server) torrentDelete(w http.ResponseWriter, r *http.Request) {
+	var d api.TorrentDownload
+	var ld []api.TorrentDownload
+	var err error
+	if d, err = parseTorrentDownload(r); err == nil {
+		err = s.torrent.Delete(d)
+	}
+	if err == nil {
+		ld, err = s.torrent.Torrents()
+	}
+	writeJsonResponse(ld, err, w)
+}
+
+func parseTorrentDownload(r *http.Request) (d api.TorrentDownload, err error) {
+	parser := json.NewDecoder(r.Body)
+	err = parser.Decode(&d)
+	return
+}
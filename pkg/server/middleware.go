@@ -0,0 +1,147 @@
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// statusWriter remembers status of response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// logging logs requests at debug level, clients poll status of player often, so successful requests are not worth
+// info level. Requests failed with server error are logged at warn level.
+func logging(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		entry := log.WithFields(log.Fields{
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"status":   sw.status,
+			"duration": time.Since(start),
+		})
+		if sw.status >= http.StatusInternalServerError {
+			entry.Warn("Request failed")
+		} else {
+			entry.Debug("Request handled")
+		}
+	})
+}
+
+// recovery turns panic of handler into internal server error, server keeps serving other requests.
+func recovery(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.WithFields(log.Fields{"err": rec, "path": r.URL.Path, "stack": string(debug.Stack())}).Error("Panic occurred while handling request")
+				writeJsonResponse(nil, newErrResponse(fmt.Errorf("internal error: %v", rec), http.StatusInternalServerError), w)
+			}
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// cors lets web pages from other origins call API, preflight requests are answered without calling handler.
+func cors(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+				w.Header().Set("Access-Control-Max-Age", "86400")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// gzipWriter compresses response once handler starts writing it, empty responses are not compressed.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	w.start()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	w.start()
+	return w.gz.Write(b)
+}
+
+func (w *gzipWriter) start() {
+	if w.gz == nil {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+}
+
+func (w *gzipWriter) close() error {
+	if w.gz == nil {
+		return nil
+	}
+	return w.gz.Close()
+}
+
+// gzipped compresses responses for clients which accept gzip encoding.
+func gzipped(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipWriter{ResponseWriter: w}
+		defer func() {
+			if err := gw.close(); err != nil {
+				log.WithFields(log.Fields{"err": err}).Error("Error occurred while write response")
+			}
+		}()
+		h.ServeHTTP(gw, r)
+	})
+}
+
+// limitSize rejects requests with body larger than max bytes, body of request without length is cut at max bytes.
+func limitSize(max int64, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			writeJsonResponse(nil, newErrResponse(fmt.Errorf("request body is too large, max %d bytes", max), http.StatusRequestEntityTooLarge), w)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		h.ServeHTTP(w, r)
+	})
+}
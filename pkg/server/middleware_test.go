@@ -0,0 +1,151 @@
+package server
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterMatchesMethodAndParams(t *testing.T) {
+	rt := createRouter()
+	var name string
+	rt.handle(http.MethodGet, "/api/tags/active", func(w http.ResponseWriter, r *http.Request) { name = "active" })
+	rt.handle(http.MethodGet, "/api/tags/{name}", func(w http.ResponseWriter, r *http.Request) { name = param(r, "name") })
+	rt.handle(http.MethodDelete, "/api/tags/{name}", func(w http.ResponseWriter, r *http.Request) { name = "deleted " + param(r, "name") })
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/tags/active", nil))
+	assert.Equal(t, "active", name)
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/tags/sci%20fi", nil))
+	assert.Equal(t, "sci fi", name)
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/tags/drama", nil))
+	assert.Equal(t, "deleted drama", name)
+}
+
+func TestRouterRespondsWithMethodNotAllowed(t *testing.T) {
+	rt := createRouter()
+	rt.handle(http.MethodPost, "/api/player/pause", func(w http.ResponseWriter, r *http.Request) {})
+	rt.handle(http.MethodPut, "/api/player/pause", func(w http.ResponseWriter, r *http.Request) {})
+	w := httptest.NewRecorder()
+
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/player/pause", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST, PUT", w.Header().Get("Allow"))
+	assert.Contains(t, w.Body.String(), "method GET is not allowed")
+}
+
+func TestLiteralRouteDoesNotHideRouteWithParam(t *testing.T) {
+	rt := createRouter()
+	var name string
+	rt.handle(http.MethodGet, "/api/profiles/active", func(w http.ResponseWriter, r *http.Request) { name = "active" })
+	rt.handle(http.MethodPut, "/api/profiles/active", func(w http.ResponseWriter, r *http.Request) { name = "activate" })
+	rt.handle(http.MethodDelete, "/api/profiles/{name}", func(w http.ResponseWriter, r *http.Request) { name = "deleted " + param(r, "name") })
+	w := httptest.NewRecorder()
+
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/profiles/active", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "deleted active", name)
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/profiles/active", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "DELETE, GET, PUT", w.Header().Get("Allow"))
+}
+
+func TestRouterRespondsWithNotFound(t *testing.T) {
+	rt := createRouter()
+	rt.handle(http.MethodGet, "/api/drives", func(w http.ResponseWriter, r *http.Request) {})
+	w := httptest.NewRecorder()
+
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/drives/archive", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCorsAnswersPreflightRequest(t *testing.T) {
+	called := false
+	h := cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	r := httptest.NewRequest(http.MethodOptions, "/api/player/pause", nil)
+	r.Header.Set("Origin", "http://remote.local")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+}
+
+func TestGzipCompressesResponse(t *testing.T) {
+	body := strings.Repeat(`{"title":"Brave"}`, 100)
+	h := gzipped(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/api/list", nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	gz, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	uncompressed, err := ioutil.ReadAll(gz)
+	assert.Nil(t, err)
+	assert.Equal(t, body, string(uncompressed))
+}
+
+func TestGzipSkipsClientsWithoutGzip(t *testing.T) {
+	h := gzipped(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("plain"))
+	}))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/list", nil))
+
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "plain", w.Body.String())
+}
+
+func TestLimitSizeRejectsLargeRequest(t *testing.T) {
+	called := false
+	h := limitSize(10, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/catalog/import", strings.NewReader(strings.Repeat("a", 11))))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestLimitSizeCutsBodyOfUnknownLength(t *testing.T) {
+	var err error
+	h := limitSize(10, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err = ioutil.ReadAll(r.Body)
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/api/catalog/import", strings.NewReader(strings.Repeat("a", 11)))
+	r.ContentLength = -1
+
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.NotNil(t, err)
+}
+
+func TestRecoveryRespondsWithInternalServerError(t *testing.T) {
+	h := recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("torrent client is not configured") }))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/torrent/list", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "torrent client is not configured")
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// router dispatches requests by path and method. Segment "{name}" of pattern matches any segment of path, its value is
+// available with param. Patterns are matched in order they are added, so literal pattern, e.g. /api/profiles/active,
+// must be added before pattern with parameter, e.g. /api/profiles/{name}. Pattern which matches path but has no handler
+// for method does not hide patterns added after it, response "405 Method Not Allowed" is sent only if none of them has
+// handler for method.
+type router struct {
+	routes []*route
+}
+
+type route struct {
+	pattern  string
+	segments []string
+	handlers map[string]http.HandlerFunc
+}
+
+type paramsKey struct{}
+
+func createRouter() *router {
+	return &router{}
+}
+
+func (rt *router) handle(method, pattern string, h http.HandlerFunc) {
+	for _, r := range rt.routes {
+		if r.pattern == pattern {
+			r.handlers[method] = h
+			return
+		}
+	}
+	rt.routes = append(rt.routes, &route{
+		pattern:  pattern,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handlers: map[string]http.HandlerFunc{method: h},
+	})
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	allowed := make(map[string]bool)
+	for _, route := range rt.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		h, found := route.handlers[method]
+		if !found {
+			for m := range route.handlers {
+				allowed[m] = true
+			}
+			continue
+		}
+		if len(params) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), paramsKey{}, params))
+		}
+		h(w, r)
+		return
+	}
+	if len(allowed) > 0 {
+		methods := make([]string, 0, len(allowed))
+		for m := range allowed {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		writeJsonResponse(nil, newErrResponse(fmt.Errorf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed), w)
+		return
+	}
+	writeJsonResponse(nil, newErrResponse(fmt.Errorf("not found: %s", r.URL.Path), http.StatusNotFound), w)
+}
+
+func (r *route) match(segments []string) (params map[string]string, ok bool) {
+	if len(segments) != len(r.segments) {
+		return
+	}
+	for i, s := range r.segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[s[1:len(s)-1]] = value
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+	ok = true
+	return
+}
+
+// param returns value of path parameter matched by router.
+func param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
@@ -0,0 +1,345 @@
+// Package server implements HTTP API of gomovies on top of services. Handlers are routed by path and method, actions
+// which change state of catalog or player accept only POST, PUT or DELETE requests.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/catalog"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/details"
+	"github.com/andrew00x/gomovies/pkg/playlist"
+	"github.com/andrew00x/gomovies/pkg/profile"
+	"github.com/andrew00x/gomovies/pkg/service"
+	"github.com/andrew00x/gomovies/pkg/timer"
+)
+
+// Services are used by handlers of server. Catalog, Player, Details, Peers, Renderers and Duplicates are required,
+// API of other services is not available if they are nil.
+type Services struct {
+	Catalog     *service.CatalogService
+	Player      *service.PlayerService
+	Details     *service.DetailsService
+	Torrent     *service.TorrentService
+	Playlists   *service.PlaylistService
+	Scheduler   *service.SchedulerService
+	Peers       *service.PeerService
+	Renderers   *service.RendererService
+	Profiles    *service.ProfileService
+	Duplicates  *service.DuplicatesService
+	MediaServer http.Handler
+}
+
+type Server struct {
+	conf              *config.Config
+	catalog           *service.CatalogService
+	player            *service.PlayerService
+	details           *service.DetailsService
+	torrent           *service.TorrentService
+	playlists         *service.PlaylistService
+	scheduler         *service.SchedulerService
+	peers             *service.PeerService
+	renderers         *service.RendererService
+	profiles          *service.ProfileService
+	duplicates        *service.DuplicatesService
+	detailsLoadedFlag int32
+	handler           http.Handler
+}
+
+func CreateServer(conf *config.Config, services Services) *Server {
+	s := &Server{
+		conf:       conf,
+		catalog:    services.Catalog,
+		player:     services.Player,
+		details:    services.Details,
+		torrent:    services.Torrent,
+		playlists:  services.Playlists,
+		scheduler:  services.Scheduler,
+		peers:      services.Peers,
+		renderers:  services.Renderers,
+		profiles:   services.Profiles,
+		duplicates: services.Duplicates,
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/", limitSize(conf.MaxRequestSize, gzipped(s.routes())))
+	mux.Handle("/file/", http.FileServer(contentRepository{prefix: "/file/", conf: conf, catalog: s.catalog}))
+	if services.MediaServer != nil {
+		mux.Handle("/upnp/", services.MediaServer)
+	}
+	s.handler = logging(recovery(cors(mux)))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() *router {
+	rt := createRouter()
+	rt.handle(http.MethodGet, "/api/list", s.allMovies)
+	rt.handle(http.MethodGet, "/api/search", s.searchMovies)
+	rt.handle(http.MethodGet, "/api/person", s.personMovies)
+	rt.handle(http.MethodPost, "/api/update", s.updateMovie)
+	rt.handle(http.MethodPut, "/api/update", s.updateMovie)
+	rt.handle(http.MethodPost, "/api/refresh", s.refresh)
+	rt.handle(http.MethodGet, "/api/catalog/export", s.exportCatalog)
+	rt.handle(http.MethodPost, "/api/catalog/import", s.importCatalog)
+	rt.handle(http.MethodGet, "/api/drives", s.drives)
+	rt.handle(http.MethodPost, "/api/drives/{name}/mount", s.mountDrive)
+	rt.handle(http.MethodGet, "/api/duplicates", s.duplicatesReport)
+	for _, group := range []string{catalog.TagGroup, catalog.CollectionGroup} {
+		rt.handle(http.MethodGet, "/api/"+group, s.labels(group))
+		rt.handle(http.MethodGet, "/api/"+group+"/{name}", s.labelled(group))
+		rt.handle(http.MethodPut, "/api/"+group+"/{name}", s.renameLabel(group))
+		rt.handle(http.MethodDelete, "/api/"+group+"/{name}", s.deleteLabel(group))
+		rt.handle(http.MethodPost, "/api/"+group+"/{name}/movies", s.addLabel(group))
+		rt.handle(http.MethodDelete, "/api/"+group+"/{name}/movies", s.removeLabel(group))
+	}
+
+	rt.handle(http.MethodGet, "/api/details", s.movieDetails)
+	rt.handle(http.MethodGet, "/api/details/search", s.searchDetails)
+	rt.handle(http.MethodGet, "/api/details/nfo", s.detailsNfo)
+	rt.handle(http.MethodPost, "/api/details/nfo", s.exportNfo)
+	rt.handle(http.MethodGet, "/api/franchises", s.franchises)
+	rt.handle(http.MethodGet, "/api/franchises/{id}", s.franchise)
+	rt.handle(http.MethodPost, "/api/franchises/{id}/enqueue", s.enqueueFranchise)
+
+	rt.handle(http.MethodPost, "/api/play", s.playMovie)
+	rt.handle(http.MethodGet, "/api/queue", s.queue)
+	rt.handle(http.MethodPost, "/api/enqueue", s.enqueue)
+	rt.handle(http.MethodPost, "/api/dequeue", s.dequeue)
+	rt.handle(http.MethodPost, "/api/clearqueue", s.clearQueue)
+	rt.handle(http.MethodPost, "/api/shiftqueue", s.shiftQueue)
+	rt.handle(http.MethodPost, "/api/queue/next", s.nextInQueue)
+	rt.handle(http.MethodPost, "/api/queue/previous", s.previousInQueue)
+	rt.handle(http.MethodPost, "/api/queue/jump", s.jumpInQueue)
+	rt.handle(http.MethodGet, "/api/queue/mode", s.queueMode)
+	rt.handle(http.MethodPost, "/api/queue/mode", s.setQueueMode)
+	rt.handle(http.MethodPut, "/api/queue/mode", s.setQueueMode)
+	rt.handle(http.MethodGet, "/api/peers", s.allPeers)
+	rt.handle(http.MethodGet, "/api/peers/list", s.allPeerMovies)
+	rt.handle(http.MethodGet, "/api/renderers", s.allRenderers)
+	rt.handle(http.MethodGet, "/api/player/audios", s.withPlayer(audios))
+	rt.handle(http.MethodGet, "/api/player/status", s.withPlayer(status))
+	rt.handle(http.MethodGet, "/api/player/subtitles", s.withPlayer(subtitles))
+	rt.handle(http.MethodGet, "/api/player/volume", s.withPlayer(volume))
+	rt.handle(http.MethodPost, "/api/player/nextaudiotrack", s.withPlayer(nextAudioTrack))
+	rt.handle(http.MethodPost, "/api/player/nextsubtitle", s.withPlayer(nextSubtitle))
+	rt.handle(http.MethodPost, "/api/player/pause", s.withPlayer(pause))
+	rt.handle(http.MethodPost, "/api/player/play", s.withPlayer(play))
+	rt.handle(http.MethodPost, "/api/player/playpause", s.withPlayer(playPause))
+	rt.handle(http.MethodPost, "/api/player/previousaudiotrack", s.withPlayer(previousAudioTrack))
+	rt.handle(http.MethodPost, "/api/player/previoussubtitle", s.withPlayer(previousSubtitle))
+	rt.handle(http.MethodPost, "/api/player/replay", s.withPlayer(replayCurrent))
+	rt.handle(http.MethodPost, "/api/player/seek", s.withPlayer(seek))
+	rt.handle(http.MethodPost, "/api/player/audio", s.withPlayer(selectAudio))
+	rt.handle(http.MethodPost, "/api/player/subtitle", s.withPlayer(selectSubtitle))
+	rt.handle(http.MethodPost, "/api/player/position", s.withPlayer(setPosition))
+	rt.handle(http.MethodPost, "/api/player/stop", s.withPlayer(stop))
+	rt.handle(http.MethodPost, "/api/player/togglemute", s.withPlayer(toggleMute))
+	rt.handle(http.MethodPost, "/api/player/togglesubtitles", s.withPlayer(toggleSubtitles))
+	rt.handle(http.MethodPost, "/api/player/volumedown", s.withPlayer(volumeDown))
+	rt.handle(http.MethodPost, "/api/player/volumeup", s.withPlayer(volumeUp))
+
+	if s.playlists != nil {
+		rt.handle(http.MethodGet, "/api/playlists", s.allPlaylists)
+		rt.handle(http.MethodPost, "/api/playlists", s.createPlaylist)
+		rt.handle(http.MethodGet, "/api/playlists/{name}", s.playlist)
+		rt.handle(http.MethodPut, "/api/playlists/{name}", s.updatePlaylist)
+		rt.handle(http.MethodDelete, "/api/playlists/{name}", s.deletePlaylist)
+		rt.handle(http.MethodPost, "/api/playlists/{name}/items", s.insertPlaylistItems)
+		rt.handle(http.MethodDelete, "/api/playlists/{name}/items", s.removePlaylistItem)
+		rt.handle(http.MethodPost, "/api/playlists/{name}/move", s.movePlaylistItem)
+		rt.handle(http.MethodPost, "/api/playlists/{name}/play", s.playPlaylist)
+	}
+
+	if s.profiles != nil {
+		rt.handle(http.MethodGet, "/api/profiles", s.allProfiles)
+		rt.handle(http.MethodPost, "/api/profiles", s.createProfile)
+		rt.handle(http.MethodGet, "/api/profiles/active", s.activeProfile)
+		rt.handle(http.MethodPost, "/api/profiles/active", s.activateProfile)
+		rt.handle(http.MethodPut, "/api/profiles/active", s.activateProfile)
+		rt.handle(http.MethodGet, "/api/profiles/{name}", s.profile)
+		rt.handle(http.MethodPut, "/api/profiles/{name}", s.updateProfile)
+		rt.handle(http.MethodDelete, "/api/profiles/{name}", s.deleteProfile)
+	}
+
+	if s.scheduler != nil {
+		rt.handle(http.MethodGet, "/api/timers", s.allTimers)
+		rt.handle(http.MethodPost, "/api/timers", s.createTimer)
+		rt.handle(http.MethodGet, "/api/timers/{id}", s.timer)
+		rt.handle(http.MethodPut, "/api/timers/{id}", s.updateTimer)
+		rt.handle(http.MethodDelete, "/api/timers/{id}", s.deleteTimer)
+	}
+
+	if s.torrent != nil {
+		rt.handle(http.MethodPost, "/api/torrent/add", s.torrentAddFile)
+		rt.handle(http.MethodGet, "/api/torrent/list", s.torrentListDownloads)
+		rt.handle(http.MethodPost, "/api/torrent/stop", s.torrentStop)
+		rt.handle(http.MethodPost, "/api/torrent/start", s.torrentStart)
+		rt.handle(http.MethodPost, "/api/torrent/delete", s.torrentDelete)
+	}
+	return rt
+}
+
+func (s *Server) isDetailsLoaded() (loaded bool) {
+	if atomic.LoadInt32(&s.detailsLoadedFlag) != 0 {
+		loaded = true
+	}
+	return
+}
+
+func (s *Server) setDetailsLoaded(value bool) {
+	var f int32
+	if value {
+		f = 1
+	}
+	atomic.StoreInt32(&s.detailsLoadedFlag, f)
+}
+
+// LoadDetails loads details of all movies in catalog and tags movies with titles, genres and people. It takes a while,
+// so it should be run in separate goroutine. Until details are loaded list of movies contains only cached details.
+func (s *Server) LoadDetails() {
+	if s.isDetailsLoaded() {
+		log.Info("Skip loading movies' details since they are already loaded")
+		return
+	}
+	log.Info("Start loading movies' details")
+	startDetailsLoad := time.Now()
+	for _, m := range s.catalog.Unrestricted() {
+		for _, lang := range s.conf.DetailsLangs {
+			if d, ok, e := s.details.MovieDetails(m, lang, true); e != nil {
+				log.WithFields(log.Fields{"err": e, "movie": m.Title}).Warn("Error occurred while loading movie details")
+			} else if ok {
+				tags := []string{d.Title, d.OriginalTitle}
+				for _, g := range d.Genres {
+					tags = append(tags, g)
+				}
+				tags = append(tags, service.People(d)...)
+				for _, t := range tags {
+					if t != "" {
+						e = s.catalog.AddTag(t, m.Id)
+						if e != nil {
+							log.WithFields(log.Fields{"err": e, "movie": m.Title}).Warn("Error occurred while adding tag for movie")
+						}
+					}
+				}
+			}
+		}
+	}
+	stopDetailsLoad := time.Now()
+	log.WithFields(log.Fields{
+		"spent_time": stopDetailsLoad.Sub(startDetailsLoad).Truncate(time.Second),
+	}).Info("Stop loading movies' details")
+	s.setDetailsLoaded(true)
+}
+
+type contentRepository struct {
+	prefix  string
+	conf    *config.Config
+	catalog *service.CatalogService
+	http.FileSystem
+}
+
+func (fs contentRepository) Open(name string) (http.File, error) {
+	invalid := true
+	path := filepath.Join("/", strings.TrimPrefix(name, fs.prefix))
+	for _, d := range fs.conf.Dirs {
+		if d = filepath.Clean(d); path == d || strings.HasPrefix(path, strings.TrimSuffix(d, string(filepath.Separator))+string(filepath.Separator)) {
+			invalid = false
+			break
+		}
+	}
+	if invalid {
+		return nil, os.ErrNotExist
+	}
+	if !fs.catalog.Allowed(path) {
+		return nil, os.ErrPermission
+	}
+	return os.Open(path)
+}
+
+type errResponse struct {
+	err  error
+	code int
+}
+
+func newErrResponse(err error, code int) *errResponse {
+	return &errResponse{err: err, code: code}
+}
+
+func isErrResponse(err error) bool {
+	_, ok := err.(*errResponse)
+	return ok
+}
+
+func (e *errResponse) Error() string {
+	return e.err.Error()
+}
+
+// isForbidden checks whether error is caused by restrictions of the active profile.
+func isForbidden(err error) bool {
+	_, restricted := err.(*service.RestrictedError)
+	return restricted || err == service.ErrProfileLocked
+}
+
+// isNotFound checks whether error is caused by request to unknown playlist, profile, timer or label.
+func isNotFound(err error) bool {
+	switch err.(type) {
+	case *playlist.NotFoundError, *profile.NotFoundError, *timer.NotFoundError, *catalog.LabelNotFoundError:
+		return true
+	}
+	return false
+}
+
+// isConflict checks whether error is caused by state of server, e.g. offline drive or existing NFO file.
+func isConflict(err error) bool {
+	switch err.(type) {
+	case *service.DriveOfflineError, *details.NfoExistsError:
+		return true
+	}
+	return false
+}
+
+func writeJsonResponse(body interface{}, err error, w http.ResponseWriter) {
+	if body == nil && err == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err == nil {
+		if e := encoder.Encode(body); e != nil {
+			log.WithFields(log.Fields{"err": e}).Error("Error occurred while write response")
+		}
+	} else {
+		if isErrResponse(err) {
+			w.WriteHeader(err.(*errResponse).code)
+		} else if isForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+		} else if isNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if isConflict(err) {
+			w.WriteHeader(http.StatusConflict)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		m := api.MessagePayload{Message: err.Error()}
+		if offline, ok := err.(*service.DriveOfflineError); ok {
+			m.Code = "mount_drive"
+			m.Drive = offline.Drive
+		}
+		if e := encoder.Encode(m); e != nil {
+			log.WithFields(log.Fields{"err": e}).Error("Error occurred while write response")
+		}
+	}
+}
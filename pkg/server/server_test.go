@@ -0,0 +1,219 @@
+package server_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/server/servertest"
+)
+
+var movies = []api.Movie{
+	{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv", Available: true},
+	{Id: 2, File: "/movies/up.mkv", Title: "up.mkv", Available: true},
+}
+
+func request(t *testing.T, s *servertest.Server, method, path, body string, result interface{}) *http.Response {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	assert.Nil(t, err)
+	resp, err := s.Client().Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	if result != nil {
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(result))
+	}
+	return resp
+}
+
+func TestPlayMovie(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	var status api.PlayerStatus
+	resp := request(t, s, http.MethodPost, "/api/play", `{"file":"/movies/up.mkv","position":60}`, &status)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, api.PlayerStatus{File: "/movies/up.mkv", Position: 60}, status)
+	assert.Equal(t, []string{"play /movies/up.mkv"}, s.Player.Actions())
+}
+
+func TestPlayerActions(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	request(t, s, http.MethodPost, "/api/play", `{"file":"/movies/brave.mkv"}`, nil)
+
+	var status api.PlayerStatus
+	request(t, s, http.MethodPost, "/api/player/pause", "", &status)
+	assert.True(t, status.Paused)
+
+	request(t, s, http.MethodPost, "/api/player/togglemute", "", &status)
+	assert.True(t, status.Muted)
+
+	var volume api.Volume
+	request(t, s, http.MethodPost, "/api/player/volumeup", "", &volume)
+	assert.InDelta(t, 0.1, volume.Volume, 0.001)
+
+	request(t, s, http.MethodGet, "/api/player/status", "", &status)
+	assert.Equal(t, "/movies/brave.mkv", status.File)
+	assert.Equal(t, []string{"play /movies/brave.mkv", "pause", "togglemute", "volumeup"}, s.Player.Actions())
+}
+
+func TestPlayerActionRequiresPost(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	resp := request(t, s, http.MethodGet, "/api/player/stop", "", nil)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, http.MethodPost, resp.Header.Get("Allow"))
+	assert.Empty(t, s.Player.Actions())
+}
+
+func TestPlayerOfUnknownHost(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	var msg api.MessagePayload
+	resp := request(t, s, http.MethodPost, "/api/player/pause?host=bedroom", "", &msg)
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, msg.Message, "bedroom")
+}
+
+func TestEnqueueAndPlayNext(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	var files []api.MoviePath
+	request(t, s, http.MethodPost, "/api/enqueue", `[{"file":"/movies/brave.mkv"},{"file":"/movies/up.mkv"}]`, &files)
+	assert.Equal(t, []api.MoviePath{{File: "/movies/brave.mkv"}, {File: "/movies/up.mkv"}}, files)
+
+	var queue api.Queue
+	request(t, s, http.MethodPost, "/api/queue/next", "", &queue)
+	request(t, s, http.MethodGet, "/api/queue", "", &queue)
+	assert.Equal(t, 2, len(queue.Items))
+	assert.Contains(t, s.Player.Actions(), "play /movies/up.mkv")
+}
+
+func TestUpdateAndListMovies(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	var updated api.Movie
+	resp := request(t, s, http.MethodPut, "/api/update", `{"id":2,"tmdb_id":14160}`, &updated)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 14160, updated.TMDbId)
+
+	resp = request(t, s, http.MethodPut, "/api/update", `{"id":2,"overrides":{"title":"Up (2009)"}}`, &updated)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 14160, updated.TMDbId)
+	assert.Equal(t, "Up (2009)", updated.Overrides.Title)
+
+	var list []api.Movie
+	request(t, s, http.MethodGet, "/api/list?details=false", "", &list)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, 14160, list[1].TMDbId)
+
+	var found []api.Movie
+	request(t, s, http.MethodGet, "/api/search?q=brave", "", &found)
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, 1, found[0].Id)
+}
+
+func TestChangesOfCatalogRequirePinOfLockedProfile(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	resp := request(t, s, http.MethodPost, "/api/profiles", `{"name":"kids","pin":"1234"}`, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = request(t, s, http.MethodPost, "/api/profiles/active", `{"name":"kids"}`, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = request(t, s, http.MethodPut, "/api/update", `{"id":2,"tmdb_id":14160}`, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = request(t, s, http.MethodPost, "/api/catalog/import?format=json", `[{"file":"/movies/up.mkv"}]`, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	m, _ := s.Catalog.Get(2)
+	assert.Equal(t, 0, m.TMDbId)
+
+	var updated api.Movie
+	resp = request(t, s, http.MethodPut, "/api/update?pin=1234", `{"id":2,"tmdb_id":14160}`, &updated)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 14160, updated.TMDbId)
+}
+
+func TestRestrictedMovieIsNotFound(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	request(t, s, http.MethodPost, "/api/profiles", `{"name":"kids","allowedDirs":["/movies/kids"]}`, nil)
+	request(t, s, http.MethodPost, "/api/profiles/active", `{"name":"kids"}`, nil)
+
+	resp := request(t, s, http.MethodGet, "/api/details?id=1", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = request(t, s, http.MethodGet, "/api/details/nfo?id=1", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestLabels(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	var m api.Movie
+	request(t, s, http.MethodPost, "/api/tags/sci%20fi/movies?id=1", "", &m)
+	assert.Equal(t, []string{"sci fi"}, m.Tags)
+
+	var labelled []api.Movie
+	request(t, s, http.MethodGet, "/api/tags/sci%20fi", "", &labelled)
+	assert.Equal(t, 1, len(labelled))
+
+	var msg api.MessagePayload
+	resp := request(t, s, http.MethodDelete, "/api/tags/drama", "", &msg)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestMountDrive(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+	s.Catalog.AddDrive(api.Drive{Name: "archive", Attached: true})
+
+	var drives []api.Drive
+	resp := request(t, s, http.MethodPost, "/api/drives/archive/mount", "", &drives)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"archive"}, s.Catalog.Mounted())
+	assert.True(t, drives[0].Mounted)
+}
+
+func TestAddAndListTorrents(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	content := base64.StdEncoding.EncodeToString([]byte("d8:announce"))
+	resp := request(t, s, http.MethodPost, "/api/torrent/add", `{"file":"`+content+`"}`, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, [][]byte{[]byte("d8:announce")}, s.Torrent.Added())
+
+	var downloads []api.TorrentDownload
+	request(t, s, http.MethodGet, "/api/torrent/list", "", &downloads)
+	assert.Equal(t, []api.TorrentDownload{{Name: "torrent-0"}}, downloads)
+
+	var stopped api.TorrentDownload
+	request(t, s, http.MethodPost, "/api/torrent/stop", `{"name":"torrent-0"}`, &stopped)
+	assert.True(t, stopped.Stopped)
+
+	request(t, s, http.MethodPost, "/api/torrent/delete", `{"name":"torrent-0"}`, &downloads)
+	assert.Empty(t, downloads)
+}
+
+func TestUnavailableApiIsNotFound(t *testing.T) {
+	s := servertest.NewServer(movies...)
+	defer s.Close()
+
+	resp := request(t, s, http.MethodGet, "/api/playlists", "", nil)
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
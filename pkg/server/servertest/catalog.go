@@ -0,0 +1,228 @@
+package servertest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/catalog"
+)
+
+// Catalog keeps movies in memory, files of movies are not checked. Movies are found by substring of title.
+type Catalog struct {
+	mu      sync.Mutex
+	movies  map[int]*api.Movie
+	drives  []api.Drive
+	mounted []string
+}
+
+// NewCatalog creates catalog with movies, it may be used without server through service.CreateCatalogServiceWith.
+func NewCatalog(movies ...api.Movie) *Catalog {
+	return createCatalog(movies)
+}
+
+func createCatalog(movies []api.Movie) *Catalog {
+	c := &Catalog{movies: make(map[int]*api.Movie)}
+	for i := range movies {
+		m := movies[i]
+		c.movies[m.Id] = &m
+	}
+	return c
+}
+
+// AddDrive adds drive which is reported by Drives.
+func (c *Catalog) AddDrive(d api.Drive) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drives = append(c.drives, d)
+}
+
+// Mounted returns names of drives mounted with MountDrive.
+func (c *Catalog) Mounted() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.mounted...)
+}
+
+func (c *Catalog) All() []api.Movie {
+	return c.filter(func(api.Movie) bool { return true })
+}
+
+func (c *Catalog) Find(title string) []api.Movie {
+	title = strings.ToLower(title)
+	return c.filter(func(m api.Movie) bool { return strings.Contains(strings.ToLower(m.Title), title) })
+}
+
+func (c *Catalog) Get(id int) (api.Movie, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if m, ok := c.movies[id]; ok {
+		return *m, true
+	}
+	return api.Movie{}, false
+}
+
+func (c *Catalog) GetByFile(path string) (api.Movie, bool) {
+	movies := c.filter(func(m api.Movie) bool { return m.File == path })
+	if len(movies) == 0 {
+		return api.Movie{}, false
+	}
+	return movies[0], true
+}
+
+func (c *Catalog) Load() error    { return nil }
+func (c *Catalog) Refresh() error { return nil }
+func (c *Catalog) Save() error    { return nil }
+
+func (c *Catalog) Update(u api.Movie) (api.Movie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.movies[u.Id]
+	if !ok {
+		return api.Movie{}, fmt.Errorf("unknown movie, id: %d, title: %s", u.Id, u.Title)
+	}
+	// fields which are not set are left unchanged and empty options or overrides are removed, as catalog.JsonCatalog does
+	if u.TMDbId != 0 {
+		m.TMDbId = u.TMDbId
+	}
+	if u.PlayerOptions != nil {
+		if reflect.DeepEqual(*u.PlayerOptions, api.PlayerOptions{}) {
+			m.PlayerOptions = nil
+		} else {
+			m.PlayerOptions = u.PlayerOptions
+		}
+	}
+	if u.Overrides != nil {
+		if reflect.DeepEqual(*u.Overrides, api.Overrides{}) {
+			m.Overrides = nil
+		} else {
+			m.Overrides = u.Overrides
+		}
+	}
+	return *m, nil
+}
+
+func (c *Catalog) AddTag(string, int) error { return nil }
+
+func (c *Catalog) AddLabel(group string, id int, name string) (api.Movie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.movies[id]
+	if !ok {
+		return api.Movie{}, fmt.Errorf("unknown movie, id: %d", id)
+	}
+	labels := labelsOf(m, group)
+	for _, l := range *labels {
+		if l == name {
+			return *m, nil
+		}
+	}
+	*labels = append(*labels, name)
+	return *m, nil
+}
+
+func (c *Catalog) RemoveLabel(group string, id int, name string) (api.Movie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.movies[id]
+	if !ok {
+		return api.Movie{}, fmt.Errorf("unknown movie, id: %d", id)
+	}
+	labels := labelsOf(m, group)
+	*labels = without(*labels, name)
+	return *m, nil
+}
+
+func (c *Catalog) RenameLabel(group, name, newName string) error {
+	return c.changeLabel(group, name, func(labels []string) []string { return append(without(labels, name), newName) })
+}
+
+func (c *Catalog) DeleteLabel(group, name string) error {
+	return c.changeLabel(group, name, func(labels []string) []string { return without(labels, name) })
+}
+
+func (c *Catalog) Drives() ([]api.Drive, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]api.Drive{}, c.drives...), nil
+}
+
+func (c *Catalog) MountDrive(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.drives {
+		if c.drives[i].Name == name {
+			c.drives[i].Mounted = true
+			c.mounted = append(c.mounted, name)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown drive: %s", name)
+}
+
+// Import matches imported movies by file only.
+func (c *Catalog) Import(movies []api.Movie) (result api.ImportResult, err error) {
+	result.Unmatched = []string{}
+	result.Rejected = []string{}
+	for _, m := range movies {
+		if _, found := c.GetByFile(m.File); found {
+			result.Matched++
+		} else {
+			result.Unmatched = append(result.Unmatched, m.File)
+		}
+	}
+	return
+}
+
+func (c *Catalog) filter(accept func(m api.Movie) bool) []api.Movie {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make([]api.Movie, 0)
+	for _, m := range c.movies {
+		if accept(*m) {
+			result = append(result, *m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+func (c *Catalog) changeLabel(group, name string, change func(labels []string) []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := false
+	for _, m := range c.movies {
+		labels := labelsOf(m, group)
+		for _, l := range *labels {
+			if l == name {
+				*labels = change(*labels)
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return &catalog.LabelNotFoundError{Group: group, Name: name}
+	}
+	return nil
+}
+
+func labelsOf(m *api.Movie, group string) *[]string {
+	if group == catalog.CollectionGroup {
+		return &m.Collections
+	}
+	return &m.Tags
+}
+
+func without(labels []string, name string) []string {
+	var result []string
+	for _, l := range labels {
+		if l != name {
+			result = append(result, l)
+		}
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package servertest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/player"
+)
+
+// Player keeps state of playback in memory and records invoked actions. Err is returned by every action if it is set.
+type Player struct {
+	mu        sync.Mutex
+	actions   []string
+	status    api.PlayerStatus
+	volume    float64
+	listeners []player.PlayListener
+	Err       error
+}
+
+// Actions returns names of invoked actions, e.g. "pause" or "play /movies/brave.mkv".
+func (p *Player) Actions() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.actions...)
+}
+
+func (p *Player) AddListener(l player.PlayListener) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners = append(p.listeners, l)
+}
+
+func (p *Player) AudioTracks() ([]api.Stream, error) {
+	return p.streams("audios")
+}
+
+func (p *Player) Subtitles() ([]api.Stream, error) {
+	return p.streams("subtitles")
+}
+
+func (p *Player) NextAudioTrack() error     { return p.act("nextaudiotrack", nil) }
+func (p *Player) NextSubtitle() error       { return p.act("nextsubtitle", nil) }
+func (p *Player) PreviousAudioTrack() error { return p.act("previousaudiotrack", nil) }
+func (p *Player) PreviousSubtitle() error   { return p.act("previoussubtitle", nil) }
+func (p *Player) ReplayCurrent() error      { return p.act("replay", func() { p.status.Position = 0 }) }
+
+func (p *Player) SelectAudio(index int) error {
+	return p.act("audio", func() { p.status.ActiveAudioTrack = index })
+}
+
+func (p *Player) SelectSubtitle(index int) error {
+	return p.act("subtitle", func() { p.status.ActiveSubtitle = index })
+}
+
+func (p *Player) Pause() error { return p.act("pause", func() { p.status.Paused = true }) }
+func (p *Player) Play() error  { return p.act("play", func() { p.status.Paused = false }) }
+
+func (p *Player) PlayPause() error {
+	return p.act("playpause", func() { p.status.Paused = !p.status.Paused })
+}
+
+func (p *Player) PlayMovie(path string, opts player.LaunchOptions) error {
+	return p.act("play "+path, func() {
+		p.status = api.PlayerStatus{File: path, Position: int(opts.Position / time.Second)}
+	})
+}
+
+func (p *Player) Seek(offset time.Duration) error {
+	return p.act("seek", func() { p.status.Position += int(offset / time.Second) })
+}
+
+func (p *Player) SetPosition(position time.Duration) error {
+	return p.act("position", func() { p.status.Position = int(position / time.Second) })
+}
+
+func (p *Player) Status() (api.PlayerStatus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := p.status
+	status.Stopped = status.File == ""
+	return status, p.Err
+}
+
+func (p *Player) Stop() error { return p.act("stop", func() { p.status = api.PlayerStatus{} }) }
+
+func (p *Player) ToggleMute() error {
+	return p.act("togglemute", func() { p.status.Muted = !p.status.Muted })
+}
+
+func (p *Player) ToggleSubtitles() error {
+	return p.act("togglesubtitles", func() { p.status.SubtitlesOff = !p.status.SubtitlesOff })
+}
+
+func (p *Player) Volume() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volume, p.Err
+}
+
+func (p *Player) VolumeDown() error { return p.act("volumedown", func() { p.volume -= 0.1 }) }
+func (p *Player) VolumeUp() error   { return p.act("volumeup", func() { p.volume += 0.1 }) }
+
+func (p *Player) streams(action string) ([]api.Stream, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.actions = append(p.actions, action)
+	return []api.Stream{}, p.Err
+}
+
+func (p *Player) act(action string, change func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.actions = append(p.actions, action)
+	if p.Err != nil {
+		return p.Err
+	}
+	if change != nil {
+		change()
+	}
+	return nil
+}
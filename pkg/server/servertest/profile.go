@@ -0,0 +1,74 @@
+package servertest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/profile"
+)
+
+// Profiles keeps profiles and name of the active profile in memory.
+type Profiles struct {
+	mu       sync.Mutex
+	profiles map[string]api.Profile
+	active   string
+}
+
+func createProfiles() *Profiles {
+	return &Profiles{profiles: make(map[string]api.Profile)}
+}
+
+func (p *Profiles) All() []api.Profile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]api.Profile, 0, len(p.profiles))
+	for _, pr := range p.profiles {
+		result = append(result, pr)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (p *Profiles) Get(name string) (api.Profile, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pr, ok := p.profiles[name]
+	return pr, ok
+}
+
+func (p *Profiles) Save(pr api.Profile) (api.Profile, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.profiles[pr.Name] = pr
+	return pr, nil
+}
+
+func (p *Profiles) Delete(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.profiles[name]; !ok {
+		return &profile.NotFoundError{Name: name}
+	}
+	delete(p.profiles, name)
+	if p.active == name {
+		p.active = ""
+	}
+	return nil
+}
+
+func (p *Profiles) Active() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active
+}
+
+func (p *Profiles) SetActive(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.profiles[name]; !ok && name != "" {
+		return &profile.NotFoundError{Name: name}
+	}
+	p.active = name
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package servertest provides HTTP server of gomovies with fake player, torrent client and catalog for tests.
+package servertest
+
+import (
+	"net/http/httptest"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/server"
+	"github.com/andrew00x/gomovies/pkg/service"
+)
+
+// Server serves API with real handlers and services on top of fakes. Playlists and timers are not available since their
+// services keep data in files.
+type Server struct {
+	*httptest.Server
+	Player   *Player
+	Torrent  *Torrent
+	Catalog  *Catalog
+	Profiles *Profiles
+	// Services are real services used by handlers, e.g. restriction may be set to catalog service
+	Services server.Services
+}
+
+// NewServer starts server with movies in catalog, server must be closed by caller.
+func NewServer(movies ...api.Movie) *Server {
+	return NewServerWithConfig(&config.Config{WebPort: 8000, MaxRequestSize: 16 << 20, DetailsLangs: []string{"en"}}, movies...)
+}
+
+// NewServerWithConfig starts server with specified configuration, server must be closed by caller.
+func NewServerWithConfig(conf *config.Config, movies ...api.Movie) *Server {
+	s := &Server{Player: &Player{}, Torrent: &Torrent{}, Catalog: createCatalog(movies), Profiles: createProfiles()}
+
+	var services server.Services
+	var err error
+	services.Catalog = service.CreateCatalogServiceWith(s.Catalog, conf)
+	services.Player = service.CreatePlayerServiceWith(s.Player, conf, services.Catalog)
+	if services.Details, err = service.CreateDetailsService(conf); err != nil {
+		panic(err)
+	}
+	services.Profiles = service.CreateProfileServiceWith(s.Profiles, conf, services.Catalog, services.Details)
+	services.Torrent = service.CreateTorrentServiceWith(s.Torrent)
+	services.Peers = service.CreatePeerService(conf, services.Catalog, services.Player)
+	services.Renderers = service.CreateRendererService(conf, services.Catalog)
+	services.Duplicates = service.CreateDuplicatesService(conf, services.Catalog, services.Details)
+	s.Services = services
+	s.Server = httptest.NewServer(server.CreateServer(conf, services))
+	return s
+}
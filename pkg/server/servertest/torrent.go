@@ -0,0 +1,71 @@
+package servertest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+)
+
+// Torrent keeps downloads in memory, every added torrent file or url becomes download named after its index.
+type Torrent struct {
+	mu        sync.Mutex
+	added     [][]byte
+	downloads []api.TorrentDownload
+	Err       error
+}
+
+// Added returns content of added torrent files.
+func (t *Torrent) Added() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([][]byte(nil), t.added...)
+}
+
+func (t *Torrent) AddFile(b []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Err != nil {
+		return t.Err
+	}
+	t.added = append(t.added, b)
+	t.downloads = append(t.downloads, api.TorrentDownload{Name: fmt.Sprintf("torrent-%d", len(t.downloads))})
+	return nil
+}
+
+func (t *Torrent) AddUrl(u string) error {
+	return t.AddFile([]byte(u))
+}
+
+func (t *Torrent) Torrents() ([]api.TorrentDownload, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]api.TorrentDownload{}, t.downloads...), t.Err
+}
+
+func (t *Torrent) Start(d api.TorrentDownload) error {
+	return t.update(d.Name, func(i int) { t.downloads[i].Stopped = false })
+}
+
+func (t *Torrent) Stop(d api.TorrentDownload) error {
+	return t.update(d.Name, func(i int) { t.downloads[i].Stopped = true })
+}
+
+func (t *Torrent) Delete(d api.TorrentDownload) error {
+	return t.update(d.Name, func(i int) { t.downloads = append(t.downloads[:i], t.downloads[i+1:]...) })
+}
+
+func (t *Torrent) update(name string, change func(i int)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Err != nil {
+		return t.Err
+	}
+	for i, d := range t.downloads {
+		if d.Name == name {
+			change(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown torrent: %s", name)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+)
+
+// certifications lists age ratings of countries from the least to the most restrictive. Countries which use age as
+// rating, e.g. "12" or "16+", are not listed, age is compared for them.
+var certifications = map[string][]string{
+	"AU": {"E", "G", "PG", "M", "MA15+", "R18+", "X18+"},
+	"CA": {"G", "PG", "14A", "18A", "R", "A"},
+	"GB": {"U", "PG", "12A", "12", "15", "18", "R18"},
+	"IE": {"G", "PG", "12A", "15A", "16", "18"},
+	"NZ": {"G", "PG", "M", "R13", "R15", "R16", "R18", "R"},
+	"US": {"G", "PG", "PG-13", "R", "NC-17"},
+}
+
+// certificationRank gives position of certification in scale of country, false is returned if certification is
+// unknown.
+func certificationRank(country, cert string) (int, bool) {
+	cert = strings.TrimSpace(cert)
+	if cert == "" {
+		return 0, false
+	}
+	if scale, ok := certifications[strings.ToUpper(country)]; ok {
+		for i, c := range scale {
+			if strings.EqualFold(c, cert) {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	age, err := strconv.Atoi(strings.TrimSuffix(cert, "+"))
+	if err != nil {
+		return 0, false
+	}
+	return age, true
+}
+
+// ValidCertification checks whether certification is known for country.
+func ValidCertification(country, cert string) bool {
+	_, ok := certificationRank(country, cert)
+	return ok
+}
+
+// CertificationAllowed checks whether certification is at or under max. Movie without certification or with unknown
+// certification is not allowed.
+func CertificationAllowed(country, cert, max string) bool {
+	rank, ok := certificationRank(country, cert)
+	if !ok {
+		return false
+	}
+	maxRank, ok := certificationRank(country, max)
+	return ok && rank <= maxRank
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/playlist"
+)
+
+// PlayQueue is a list of files with cursor which points to the current item. Items before cursor are history of
+// playback, items after cursor are upcoming. When shuffle mode is on upcoming items are kept in random order, order in
+// which they were added is restored when shuffle mode is turned off.
+type PlayQueue struct {
+	lock  sync.Mutex
+	items []string
+	// added keeps sequence numbers of items in order they were added to queue, it is shuffled together with items
+	added     []int
+	lastAdded int
+	cursor    int
+	// ended is set when playback of the current item is finished and there is nothing to play next
+	ended       bool
+	repeat      string
+	shuffle     bool
+	autoAdvance bool
+	shuffleFunc func(n int, swap func(i, j int))
+}
+
+func CreatePlayQueue() *PlayQueue {
+	return &PlayQueue{cursor: -1, autoAdvance: true, shuffleFunc: rand.Shuffle}
+}
+
+func (q *PlayQueue) Enqueue(paths []string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items = append(q.items, paths...)
+	for range paths {
+		q.lastAdded++
+		q.added = append(q.added, q.lastAdded)
+	}
+	if q.shuffle {
+		q.shuffleUpcoming()
+	}
+}
+
+func (q *PlayQueue) Dequeue(i int) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if i < 0 || i >= len(q.items) {
+		return fmt.Errorf("invalid queue position: %d", i)
+	}
+	if i == q.cursor && !q.ended {
+		return fmt.Errorf("unable remove item %d from queue since it is played at the moment", i)
+	}
+	q.items = append(q.items[:i], q.items[i+1:]...)
+	q.added = append(q.added[:i], q.added[i+1:]...)
+	if i <= q.cursor {
+		q.cursor--
+		if q.cursor < 0 {
+			q.ended = false
+		}
+	}
+	return nil
+}
+
+// Current returns item which is played at the moment or was played last if playback is stopped.
+func (q *PlayQueue) Current() (path string, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.hasCurrent() {
+		path, ok = q.items[q.cursor], true
+	}
+	return
+}
+
+// QueueMove is a move of cursor found with PeekNext, PeekPrevious or PeekJump. It is applied with Commit once playback
+// of the item is started, so failed attempt does not change queue.
+type QueueMove struct {
+	Path   string
+	cursor int
+	// order is new order of items when shuffled queue is repeated
+	order []int
+}
+
+// Next moves cursor to the next item. If auto is true cursor is moved because playback of the current item is
+// finished and current item is returned again in repeat one mode. Queue is marked as ended if there is no next item.
+func (q *PlayQueue) Next(auto bool) (path string, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var m QueueMove
+	if m, ok = q.peekNext(auto); !ok {
+		q.ended = q.cursor >= 0
+		return
+	}
+	q.commit(m)
+	return m.Path, true
+}
+
+// PeekNext finds the next item like Next does but does not move cursor.
+func (q *PlayQueue) PeekNext(auto bool) (QueueMove, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.peekNext(auto)
+}
+
+func (q *PlayQueue) peekNext(auto bool) (m QueueMove, ok bool) {
+	if auto && q.repeat == playlist.RepeatOne && q.hasCurrent() {
+		return QueueMove{Path: q.items[q.cursor], cursor: q.cursor}, true
+	}
+	if q.cursor+1 < len(q.items) {
+		return QueueMove{Path: q.items[q.cursor+1], cursor: q.cursor + 1}, true
+	}
+	if q.repeat == playlist.RepeatAll && len(q.items) > 0 {
+		if !q.shuffle {
+			return QueueMove{Path: q.items[0]}, true
+		}
+		order := make([]int, len(q.items))
+		for i := range order {
+			order[i] = i
+		}
+		q.shuffleFunc(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		return QueueMove{Path: q.items[order[0]], order: order}, true
+	}
+	return
+}
+
+func (q *PlayQueue) Previous() (path string, ok bool) {
+	var m QueueMove
+	if m, ok = q.PeekPrevious(); ok {
+		q.Commit(m)
+		path = m.Path
+	}
+	return
+}
+
+// PeekPrevious finds the previous item like Previous does but does not move cursor.
+func (q *PlayQueue) PeekPrevious() (m QueueMove, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	cursor := q.cursor
+	switch {
+	case q.ended:
+		// the last played item is played again
+	case q.cursor > 0:
+		cursor--
+	case q.repeat == playlist.RepeatAll && len(q.items) > 0:
+		cursor = len(q.items) - 1
+	default:
+		return
+	}
+	return QueueMove{Path: q.items[cursor], cursor: cursor}, true
+}
+
+func (q *PlayQueue) Jump(i int) (path string, ok bool) {
+	var m QueueMove
+	if m, ok = q.PeekJump(i); ok {
+		q.Commit(m)
+		path = m.Path
+	}
+	return
+}
+
+// PeekJump finds item at specified position like Jump does but does not move cursor.
+func (q *PlayQueue) PeekJump(i int) (m QueueMove, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if i < 0 || i >= len(q.items) {
+		return
+	}
+	return QueueMove{Path: q.items[i], cursor: i}, true
+}
+
+// Commit moves cursor as it was found by one of Peek methods. If queue was changed in the meantime, item of move is made
+// current like with PlayNow.
+func (q *PlayQueue) Commit(m QueueMove) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.commit(m)
+}
+
+func (q *PlayQueue) commit(m QueueMove) {
+	if m.order != nil && len(m.order) == len(q.items) {
+		items := make([]string, len(q.items))
+		added := make([]int, len(q.added))
+		for i, j := range m.order {
+			items[i], added[i] = q.items[j], q.added[j]
+		}
+		q.items, q.added = items, added
+	}
+	if m.cursor < len(q.items) && q.items[m.cursor] == m.Path {
+		q.cursor = m.cursor
+		q.ended = false
+		return
+	}
+	q.playNow(m.Path)
+}
+
+// PlayNow makes path current item of queue. Path is inserted right after the current item unless it is already
+// current.
+func (q *PlayQueue) PlayNow(path string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.playNow(path)
+}
+
+func (q *PlayQueue) playNow(path string) {
+	if q.hasCurrent() && q.items[q.cursor] == path {
+		return
+	}
+	i := q.cursor + 1
+	q.items = append(q.items[:i], append([]string{path}, q.items[i:]...)...)
+	q.lastAdded++
+	q.added = append(q.added[:i], append([]int{q.lastAdded}, q.added[i:]...)...)
+	q.cursor = i
+	q.ended = false
+}
+
+func (q *PlayQueue) SetMode(mode api.QueueMode) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if mode.Repeat != playlist.RepeatOff && mode.Repeat != playlist.RepeatOne && mode.Repeat != playlist.RepeatAll {
+		return fmt.Errorf("invalid repeat mode '%s', supported: %s, %s", mode.Repeat, playlist.RepeatOne, playlist.RepeatAll)
+	}
+	if mode.Shuffle && !q.shuffle {
+		q.shuffleUpcoming()
+	} else if !mode.Shuffle && q.shuffle {
+		q.restoreUpcoming()
+	}
+	q.repeat = mode.Repeat
+	q.shuffle = mode.Shuffle
+	q.autoAdvance = mode.AutoAdvance
+	return nil
+}
+
+func (q *PlayQueue) Mode() api.QueueMode {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return api.QueueMode{Repeat: q.repeat, Shuffle: q.shuffle, AutoAdvance: q.autoAdvance}
+}
+
+func (q *PlayQueue) Empty() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.items) == 0
+}
+
+func (q *PlayQueue) All() []string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([]string{}, q.items...)
+}
+
+// State returns content of queue together with position of the current item, -1 if there is no current item.
+func (q *PlayQueue) State() api.Queue {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	state := api.Queue{
+		Items:   make([]api.MoviePath, len(q.items)),
+		Current: -1,
+		Mode:    api.QueueMode{Repeat: q.repeat, Shuffle: q.shuffle, AutoAdvance: q.autoAdvance},
+	}
+	for i, item := range q.items {
+		state.Items[i] = api.MoviePath{File: item}
+	}
+	if q.hasCurrent() {
+		state.Current = q.cursor
+	}
+	return state
+}
+
+func (q *PlayQueue) Clear() {
+	q.lock.Lock()
+	q.items = nil
+	q.added = nil
+	q.cursor = -1
+	q.ended = false
+	q.lock.Unlock()
+}
+
+func (q *PlayQueue) hasCurrent() bool {
+	return !q.ended && q.cursor >= 0 && q.cursor < len(q.items)
+}
+
+func (q *PlayQueue) shuffleUpcoming() {
+	first := q.cursor + 1
+	q.shuffleFunc(len(q.items)-first, func(i, j int) { q.swap(first+i, first+j) })
+}
+
+// restoreUpcoming puts upcoming items back in order they were added to queue.
+func (q *PlayQueue) restoreUpcoming() {
+	sort.Sort(upcomingItems{q})
+}
+
+func (q *PlayQueue) swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.added[i], q.added[j] = q.added[j], q.added[i]
+}
+
+type upcomingItems struct {
+	q *PlayQueue
+}
+
+func (u upcomingItems) Len() int      { return len(u.q.items) - u.q.cursor - 1 }
+func (u upcomingItems) Swap(i, j int) { u.q.swap(u.q.cursor+1+i, u.q.cursor+1+j) }
+func (u upcomingItems) Less(i, j int) bool {
+	return u.q.added[u.q.cursor+1+i] < u.q.added[u.q.cursor+1+j]
+}
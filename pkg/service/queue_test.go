@@ -4,61 +4,186 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/playlist"
 )
 
 func TestEnqueue(t *testing.T) {
-	q := PlayQueue{}
+	q := CreatePlayQueue()
 	q.Enqueue([]string{"/a.avi", "/b.avi"})
-	assert.Equal(t, []string{"/a.avi", "/b.avi"}, q.arr)
+	assert.Equal(t, []string{"/a.avi", "/b.avi"}, q.items)
+	assert.Equal(t, -1, q.cursor)
 }
 
-func TestPop(t *testing.T) {
-	q := PlayQueue{arr: []string{"/a.mkv", "/b.mkv"}}
-	p := q.Pop()
-	assert.Equal(t, "/a.mkv", p)
-	assert.Equal(t, []string{"/b.mkv"}, q.arr)
+func TestDequeue(t *testing.T) {
+	q := createTestQueue("/a.mkv", "/b.mkv", "/c.mkv")
+	assert.Nil(t, q.Dequeue(1))
+	assert.Equal(t, []string{"/a.mkv", "/c.mkv"}, q.items)
 }
 
-func TestDequeue(t *testing.T) {
-	q := PlayQueue{arr: []string{"/a.mkv", "/b.mkv", "/c.mkv"}}
-	q.Dequeue(1)
-	assert.Equal(t, []string{"/a.mkv", "/c.mkv"}, q.arr)
+func TestDequeueMovesCursor(t *testing.T) {
+	q := createTestQueue("/a.mkv", "/b.mkv", "/c.mkv")
+	q.Jump(2)
+	assert.Nil(t, q.Dequeue(0))
+	current, _ := q.Current()
+	assert.Equal(t, "/c.mkv", current)
 }
 
-func TestPopWhenEmpty(t *testing.T) {
-	q := PlayQueue{}
-	p := q.Pop()
-	assert.Equal(t, "", p)
+func TestDequeueFailsWhenItemIsPlayed(t *testing.T) {
+	q := createTestQueue("/a.mkv", "/b.mkv")
+	q.Next(false)
+	assert.NotNil(t, q.Dequeue(0))
+	assert.Equal(t, []string{"/a.mkv", "/b.mkv"}, q.items)
 }
 
-func TestEmptyWhenEmpty(t *testing.T) {
-	q := PlayQueue{}
-	assert.Equal(t, true, q.Empty())
+func TestDequeueFailsWhenPositionInvalid(t *testing.T) {
+	q := createTestQueue("/a.mkv")
+	assert.NotNil(t, q.Dequeue(1))
 }
 
 func TestEmpty(t *testing.T) {
-	q := PlayQueue{arr: []string{"/a.avi"}}
-	assert.Equal(t, false, q.Empty())
+	assert.Equal(t, true, CreatePlayQueue().Empty())
+	assert.Equal(t, false, createTestQueue("/a.avi").Empty())
 }
 
 func TestAll(t *testing.T) {
-	q := PlayQueue{arr: []string{"/a.avi", "/b.avi"}}
+	q := createTestQueue("/a.avi", "/b.avi")
 	assert.Equal(t, []string{"/a.avi", "/b.avi"}, q.All())
 }
 
 func TestAllWhenEmpty(t *testing.T) {
-	q := PlayQueue{}
+	q := CreatePlayQueue()
 	assert.Equal(t, []string{}, q.All())
 }
 
-func TestClear(t *testing.T) {
-	q := PlayQueue{arr: []string{"/a.avi", "/b.avi"}}
+func TestClearKeepsMode(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	assert.Nil(t, q.SetMode(api.QueueMode{Repeat: playlist.RepeatAll, AutoAdvance: false}))
+	q.Next(false)
 	q.Clear()
-	assert.Equal(t, 0, len(q.arr))
+	assert.Equal(t, 0, len(q.items))
+	assert.Equal(t, -1, q.cursor)
+	assert.Equal(t, api.QueueMode{Repeat: playlist.RepeatAll, AutoAdvance: false}, q.Mode())
+}
+
+func TestNextKeepsHistory(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	assert.Equal(t, pathResult("/a.avi", true), pathResult(q.Next(false)))
+	assert.Equal(t, pathResult("/b.avi", true), pathResult(q.Next(false)))
+	assert.Equal(t, []string{"/a.avi", "/b.avi"}, q.items)
+	assert.Equal(t, 1, q.State().Current)
+}
+
+func TestNextWithoutRepeat(t *testing.T) {
+	q := createTestQueue("/a.avi")
+	q.Next(false)
+	assert.Equal(t, pathResult("", false), pathResult(q.Next(true)))
+	assert.Equal(t, -1, q.State().Current)
+}
+
+func TestNextRepeatsAll(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	assert.Nil(t, q.SetMode(api.QueueMode{Repeat: playlist.RepeatAll}))
+	q.Next(false)
+	q.Next(false)
+	assert.Equal(t, pathResult("/a.avi", true), pathResult(q.Next(true)))
+}
+
+func TestNextRepeatsOne(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	assert.Nil(t, q.SetMode(api.QueueMode{Repeat: playlist.RepeatOne}))
+	q.Next(false)
+	assert.Equal(t, pathResult("/a.avi", true), pathResult(q.Next(true)))
+	assert.Equal(t, pathResult("/b.avi", true), pathResult(q.Next(false)))
+}
+
+func TestPrevious(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	q.Next(false)
+	q.Next(false)
+	assert.Equal(t, pathResult("/a.avi", true), pathResult(q.Previous()))
+	assert.Equal(t, pathResult("", false), pathResult(q.Previous()))
+}
+
+func TestPreviousAfterQueueEnded(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	q.Next(false)
+	q.Next(false)
+	q.Next(true)
+	assert.Equal(t, pathResult("/b.avi", true), pathResult(q.Previous()))
+}
+
+func TestJump(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi", "/c.avi")
+	assert.Equal(t, pathResult("/c.avi", true), pathResult(q.Jump(2)))
+	assert.Equal(t, pathResult("/b.avi", true), pathResult(q.Previous()))
+	assert.Equal(t, pathResult("", false), pathResult(q.Jump(3)))
+}
+
+func TestPlayNowInsertsAfterCurrent(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	q.Next(false)
+	q.PlayNow("/x.avi")
+	assert.Equal(t, []string{"/a.avi", "/x.avi", "/b.avi"}, q.items)
+	assert.Equal(t, 1, q.State().Current)
+}
+
+func TestShuffleKeepsHistory(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi", "/c.avi")
+	q.shuffleFunc = reverse
+	q.Next(false)
+	assert.Nil(t, q.SetMode(api.QueueMode{Shuffle: true}))
+	assert.Equal(t, []string{"/a.avi", "/c.avi", "/b.avi"}, q.items)
+}
+
+func TestTurningShuffleOffRestoresOrder(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi", "/c.avi", "/d.avi")
+	q.shuffleFunc = reverse
+	q.Next(false)
+	assert.Nil(t, q.SetMode(api.QueueMode{Shuffle: true}))
+	q.Enqueue([]string{"/e.avi"})
+	assert.Equal(t, []string{"/a.avi", "/e.avi", "/b.avi", "/c.avi", "/d.avi"}, q.items)
+
+	assert.Nil(t, q.SetMode(api.QueueMode{}))
+
+	assert.Equal(t, 0, q.State().Current)
+	assert.Equal(t, []string{"/a.avi", "/b.avi", "/c.avi", "/d.avi", "/e.avi"}, q.items)
+}
+
+func TestSetModeFailsWhenRepeatInvalid(t *testing.T) {
+	q := CreatePlayQueue()
+	assert.NotNil(t, q.SetMode(api.QueueMode{Repeat: "twice"}))
+}
+
+func TestState(t *testing.T) {
+	q := createTestQueue("/a.avi", "/b.avi")
+	q.Next(false)
+	expected := api.Queue{
+		Items:   []api.MoviePath{{File: "/a.avi"}, {File: "/b.avi"}},
+		Current: 0,
+		Mode:    api.QueueMode{AutoAdvance: true},
+	}
+	assert.Equal(t, expected, q.State())
+}
+
+func createTestQueue(paths ...string) *PlayQueue {
+	q := CreatePlayQueue()
+	q.Enqueue(paths)
+	return q
+}
+
+func reverse(n int, swap func(i, j int)) {
+	for i := 0; i < n/2; i++ {
+		swap(i, n-1-i)
+	}
+}
+
+type result struct {
+	path string
+	ok   bool
 }
 
-func TestShift(t *testing.T) {
-	q := PlayQueue{arr: []string{"/a.mkv", "/b.mkv", "/c.mkv", "/d.mkv"}}
-	q.Shift(2)
-	assert.Equal(t, []string{"/c.mkv", "/d.mkv"}, q.arr)
+func pathResult(path string, ok bool) result {
+	return result{path: path, ok: ok}
 }
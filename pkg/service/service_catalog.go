@@ -1,7 +1,13 @@
 package service
 
 import (
+	"fmt"
+	"io"
+	"path/filepath"
 	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/andrew00x/gomovies/pkg/api"
 	"github.com/andrew00x/gomovies/pkg/catalog"
@@ -9,8 +15,37 @@ import (
 )
 
 type CatalogService struct {
-	ctl  catalog.Catalog
-	conf *config.Config
+	ctl         catalog.Catalog
+	conf        *config.Config
+	restriction Restriction
+}
+
+// Restriction decides which movies and files may be listed, played and downloaded.
+type Restriction interface {
+	AllowsMovie(m api.Movie) bool
+	// AllowsFile is used for files which are not in catalog, e.g. posters
+	AllowsFile(path string) bool
+}
+
+// RestrictedError is returned when movie is not allowed by the active profile.
+type RestrictedError struct {
+	File string
+}
+
+func (e *RestrictedError) Error() string {
+	return fmt.Sprintf("movie %s is restricted by the active profile", e.File)
+}
+
+// DriveOfflineError is returned when movie is on drive which is not mounted. Attached is true if drive is plugged in,
+// so it may be mounted with MountDrive.
+type DriveOfflineError struct {
+	Drive    string
+	File     string
+	Attached bool
+}
+
+func (e *DriveOfflineError) Error() string {
+	return fmt.Sprintf("mount drive %s to play %s", e.Drive, e.File)
 }
 
 type ByName []api.Movie
@@ -27,24 +62,88 @@ func CreateCatalogService(conf *config.Config) (*CatalogService, error) {
 	return createCatalogService(ctl, conf), nil
 }
 
+// CreateCatalogServiceWith creates service on top of catalog which is not created from configuration, e.g. fake catalog
+// of servertest.
+func CreateCatalogServiceWith(ctl catalog.Catalog, conf *config.Config) *CatalogService {
+	return createCatalogService(ctl, conf)
+}
+
 func createCatalogService(ctl catalog.Catalog, conf *config.Config) *CatalogService {
 	return &CatalogService{ctl: ctl, conf: conf}
 }
 
+// SetRestriction sets restriction which is applied to listing of movies.
+func (srv *CatalogService) SetRestriction(r Restriction) {
+	srv.restriction = r
+}
+
+// All returns movies allowed by restriction.
 func (srv *CatalogService) All() []api.Movie {
+	return srv.restrict(srv.Unrestricted())
+}
+
+// Unrestricted returns all movies regardless of restriction, it is meant for maintenance, e.g. loading of details.
+func (srv *CatalogService) Unrestricted() []api.Movie {
 	res := srv.ctl.All()
 	sort.Sort(ByName(res))
 	return res
 }
 
+// Allowed checks whether file may be played or downloaded. File which is not in catalog is allowed if restriction
+// allows it or if it is in the same directory as any allowed movie, e.g. poster of movie. Path is made absolute and
+// cleaned, if it is symbolic link then file it points to must be allowed as well.
+func (srv *CatalogService) Allowed(path string) bool {
+	if srv.restriction == nil {
+		return true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || !srv.allowedPath(abs) {
+		return false
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		if real, err = filepath.Abs(real); err != nil {
+			return false
+		}
+		if real != abs {
+			return srv.allowedPath(real)
+		}
+	}
+	return true
+}
+
+func (srv *CatalogService) allowedPath(path string) bool {
+	if m, found := srv.ctl.GetByFile(path); found {
+		return srv.restriction.AllowsMovie(m)
+	}
+	if srv.restriction.AllowsFile(path) {
+		return true
+	}
+	dir := filepath.Dir(path)
+	for _, m := range srv.ctl.All() {
+		if filepath.Dir(m.File) == dir && srv.restriction.AllowsMovie(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns movie by id, movie which is not allowed by restriction is not found.
 func (srv *CatalogService) Get(id int) (api.Movie, bool) {
-	return srv.ctl.Get(id)
+	m, found := srv.ctl.Get(id)
+	if found && srv.restriction != nil && !srv.restriction.AllowsMovie(m) {
+		return api.Movie{}, false
+	}
+	return m, found
+}
+
+func (srv *CatalogService) GetByFile(path string) (api.Movie, bool) {
+	return srv.ctl.GetByFile(path)
 }
 
 func (srv *CatalogService) Find(title string) []api.Movie {
 	res := srv.ctl.Find(title)
 	sort.Sort(ByName(res))
-	return res
+	return srv.restrict(res)
 }
 
 func (srv *CatalogService) Save() error {
@@ -62,3 +161,121 @@ func (srv *CatalogService) Update(u api.Movie) (api.Movie, error) {
 func (srv *CatalogService) AddTag(tag string, id int) error {
 	return srv.ctl.AddTag(tag, id)
 }
+
+// AddLabel puts user tag or collection on movie, group is either catalog.TagGroup or catalog.CollectionGroup.
+func (srv *CatalogService) AddLabel(group string, id int, name string) (api.Movie, error) {
+	return srv.ctl.AddLabel(group, id, name)
+}
+
+func (srv *CatalogService) RemoveLabel(group string, id int, name string) (api.Movie, error) {
+	return srv.ctl.RemoveLabel(group, id, name)
+}
+
+func (srv *CatalogService) RenameLabel(group, name, newName string) error {
+	return srv.ctl.RenameLabel(group, name, newName)
+}
+
+func (srv *CatalogService) DeleteLabel(group, name string) error {
+	return srv.ctl.DeleteLabel(group, name)
+}
+
+// Labels returns user tags or collections sorted by name with number of movies allowed by restriction in each of them.
+func (srv *CatalogService) Labels(group string) []api.Label {
+	counts := make(map[string]int)
+	for _, m := range srv.All() {
+		for _, l := range catalog.MovieLabels(m, group) {
+			counts[l]++
+		}
+	}
+	result := make([]api.Label, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, api.Label{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Labelled returns movies allowed by restriction which have user tag or belong to collection, case of name is ignored.
+func (srv *CatalogService) Labelled(group, name string) []api.Movie {
+	var result []api.Movie
+	for _, m := range srv.All() {
+		for _, l := range catalog.MovieLabels(m, group) {
+			if strings.EqualFold(l, name) {
+				result = append(result, m)
+				break
+			}
+		}
+	}
+	sort.Sort(ByName(result))
+	return result
+}
+
+// Export writes movies allowed by restriction, format is either catalog.JsonFormat or catalog.CsvFormat.
+func (srv *CatalogService) Export(w io.Writer, format string) error {
+	movies := srv.All()
+	sort.Slice(movies, func(i, j int) bool { return movies[i].Id < movies[j].Id })
+	return catalog.Export(w, movies, format)
+}
+
+// Import merges movies exported with Export into catalog.
+func (srv *CatalogService) Import(r io.Reader, format string) (api.ImportResult, error) {
+	movies, err := catalog.ReadExport(r, format)
+	if err != nil {
+		return api.ImportResult{}, err
+	}
+	return srv.ctl.Import(movies)
+}
+
+func (srv *CatalogService) Drives() ([]api.Drive, error) {
+	return srv.ctl.Drives()
+}
+
+// MountDrive mounts attached drive and refreshes catalog, so movies added to drive while it was offline are found.
+func (srv *CatalogService) MountDrive(name string) error {
+	if err := srv.ctl.MountDrive(name); err != nil {
+		return err
+	}
+	return srv.ctl.Refresh()
+}
+
+// EnsureAvailable checks whether drive of movie is mounted. Drive which is attached but not mounted is mounted if it is
+// enabled in configuration, otherwise DriveOfflineError is returned. Files which are not in catalog are not checked.
+func (srv *CatalogService) EnsureAvailable(path string) error {
+	m, found := srv.ctl.GetByFile(path)
+	if !found || m.Available || m.DriveName == "" {
+		return nil
+	}
+	drives, err := srv.ctl.Drives()
+	if err != nil {
+		return err
+	}
+	offline := &DriveOfflineError{Drive: m.DriveName, File: path}
+	for _, d := range drives {
+		if d.Name == m.DriveName {
+			if d.Mounted {
+				return nil
+			}
+			offline.Attached = d.Attached
+		}
+	}
+	if offline.Attached && srv.conf.AutoMount {
+		if err = srv.MountDrive(m.DriveName); err == nil {
+			return nil
+		}
+		log.WithFields(log.Fields{"drive": m.DriveName, "err": err}).Warn("Unable mount drive")
+	}
+	return offline
+}
+
+func (srv *CatalogService) restrict(movies []api.Movie) []api.Movie {
+	if srv.restriction == nil {
+		return movies
+	}
+	result := make([]api.Movie, 0, len(movies))
+	for _, m := range movies {
+		if srv.restriction.AllowsMovie(m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
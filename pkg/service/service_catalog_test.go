@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/catalog"
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+func TestLabelsAreCountedAndSorted(t *testing.T) {
+	movies := []api.Movie{
+		{Id: 1, Title: "home alone.mkv", Tags: []string{"Christmas", "Watch with kids"}},
+		{Id: 2, Title: "die hard.mkv", Tags: []string{"Christmas"}, Collections: []string{"Die Hard"}},
+		{Id: 3, Title: "brave.mkv", Tags: []string{"Watch with kids"}},
+	}
+	srv := createCatalogService(&catalogMock{movies: movies}, &config.Config{})
+
+	assert.Equal(t, []api.Label{{Name: "Christmas", Count: 2}, {Name: "Watch with kids", Count: 2}}, srv.Labels(catalog.TagGroup))
+	assert.Equal(t, []api.Label{{Name: "Die Hard", Count: 1}}, srv.Labels(catalog.CollectionGroup))
+	var titles []string
+	for _, m := range srv.Labelled(catalog.TagGroup, "christmas") {
+		titles = append(titles, m.Title)
+	}
+	assert.Equal(t, []string{"die hard.mkv", "home alone.mkv"}, titles)
+}
+
+func TestPlayMovieOnOfflineDrive(t *testing.T) {
+	movies := []api.Movie{
+		{Id: 1, File: "/media/pi/wd500/brave.mkv", DriveName: "wd500"},
+		{Id: 2, File: "/media/pi/wd320/cars.mkv", DriveName: "wd320"},
+	}
+	drives := []api.Drive{{Name: "wd500", Device: "/dev/sdb1", Attached: true}, {Name: "wd320"}}
+	ctl := &catalogMock{movies: movies, drives: drives}
+	conf := &config.Config{}
+	p := &playerMock{}
+	srv := createPlayerService(p, CreatePlayQueue(), createCatalogService(ctl, conf), conf)
+
+	_, err := srv.PlayMovie(api.Playback{File: "/media/pi/wd500/brave.mkv"})
+	assert.Equal(t, &DriveOfflineError{Drive: "wd500", File: "/media/pi/wd500/brave.mkv", Attached: true}, err)
+	assert.Empty(t, ctl.mounted)
+
+	conf.AutoMount = true
+	_, err = srv.PlayMovie(api.Playback{File: "/media/pi/wd500/brave.mkv"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wd500"}, ctl.mounted)
+
+	_, err = srv.PlayMovie(api.Playback{File: "/media/pi/wd320/cars.mkv"})
+	assert.EqualError(t, err, "mount drive wd320 to play /media/pi/wd320/cars.mkv")
+	assert.Equal(t, []string{"wd500"}, ctl.mounted)
+}
@@ -2,170 +2,298 @@ package service
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/andrew00x/gomovies/pkg/api"
 	"github.com/andrew00x/gomovies/pkg/config"
 	"github.com/andrew00x/gomovies/pkg/details"
+	"github.com/andrew00x/gomovies/pkg/omdb"
 	"github.com/andrew00x/gomovies/pkg/tmdb"
-	"github.com/andrew00x/gomovies/pkg/util"
 )
 
+// Person roles which may be used to find movies of person.
+const (
+	RoleCast     = "cast"
+	RoleDirector = "director"
+	RoleWriter   = "writer"
+)
+
+// DetailsService gives details of movies from metadata providers. Providers are ordered by priority, details found by
+// provider with higher priority win and providers with lower priority fill the gaps. Local files are preferred over
+// TMDb and OMDb is used as the last resort.
 type DetailsService struct {
-	tmdbLoader  *tmdbLoader
-	localLoader *localLoader
+	conf      *config.Config
+	providers []MetadataProvider
+	tmdb      *tmdbProvider
 }
 
-func CreateDetailsService(conf *config.Config) (srv *DetailsService, err error) {
-	if conf.TMDbApiKey == "" {
-		srv = &DetailsService{
-			tmdbLoader: nil,
-			localLoader: &localLoader{
-				cache: util.CreateCache(),
-			},
-		}
-	} else {
-		tmdbConn := tmdb.GetTmDbInstance(conf.TMDbApiKey)
-		var tmdbConf tmdb.Config
-		tmdbConf, err = tmdbConn.GetConfiguration()
+func CreateDetailsService(conf *config.Config) (*DetailsService, error) {
+	providers := []MetadataProvider{createLocalProvider()}
+	if conf.TMDbApiKey != "" {
+		p, err := createTmdbProvider(conf, tmdb.GetTmDbInstance(conf.TMDbApiKey))
 		if err != nil {
-			return
-		}
-		srv = &DetailsService{
-			tmdbLoader: &tmdbLoader{
-				conf:     conf,
-				tmdbConf: tmdbConf,
-				tmdbConn: tmdbConn,
-				cache:    util.CreateCache(),
-			},
-			localLoader: &localLoader{
-				cache: util.CreateCache(),
-			},
+			return nil, err
 		}
+		providers = append(providers, p)
 	}
-	return
+	if conf.OMDbApiKey != "" {
+		providers = append(providers, createOmdbProvider(conf, omdb.CreateOMDb(conf.OMDbApiKey)))
+	}
+	return createDetailsService(conf, providers...), nil
 }
 
-func (srv *DetailsService) MovieDetails(m api.Movie, lang string, tryLoad bool) (api.MovieDetails, bool, error) {
-	if m.TMDbId != 0 && srv.tmdbLoader != nil {
-		return srv.tmdbLoader.load(m, lang, tryLoad)
+func createDetailsService(conf *config.Config, providers ...MetadataProvider) *DetailsService {
+	srv := &DetailsService{conf: conf, providers: providers}
+	for _, p := range providers {
+		if t, ok := p.(*tmdbProvider); ok {
+			srv.tmdb = t
+		}
 	}
-	return srv.localLoader.load(m, lang, tryLoad)
+	return srv
 }
 
-func (srv *DetailsService) SearchDetails(query, lang string) ([]api.MovieDetails, error) {
-	if srv.tmdbLoader != nil {
-		result, err := srv.tmdbLoader.tmdbConn.SearchMovies(query, lang)
-		if err == nil {
-			movies := make([]api.MovieDetails, 0, len(result))
-			for _, tmDbMovie := range result {
-				movies = append(movies,
-					api.MovieDetails{
-						OriginalTitle:  tmDbMovie.OriginalTitle,
-						Overview:       tmDbMovie.Overview,
-						PosterSmallUrl: fmt.Sprintf("%s%s%s", srv.tmdbLoader.tmdbConf.Images.BaseUrl, srv.tmdbLoader.conf.TMDbPosterSmall, tmDbMovie.PosterPath),
-						PosterLargeUrl: fmt.Sprintf("%s%s%s", srv.tmdbLoader.tmdbConf.Images.BaseUrl, srv.tmdbLoader.conf.TMDbPosterLarge, tmDbMovie.PosterPath),
-						ReleaseDate:    tmDbMovie.ReleaseDate,
-						TMDbId:         tmDbMovie.Id,
-					})
+// MovieDetails merges details of movie found by all providers, overrides set by user win over details of providers.
+// Error is returned only if neither providers found details nor movie has overrides.
+func (srv *DetailsService) MovieDetails(m api.Movie, lang string, tryLoad bool) (md api.MovieDetails, found bool, err error) {
+	for _, p := range srv.providers {
+		d, ok, e := p.Details(m, md, lang, tryLoad)
+		if e != nil {
+			if err == nil {
+				err = e
 			}
-			return movies, nil
+			continue
+		}
+		if ok {
+			mergeDetails(&md, d)
+			found = true
 		}
+	}
+	if m.Overrides != nil {
+		applyOverrides(&md, *m.Overrides)
+		found = true
+	}
+	md.Tags = m.Tags
+	if found {
+		err = nil
+	}
+	return
+}
+
+// Nfo creates Kodi NFO document with details of movie.
+func (srv *DetailsService) Nfo(m api.Movie, lang string) ([]byte, error) {
+	md, err := srv.detailsForExport(m, lang)
+	if err != nil {
 		return nil, err
 	}
-	return nil, nil
+	return details.MarshalNfo(md)
 }
 
-type localDetailsKey struct {
-	file string
-	lang string
+// ExportNfo saves details of movie in Kodi NFO file next to movie file. Existing NFO file is replaced only if overwrite
+// is true.
+func (srv *DetailsService) ExportNfo(m api.Movie, lang string, overwrite bool) error {
+	md, err := srv.detailsForExport(m, lang)
+	if err != nil {
+		return err
+	}
+	return details.WriteNfo(m.File, md, overwrite)
+}
+
+func (srv *DetailsService) detailsForExport(m api.Movie, lang string) (api.MovieDetails, error) {
+	md, found, err := srv.MovieDetails(m, lang, true)
+	if err == nil && !found {
+		err = fmt.Errorf("there is no details for movie %s, lang %s", m.File, lang)
+	}
+	return md, err
 }
 
-type localLoader struct {
-	cache util.Cache
+// Credited checks whether person with specified name took part in making of movie. Role is one of RoleCast,
+// RoleDirector, RoleWriter or empty string for any role. Only details which are already loaded are checked.
+func (srv *DetailsService) Credited(m api.Movie, lang, name, role string) bool {
+	md, found, err := srv.MovieDetails(m, lang, false)
+	return err == nil && found && credited(md, name, role)
 }
 
-func (l *localLoader) load(m api.Movie, lang string, tryLoad bool) (md api.MovieDetails, found bool, err error) {
-	var v interface{}
-	if tryLoad {
-		v, err = l.cache.GetOrLoad(localDetailsKey{file: m.File, lang: lang}, func(key util.Key) (interface{}, error) {
-			k := key.(localDetailsKey)
-			return details.GetDetails(k.file, k.lang)
-		})
-	} else {
-		v, err = l.cache.Get(localDetailsKey{file: m.File, lang: lang})
+// FilterByCertification keeps movies which are rated at or under max certification of configured country. Movies
+// without certification are filtered out as well as movies which details are not loaded yet.
+func (srv *DetailsService) FilterByCertification(movies []api.Movie, lang, max string) ([]api.Movie, error) {
+	if !ValidCertification(srv.conf.CertificationCountry, max) {
+		return nil, fmt.Errorf("unknown certification %s for country %s", max, srv.conf.CertificationCountry)
 	}
-	if err == nil && v != nil {
-		found = true
-		md = v.(api.MovieDetails)
+	result := make([]api.Movie, 0, len(movies))
+	for _, m := range movies {
+		md, found, err := srv.MovieDetails(m, lang, false)
+		if err == nil && found && CertificationAllowed(srv.conf.CertificationCountry, md.Certification, max) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// Franchises groups movies into TMDb collections, only already loaded details of movies are used. Parts of franchise are
+// movies from the list ordered by release date, missing parts are known once franchise is loaded with Franchise.
+func (srv *DetailsService) Franchises(movies []api.Movie, lang string) []api.Franchise {
+	owned := srv.franchiseParts(movies, lang)
+	result := make([]api.Franchise, 0, len(owned))
+	for id, parts := range owned {
+		f := api.Franchise{Id: id, Name: parts[0].name, Parts: []api.FranchisePart{}}
+		if srv.tmdb != nil {
+			if loaded, found, err := srv.tmdb.collection(id, lang, false); err == nil && found {
+				f = loaded
+			}
+		}
+		result = append(result, mergeParts(f, parts))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Franchise loads TMDb collection and shows which of its parts are in the list of movies and which are missing.
+func (srv *DetailsService) Franchise(id int, movies []api.Movie, lang string) (f api.Franchise, err error) {
+	if srv.tmdb == nil {
+		err = fmt.Errorf("collections are not available without TMDb api key")
+		return
 	}
+	var found bool
+	if f, found, err = srv.tmdb.collection(id, lang, true); err != nil {
+		return
+	} else if !found {
+		err = fmt.Errorf("unknown collection, id: %d", id)
+		return
+	}
+	f = mergeParts(f, srv.franchiseParts(movies, lang)[id])
 	return
 }
 
-type tmdbDetailsKey struct {
-	id   int
-	lang string
+type ownedPart struct {
+	api.FranchisePart
+	name string
 }
 
-type tmdbLoader struct {
-	conf     *config.Config
-	tmdbConf tmdb.Config
-	tmdbConn *tmdb.TmDb
-	cache    util.Cache
+func (srv *DetailsService) franchiseParts(movies []api.Movie, lang string) map[int][]ownedPart {
+	result := make(map[int][]ownedPart)
+	for _, m := range movies {
+		if md, found, err := srv.MovieDetails(m, lang, false); err == nil && found && md.CollectionId != 0 {
+			result[md.CollectionId] = append(result[md.CollectionId], ownedPart{
+				FranchisePart: api.FranchisePart{
+					MovieId:     m.Id,
+					File:        m.File,
+					TMDbId:      md.TMDbId,
+					Title:       md.Title,
+					ReleaseDate: md.ReleaseDate,
+					PosterUrl:   md.PosterSmallUrl,
+				},
+				name: md.CollectionName,
+			})
+		}
+	}
+	return result
 }
 
-func (l *tmdbLoader) load(m api.Movie, lang string, tryLoad bool) (md api.MovieDetails, found bool, err error) {
-	var v interface{}
-	if tryLoad {
-		v, err = l.cache.GetOrLoad(tmdbDetailsKey{id: m.TMDbId, lang: lang}, func(key util.Key) (interface{}, error) {
-			k := key.(tmdbDetailsKey)
-			return l.tmdbConn.GetMovie(k.id, k.lang)
-		})
-	} else {
-		v, err = l.cache.Get(tmdbDetailsKey{id: m.TMDbId, lang: lang})
+// mergeParts links parts of franchise to movies which are in catalog, movies unknown to franchise are added to it.
+func mergeParts(f api.Franchise, owned []ownedPart) api.Franchise {
+	parts := make([]api.FranchisePart, len(f.Parts))
+	copy(parts, f.Parts)
+	for _, o := range owned {
+		linked := false
+		for i := range parts {
+			if parts[i].TMDbId == o.TMDbId && parts[i].MovieId == 0 {
+				parts[i].MovieId = o.MovieId
+				parts[i].File = o.File
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			parts = append(parts, o.FranchisePart)
+		}
 	}
-	if err == nil && v != nil {
-		found = true
-		tmDbMovie := v.(tmdb.MovieDetails)
-		md = api.MovieDetails{
-			Budget:         tmDbMovie.Budget,
-			Companies:      companyNames(tmDbMovie.ProductionCompanies),
-			Countries:      countryNames(tmDbMovie.ProductionCountries),
-			Genres:         genreNames(tmDbMovie.Genres),
-			OriginalTitle:  tmDbMovie.OriginalTitle,
-			Overview:       tmDbMovie.Overview,
-			PosterSmallUrl: fmt.Sprintf("%s%s%s", l.tmdbConf.Images.BaseUrl, l.conf.TMDbPosterSmall, tmDbMovie.PosterPath),
-			PosterLargeUrl: fmt.Sprintf("%s%s%s", l.tmdbConf.Images.BaseUrl, l.conf.TMDbPosterLarge, tmDbMovie.PosterPath),
-			Runtime:        tmDbMovie.Runtime,
-			ReleaseDate:    tmDbMovie.ReleaseDate,
-			Revenue:        tmDbMovie.Revenue,
-			TagLine:        tmDbMovie.TagLine,
-			Title:          tmDbMovie.Title,
-			TMDbId:         tmDbMovie.Id,
+	sortParts(parts)
+	f.Parts = parts
+	f.Missing = 0
+	for _, p := range parts {
+		if p.MovieId == 0 {
+			f.Missing++
 		}
 	}
-	return
+	return f
 }
 
-func companyNames(companies []tmdb.Company) []string {
-	names := make([]string, 0, len(companies))
-	for _, c := range companies {
-		names = append(names, c.Name)
+// sortParts orders parts by release date, parts which are not released yet go last.
+func sortParts(parts []api.FranchisePart) {
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, b := parts[i].ReleaseDate, parts[j].ReleaseDate
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return a < b
+	})
+}
+
+func (srv *DetailsService) SearchDetails(query, lang string) ([]api.MovieDetails, error) {
+	if srv.tmdb != nil {
+		return srv.tmdb.search(query, lang)
 	}
-	return names
+	return nil, nil
 }
 
-func countryNames(countries []tmdb.Country) []string {
-	names := make([]string, 0, len(countries))
-	for _, c := range countries {
-		names = append(names, c.Name)
+func applyOverrides(md *api.MovieDetails, o api.Overrides) {
+	if o.Title != "" {
+		md.Title = o.Title
 	}
-	return names
+	if year := strconv.Itoa(o.Year); o.Year != 0 && !strings.HasPrefix(md.ReleaseDate, year) {
+		md.ReleaseDate = year
+	}
+	if o.Overview != "" {
+		md.Overview = o.Overview
+	}
+	if len(o.Genres) > 0 {
+		md.Genres = o.Genres
+	}
+	if o.PosterUrl != "" {
+		md.PosterSmallUrl = o.PosterUrl
+		md.PosterLargeUrl = o.PosterUrl
+	}
+	md.Notes = o.Notes
 }
 
-func genreNames(genres []tmdb.Genre) []string {
-	names := make([]string, 0, len(genres))
-	for _, c := range genres {
+// People returns names of cast, directors and writers of movie.
+func People(md api.MovieDetails) []string {
+	var names []string
+	for _, a := range md.Cast {
+		names = append(names, a.Name)
+	}
+	for _, c := range md.Directors {
+		names = append(names, c.Name)
+	}
+	for _, c := range md.Writers {
 		names = append(names, c.Name)
 	}
 	return names
 }
+
+func credited(md api.MovieDetails, name, role string) bool {
+	if role == "" || role == RoleCast {
+		for _, a := range md.Cast {
+			if strings.EqualFold(a.Name, name) {
+				return true
+			}
+		}
+	}
+	if role == "" || role == RoleDirector {
+		for _, c := range md.Directors {
+			if strings.EqualFold(c.Name, name) {
+				return true
+			}
+		}
+	}
+	if role == "" || role == RoleWriter {
+		for _, c := range md.Writers {
+			if strings.EqualFold(c.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
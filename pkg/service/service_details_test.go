@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/tmdb"
+)
+
+func TestCreditedPerson(t *testing.T) {
+	md := api.MovieDetails{
+		Cast:      []api.Actor{{Name: "Tom Hanks", Character: "Forrest Gump"}},
+		Directors: []api.Crew{{Name: "Robert Zemeckis", Job: "Director"}},
+		Writers:   []api.Crew{{Name: "Eric Roth", Job: "Screenplay"}},
+	}
+
+	assert.True(t, credited(md, "tom hanks", ""))
+	assert.True(t, credited(md, "Tom Hanks", RoleCast))
+	assert.False(t, credited(md, "Tom Hanks", RoleDirector))
+	assert.True(t, credited(md, "Robert Zemeckis", RoleDirector))
+	assert.True(t, credited(md, "Eric Roth", RoleWriter))
+	assert.False(t, credited(md, "Tom", ""))
+	assert.Equal(t, []string{"Tom Hanks", "Robert Zemeckis", "Eric Roth"}, People(md))
+}
+
+func TestCertificationAllowed(t *testing.T) {
+	assert.True(t, CertificationAllowed("US", "PG", "PG-13"))
+	assert.True(t, CertificationAllowed("US", "pg-13", "PG-13"))
+	assert.False(t, CertificationAllowed("US", "R", "PG-13"))
+	assert.False(t, CertificationAllowed("US", "", "PG-13"))
+	assert.True(t, CertificationAllowed("DE", "12", "16"))
+	assert.False(t, CertificationAllowed("RU", "18+", "16+"))
+	assert.False(t, ValidCertification("GB", "PG-13"))
+}
+
+func TestCertificationOfTheatricalRelease(t *testing.T) {
+	releases := tmdb.ReleaseDates{Results: []tmdb.CountryReleases{
+		{Country: "GB", Releases: []tmdb.Release{{Certification: "15", Type: tmdb.TheatricalRelease}}},
+		{Country: "US", Releases: []tmdb.Release{
+			{Certification: "NR", Type: 4},
+			{Certification: "", Type: 1},
+			{Certification: "R", Type: tmdb.TheatricalRelease},
+		}},
+	}}
+
+	assert.Equal(t, "R", certification(releases, "US"))
+	assert.Equal(t, "15", certification(releases, "gb"))
+	assert.Equal(t, "", certification(releases, "DE"))
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/catalog"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/file"
+	"github.com/andrew00x/gomovies/pkg/util"
+)
+
+// Reasons why movies are considered to be copies of the same movie.
+const (
+	DuplicateByTMDb    = "tmdb"
+	DuplicateByTitle   = "title"
+	DuplicateByContent = "content"
+)
+
+// DuplicatesService finds copies of the same movie in catalog. Copies are matched by TMDb id, by title and year parsed
+// from names of files and optionally by partial hash of content, see file.PartialHash.
+type DuplicatesService struct {
+	catalog *CatalogService
+	details func(m api.Movie) (api.MovieDetails, bool)
+	hash    func(path string) (string, error)
+}
+
+func CreateDuplicatesService(conf *config.Config, catalog *CatalogService, details *DetailsService) *DuplicatesService {
+	return createDuplicatesService(catalog, func(m api.Movie) (api.MovieDetails, bool) {
+		md, found, err := details.MovieDetails(m, conf.DetailsLangs[0], false)
+		return md, found && err == nil
+	}, file.PartialHash)
+}
+
+func createDuplicatesService(catalog *CatalogService, details func(m api.Movie) (api.MovieDetails, bool), hash func(path string) (string, error)) *DuplicatesService {
+	return &DuplicatesService{catalog: catalog, details: details, hash: hash}
+}
+
+type duplicateLink struct {
+	a, b   int
+	reason string
+}
+
+// Report groups copies of the same movie. Content of files is compared only if byContent is true, content id kept in
+// catalog is used when it is known, otherwise available movie files are read.
+func (srv *DuplicatesService) Report(byContent bool) api.DuplicatesReport {
+	movies := srv.catalog.All()
+	sort.Slice(movies, func(i, j int) bool { return movies[i].Id < movies[j].Id })
+	var links []duplicateLink
+	seen := make(map[string]int)
+	link := func(i int, reason, key string) {
+		k := reason + ":" + key
+		if first, ok := seen[k]; ok {
+			links = append(links, duplicateLink{a: first, b: i, reason: reason})
+		} else {
+			seen[k] = i
+		}
+	}
+	for i, m := range movies {
+		tmdbId := m.TMDbId
+		if md, found := srv.details(m); found && tmdbId == 0 {
+			tmdbId = md.TMDbId
+		}
+		if tmdbId != 0 {
+			link(i, DuplicateByTMDb, fmt.Sprint(tmdbId))
+		}
+		if n := catalog.ParseFileName(m.File); n.Title != "" {
+			link(i, DuplicateByTitle, fmt.Sprintf("%s/%d", n.Title, n.Year))
+		}
+		if byContent && m.ContentId != "" {
+			link(i, DuplicateByContent, m.ContentId)
+		} else if byContent && m.Available {
+			if hash, err := srv.hash(m.File); err != nil {
+				log.WithFields(log.Fields{"file": m.File, "err": err}).Warn("Unable compute hash of movie file")
+			} else {
+				link(i, DuplicateByContent, hash)
+			}
+		}
+	}
+
+	parent := make([]int, len(movies))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	for _, l := range links {
+		if ra, rb := root(l.a), root(l.b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+	reasons := make(map[int][]string)
+	for _, l := range links {
+		r := root(l.a)
+		if !util.Contains(reasons[r], l.reason) {
+			reasons[r] = append(reasons[r], l.reason)
+		}
+	}
+	members := make(map[int][]api.Movie)
+	for i, m := range movies {
+		if r := root(i); len(reasons[r]) > 0 {
+			members[r] = append(members[r], m)
+		}
+	}
+
+	report := api.DuplicatesReport{Groups: []api.DuplicateGroup{}}
+	for r, group := range members {
+		g := srv.group(group)
+		sort.Strings(reasons[r])
+		g.Reasons = reasons[r]
+		report.Groups = append(report.Groups, g)
+		report.Reclaimable += g.Reclaimable
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Title < report.Groups[j].Title })
+	return report
+}
+
+// group creates group of copies of movie, copy with the highest resolution is preferred, copies on available drives
+// are preferred over copies on drives which are not mounted.
+func (srv *DuplicatesService) group(movies []api.Movie) (g api.DuplicateGroup) {
+	for _, m := range movies {
+		v := api.Variant{MovieId: m.Id, File: m.File, DriveName: m.DriveName, Available: m.Available}
+		v.Resolution = catalog.ParseFileName(m.File).Resolution
+		if info, err := os.Stat(m.File); err == nil {
+			v.Size = info.Size()
+		}
+		g.Variants = append(g.Variants, v)
+	}
+	sort.SliceStable(g.Variants, func(i, j int) bool {
+		a, b := g.Variants[i], g.Variants[j]
+		if a.Resolution != b.Resolution {
+			return a.Resolution > b.Resolution
+		}
+		if a.Available != b.Available {
+			return a.Available
+		}
+		return a.Size > b.Size
+	})
+	g.Preferred = g.Variants[0].MovieId
+	for _, v := range g.Variants[1:] {
+		g.Reclaimable += v.Size
+	}
+	for _, m := range movies {
+		if m.Id == g.Preferred {
+			g.Title = m.Title
+			if md, found := srv.details(m); found && md.Title != "" {
+				g.Title = md.Title
+			}
+		}
+	}
+	return
+}
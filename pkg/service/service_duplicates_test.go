@@ -0,0 +1,67 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/file"
+)
+
+func TestReportDuplicates(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "DuplicatesTest")
+	mustCreateDuplicatesDir(dir)
+	defer func() { _ = os.RemoveAll(dir) }()
+	movies := []api.Movie{
+		{Id: 1, Title: "Brave.2012.720p.mkv", File: mustWriteMovie(dir, "Brave.2012.720p.mkv", 700), Available: true, DriveName: "wd500"},
+		{Id: 2, Title: "Brave (2012) 1080p.mkv", File: mustWriteMovie(dir, "Brave (2012) 1080p.mkv", 1000), Available: true, DriveName: "wd640"},
+		{Id: 3, Title: "gladiator.mkv", File: mustWriteMovie(dir, "gladiator.mkv", 300), Available: true, TMDbId: 98},
+		{Id: 4, Title: "Gladiator Extended.mkv", File: filepath.Join(dir, "unmounted", "Gladiator Extended.mkv"), DriveName: "wd320"},
+		{Id: 5, Title: "green mile.mkv", File: mustWriteMovie(dir, "green mile.mkv", 500), Available: true},
+		{Id: 6, Title: "copy of green mile.mkv", File: mustWriteMovie(dir, "copy of green mile.mkv", 500), Available: true},
+	}
+	details := func(m api.Movie) (api.MovieDetails, bool) {
+		if m.Id == 4 {
+			return api.MovieDetails{TMDbId: 98, Title: "Gladiator"}, true
+		}
+		return api.MovieDetails{}, false
+	}
+	srv := createDuplicatesService(createCatalogService(&catalogMock{movies: movies}, &config.Config{}), details, file.PartialHash)
+
+	report := srv.Report(false)
+	assert.Equal(t, 2, len(report.Groups))
+	brave := report.Groups[0]
+	assert.Equal(t, "Brave (2012) 1080p.mkv", brave.Title)
+	assert.Equal(t, []string{DuplicateByTitle}, brave.Reasons)
+	assert.Equal(t, 2, brave.Preferred)
+	assert.Equal(t, int64(700), brave.Reclaimable)
+	gladiator := report.Groups[1]
+	assert.Equal(t, []string{DuplicateByTitle, DuplicateByTMDb}, gladiator.Reasons)
+	assert.Equal(t, 3, gladiator.Preferred)
+	assert.Equal(t, int64(700), report.Reclaimable)
+
+	report = srv.Report(true)
+	assert.Equal(t, 3, len(report.Groups))
+	assert.Equal(t, []string{DuplicateByContent}, report.Groups[2].Reasons)
+	assert.Equal(t, []int{5, 6}, []int{report.Groups[2].Variants[0].MovieId, report.Groups[2].Variants[1].MovieId})
+	assert.Equal(t, int64(1200), report.Reclaimable)
+}
+
+func mustCreateDuplicatesDir(dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
+}
+
+func mustWriteMovie(dir, name string, size int) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+		panic(err)
+	}
+	return path
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/upnp"
+)
+
+const (
+	allContainer    = "all"
+	genresContainer = "genres"
+	drivesContainer = "drives"
+	genrePrefix     = "genre/"
+	drivePrefix     = "drive/"
+)
+
+// MediaDirectory exposes available movies of catalog as content of UPnP MediaServer. Movies are grouped into
+// containers: all movies, movies by genre and movies by drive. Genres and posters are taken from already loaded
+// details. Id of movie item is id of movie prefixed with id of its container, e.g. "genre/Drama/12".
+type MediaDirectory struct {
+	catalog *CatalogService
+	details func(m api.Movie) (api.MovieDetails, bool)
+}
+
+// CreateMediaServer creates UPnP MediaServer which handles requests under "/upnp/". Movie files are served by "/file/"
+// handler.
+func CreateMediaServer(conf *config.Config, catalog *CatalogService, details *DetailsService) *upnp.MediaServer {
+	dir := createMediaDirectory(catalog, func(m api.Movie) (api.MovieDetails, bool) {
+		md, found, err := details.MovieDetails(m, conf.DetailsLangs[0], false)
+		return md, found && err == nil
+	})
+	return upnp.NewMediaServer("/upnp/", mediaServerUDN(), conf.MediaServerName, dir)
+}
+
+func createMediaDirectory(catalog *CatalogService, details func(m api.Movie) (api.MovieDetails, bool)) *MediaDirectory {
+	return &MediaDirectory{catalog: catalog, details: details}
+}
+
+func (d *MediaDirectory) Object(id string) (upnp.Object, bool) {
+	switch id {
+	case upnp.RootId:
+		return upnp.Object{Id: upnp.RootId, ParentId: "-1", Title: "gomovies", Container: true, ChildCount: 3}, true
+	case allContainer, genresContainer, drivesContainer:
+		children, _ := d.Children(id)
+		return container(id, upnp.RootId, containerTitle(id), len(children)), true
+	}
+	if children, found := d.Children(id); found {
+		if strings.HasPrefix(id, genrePrefix) {
+			return container(id, genresContainer, containerTitle(id), len(children)), true
+		}
+		return container(id, drivesContainer, containerTitle(id), len(children)), true
+	}
+	i := strings.LastIndex(id, "/")
+	if i < 0 {
+		return upnp.Object{}, false
+	}
+	children, found := d.Children(id[:i])
+	if !found {
+		return upnp.Object{}, false
+	}
+	for _, o := range children {
+		if o.Id == id {
+			return o, true
+		}
+	}
+	return upnp.Object{}, false
+}
+
+func (d *MediaDirectory) Children(id string) ([]upnp.Object, bool) {
+	switch {
+	case id == upnp.RootId:
+		result := make([]upnp.Object, 0, 3)
+		for _, c := range []string{allContainer, genresContainer, drivesContainer} {
+			o, _ := d.Object(c)
+			result = append(result, o)
+		}
+		return result, true
+	case id == allContainer:
+		return d.items(id, d.movies()), true
+	case id == genresContainer:
+		return d.containers(id, genrePrefix, d.byGenre()), true
+	case id == drivesContainer:
+		return d.containers(id, drivePrefix, d.byDrive()), true
+	case strings.HasPrefix(id, genrePrefix):
+		return d.group(id, genrePrefix, d.byGenre())
+	case strings.HasPrefix(id, drivePrefix):
+		return d.group(id, drivePrefix, d.byDrive())
+	}
+	return nil, false
+}
+
+func (d *MediaDirectory) group(id, prefix string, groups map[string][]api.Movie) ([]upnp.Object, bool) {
+	name, err := url.PathUnescape(strings.TrimPrefix(id, prefix))
+	if err != nil {
+		return nil, false
+	}
+	movies, ok := groups[name]
+	if !ok {
+		return nil, false
+	}
+	return d.items(id, movies), true
+}
+
+func (d *MediaDirectory) containers(parent, prefix string, groups map[string][]api.Movie) []upnp.Object {
+	result := make([]upnp.Object, 0, len(groups))
+	for name, movies := range groups {
+		result = append(result, container(prefix+url.PathEscape(name), parent, name, len(movies)))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Title < result[j].Title })
+	return result
+}
+
+func (d *MediaDirectory) items(parent string, movies []api.Movie) []upnp.Object {
+	result := make([]upnp.Object, 0, len(movies))
+	for _, m := range movies {
+		o := upnp.Object{
+			Id:       parent + "/" + strconv.Itoa(m.Id),
+			ParentId: parent,
+			Title:    m.Title,
+			URL:      "/file" + (&url.URL{Path: m.File}).EscapedPath(),
+		}
+		if md, found := d.details(m); found {
+			if md.Title != "" {
+				o.Title = md.Title
+			}
+			o.Genres = md.Genres
+			o.PosterURL = md.PosterLargeUrl
+			o.Duration = time.Duration(md.Runtime) * time.Minute
+		}
+		if info, err := os.Stat(m.File); err == nil {
+			o.Size = info.Size()
+		}
+		result = append(result, o)
+	}
+	return result
+}
+
+// movies returns available movies sorted by title, movies from unmounted drives may not be streamed.
+func (d *MediaDirectory) movies() []api.Movie {
+	var result []api.Movie
+	for _, m := range d.catalog.All() {
+		if m.Available {
+			result = append(result, m)
+		}
+	}
+	sort.Sort(ByName(result))
+	return result
+}
+
+func (d *MediaDirectory) byGenre() map[string][]api.Movie {
+	groups := make(map[string][]api.Movie)
+	for _, m := range d.movies() {
+		if md, found := d.details(m); found {
+			for _, g := range md.Genres {
+				groups[g] = append(groups[g], m)
+			}
+		}
+	}
+	return groups
+}
+
+func (d *MediaDirectory) byDrive() map[string][]api.Movie {
+	groups := make(map[string][]api.Movie)
+	for _, m := range d.movies() {
+		groups[m.DriveName] = append(groups[m.DriveName], m)
+	}
+	return groups
+}
+
+func container(id, parent, title string, childCount int) upnp.Object {
+	return upnp.Object{Id: id, ParentId: parent, Title: title, Container: true, ChildCount: childCount}
+}
+
+func containerTitle(id string) string {
+	switch id {
+	case allContainer:
+		return "All movies"
+	case genresContainer:
+		return "Genres"
+	case drivesContainer:
+		return "Drives"
+	}
+	name, _ := url.PathUnescape(id[strings.Index(id, "/")+1:])
+	return name
+}
+
+// mediaServerUDN derives unique device name from host name, so control points recognize server after restart.
+func mediaServerUDN() string {
+	host, _ := os.Hostname()
+	sum := md5.Sum([]byte("gomovies-media-server:" + host))
+	return fmt.Sprintf("uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
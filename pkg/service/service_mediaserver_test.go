@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/upnp"
+)
+
+func TestMediaDirectoryByGenre(t *testing.T) {
+	dir := createTestMediaDirectory()
+
+	genres, found := dir.Children(genresContainer)
+	assert.True(t, found)
+	assert.Equal(t, []upnp.Object{
+		{Id: "genre/Drama", ParentId: genresContainer, Title: "Drama", Container: true, ChildCount: 1},
+		{Id: "genre/Sci-Fi%20&%20Fantasy", ParentId: genresContainer, Title: "Sci-Fi & Fantasy", Container: true, ChildCount: 2},
+	}, genres)
+
+	movies, found := dir.Children("genre/Sci-Fi%20&%20Fantasy")
+	assert.True(t, found)
+	assert.Equal(t, 2, len(movies))
+	assert.Equal(t, upnp.Object{
+		Id:        "genre/Sci-Fi%20&%20Fantasy/1",
+		ParentId:  "genre/Sci-Fi%20&%20Fantasy",
+		Title:     "Alien",
+		URL:       "/file/movies/alien%201979.mkv",
+		PosterURL: "http://image.tmdb.org/t/p/w500/alien.jpg",
+		Genres:    []string{"Sci-Fi & Fantasy", "Drama"},
+	}, movies[0])
+}
+
+func TestMediaDirectoryByDriveSkipsUnavailableMovies(t *testing.T) {
+	dir := createTestMediaDirectory()
+
+	drives, found := dir.Children(drivesContainer)
+	assert.True(t, found)
+	assert.Equal(t, []upnp.Object{{Id: "drive/usb1", ParentId: drivesContainer, Title: "usb1", Container: true, ChildCount: 2}}, drives)
+
+	o, found := dir.Object("drive/usb1/2")
+	assert.True(t, found)
+	assert.Equal(t, "brave.mkv", o.Title)
+	_, found = dir.Object("drive/usb2/3")
+	assert.False(t, found)
+	_, found = dir.Object("all/3")
+	assert.False(t, found)
+}
+
+func createTestMediaDirectory() *MediaDirectory {
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/alien 1979.mkv", Title: "alien 1979.mkv", DriveName: "usb1", Available: true},
+		{Id: 2, File: "/movies/brave.mkv", Title: "brave.mkv", DriveName: "usb1", Available: true},
+		{Id: 3, File: "/movies/contact.mkv", Title: "contact.mkv", DriveName: "usb2"},
+	}}, &config.Config{})
+	details := map[int]api.MovieDetails{
+		1: {Title: "Alien", Genres: []string{"Sci-Fi & Fantasy", "Drama"}, PosterLargeUrl: "http://image.tmdb.org/t/p/w500/alien.jpg"},
+		2: {Genres: []string{"Sci-Fi & Fantasy"}},
+	}
+	return createMediaDirectory(ctl, func(m api.Movie) (api.MovieDetails, bool) {
+		md, found := details[m.Id]
+		return md, found
+	})
+}
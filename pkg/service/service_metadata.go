@@ -0,0 +1,382 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/details"
+	"github.com/andrew00x/gomovies/pkg/omdb"
+	"github.com/andrew00x/gomovies/pkg/tmdb"
+	"github.com/andrew00x/gomovies/pkg/util"
+)
+
+// maxCast is number of top billed actors kept in details of movie.
+const maxCast = 15
+
+// MetadataProvider gives details of movie. Details found by providers with higher priority are passed as known, so
+// provider may use ids found by others, e.g. IMDb id found in TMDb is used to get details from OMDb. Details are
+// loaded only if tryLoad is true, otherwise only already loaded details are returned.
+type MetadataProvider interface {
+	Details(m api.Movie, known api.MovieDetails, lang string, tryLoad bool) (api.MovieDetails, bool, error)
+}
+
+// mergeDetails sets fields of details which are not set yet with values of fields of other details.
+func mergeDetails(md *api.MovieDetails, other api.MovieDetails) {
+	dst := reflect.ValueOf(md).Elem()
+	src := reflect.ValueOf(other)
+	for i := 0; i < dst.NumField(); i++ {
+		f := dst.Field(i)
+		if isZero(f) && !isZero(src.Field(i)) {
+			f.Set(src.Field(i))
+		}
+	}
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.Interface() == reflect.Zero(v.Type()).Interface()
+}
+
+// cached returns details from cache, details are loaded with loader if they are not in cache and tryLoad is true.
+// Loader returns nil if there are no details.
+func cached(cache util.Cache, key util.Key, tryLoad bool, loader func() (interface{}, error)) (v interface{}, err error) {
+	if tryLoad {
+		return cache.GetOrLoad(key, func(util.Key) (interface{}, error) { return loader() })
+	}
+	return cache.Get(key)
+}
+
+type localDetailsKey struct {
+	file string
+	lang string
+}
+
+// localProvider reads details from files next to movie file, see details.GetDetails.
+type localProvider struct {
+	cache util.Cache
+}
+
+func createLocalProvider() *localProvider {
+	return &localProvider{cache: util.CreateCache()}
+}
+
+func (p *localProvider) Details(m api.Movie, _ api.MovieDetails, lang string, tryLoad bool) (md api.MovieDetails, found bool, err error) {
+	var v interface{}
+	v, err = cached(p.cache, localDetailsKey{file: m.File, lang: lang}, tryLoad, func() (interface{}, error) {
+		md, err := details.GetDetails(m.File, lang)
+		if _, ok := err.(*details.NotFoundError); ok {
+			return nil, nil
+		}
+		return md, err
+	})
+	if err == nil && v != nil {
+		found = true
+		md = v.(api.MovieDetails)
+	}
+	return
+}
+
+type tmdbDetailsKey struct {
+	id   int
+	lang string
+}
+
+// tmdbProvider gets details from TMDb by TMDb id of movie.
+type tmdbProvider struct {
+	conf     *config.Config
+	tmdbConf tmdb.Config
+	tmdbConn *tmdb.TmDb
+	cache    util.Cache
+}
+
+func createTmdbProvider(conf *config.Config, conn *tmdb.TmDb) (*tmdbProvider, error) {
+	tmdbConf, err := conn.GetConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	return &tmdbProvider{conf: conf, tmdbConf: tmdbConf, tmdbConn: conn, cache: util.CreateCache()}, nil
+}
+
+func (p *tmdbProvider) Details(m api.Movie, known api.MovieDetails, lang string, tryLoad bool) (md api.MovieDetails, found bool, err error) {
+	id := m.TMDbId
+	if id == 0 {
+		id = known.TMDbId
+	}
+	if id == 0 {
+		return
+	}
+	var v interface{}
+	v, err = cached(p.cache, tmdbDetailsKey{id: id, lang: lang}, tryLoad, func() (interface{}, error) {
+		return p.tmdbConn.GetMovie(id, lang)
+	})
+	if err == nil && v != nil {
+		found = true
+		tmDbMovie := v.(tmdb.MovieDetails)
+		md = api.MovieDetails{
+			Budget:         tmDbMovie.Budget,
+			Cast:           p.cast(tmDbMovie.Credits.Cast),
+			Certification:  certification(tmDbMovie.ReleaseDates, p.conf.CertificationCountry),
+			Companies:      companyNames(tmDbMovie.ProductionCompanies),
+			Countries:      countryNames(tmDbMovie.ProductionCountries),
+			Directors:      p.crew(tmDbMovie.Credits.Crew, func(c tmdb.Crew) bool { return c.Job == "Director" }),
+			Genres:         genreNames(tmDbMovie.Genres),
+			ImdbId:         tmDbMovie.ImdbId,
+			OriginalTitle:  tmDbMovie.OriginalTitle,
+			Overview:       tmDbMovie.Overview,
+			PosterSmallUrl: p.imageUrl(p.conf.TMDbPosterSmall, tmDbMovie.PosterPath),
+			PosterLargeUrl: p.imageUrl(p.conf.TMDbPosterLarge, tmDbMovie.PosterPath),
+			Runtime:        tmDbMovie.Runtime,
+			ReleaseDate:    tmDbMovie.ReleaseDate,
+			Revenue:        tmDbMovie.Revenue,
+			TagLine:        tmDbMovie.TagLine,
+			Title:          tmDbMovie.Title,
+			TMDbId:         tmDbMovie.Id,
+			Trailers:       trailers(tmDbMovie.Videos),
+			VoteAverage:    tmDbMovie.VoteAverage,
+			VoteCount:      tmDbMovie.VoteCount,
+			Writers:        p.crew(tmDbMovie.Credits.Crew, func(c tmdb.Crew) bool { return c.Department == "Writing" }),
+		}
+		if c := tmDbMovie.BelongsToCollection; c != nil {
+			md.CollectionId = c.Id
+			md.CollectionName = c.Name
+		}
+	}
+	return
+}
+
+type tmdbCollectionKey struct {
+	id   int
+	lang string
+}
+
+// collection returns TMDb collection with all its parts ordered by release date.
+func (p *tmdbProvider) collection(id int, lang string, tryLoad bool) (f api.Franchise, found bool, err error) {
+	var v interface{}
+	v, err = cached(p.cache, tmdbCollectionKey{id: id, lang: lang}, tryLoad, func() (interface{}, error) {
+		return p.tmdbConn.GetCollection(id, lang)
+	})
+	if err == nil && v != nil {
+		found = true
+		c := v.(tmdb.Collection)
+		f = api.Franchise{Id: c.Id, Name: c.Name, Overview: c.Overview, PosterUrl: p.imageUrl(p.conf.TMDbPosterSmall, c.PosterPath)}
+		for _, part := range c.Parts {
+			f.Parts = append(f.Parts, api.FranchisePart{
+				TMDbId:      part.Id,
+				Title:       part.Title,
+				ReleaseDate: part.ReleaseDate,
+				PosterUrl:   p.imageUrl(p.conf.TMDbPosterSmall, part.PosterPath),
+			})
+		}
+		sortParts(f.Parts)
+	}
+	return
+}
+
+func (p *tmdbProvider) search(query, lang string) ([]api.MovieDetails, error) {
+	result, err := p.tmdbConn.SearchMovies(query, lang)
+	if err != nil {
+		return nil, err
+	}
+	movies := make([]api.MovieDetails, 0, len(result))
+	for _, tmDbMovie := range result {
+		movies = append(movies,
+			api.MovieDetails{
+				OriginalTitle:  tmDbMovie.OriginalTitle,
+				Overview:       tmDbMovie.Overview,
+				PosterSmallUrl: p.imageUrl(p.conf.TMDbPosterSmall, tmDbMovie.PosterPath),
+				PosterLargeUrl: p.imageUrl(p.conf.TMDbPosterLarge, tmDbMovie.PosterPath),
+				ReleaseDate:    tmDbMovie.ReleaseDate,
+				TMDbId:         tmDbMovie.Id,
+			})
+	}
+	return movies, nil
+}
+
+func (p *tmdbProvider) cast(cast []tmdb.Cast) []api.Actor {
+	if len(cast) > maxCast {
+		cast = cast[:maxCast]
+	}
+	actors := make([]api.Actor, 0, len(cast))
+	for _, c := range cast {
+		actors = append(actors, api.Actor{Name: c.Name, Character: c.Character, ProfileUrl: p.imageUrl(p.conf.TMDbProfile, c.ProfilePath)})
+	}
+	return actors
+}
+
+// crew selects members of crew, person who has few jobs, e.g. screenplay and story, is included once.
+func (p *tmdbProvider) crew(crew []tmdb.Crew, filter func(tmdb.Crew) bool) []api.Crew {
+	var result []api.Crew
+	seen := make(map[int]bool)
+	for _, c := range crew {
+		if filter(c) && !seen[c.Id] {
+			seen[c.Id] = true
+			result = append(result, api.Crew{Name: c.Name, Job: c.Job, ProfileUrl: p.imageUrl(p.conf.TMDbProfile, c.ProfilePath)})
+		}
+	}
+	return result
+}
+
+func (p *tmdbProvider) imageUrl(size, path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s%s%s", p.tmdbConf.Images.BaseUrl, size, path)
+}
+
+// omdbProvider gets details from OMDb by IMDb id of movie.
+type omdbProvider struct {
+	conf     *config.Config
+	omdbConn *omdb.OMDb
+	cache    util.Cache
+}
+
+func createOmdbProvider(conf *config.Config, conn *omdb.OMDb) *omdbProvider {
+	return &omdbProvider{conf: conf, omdbConn: conn, cache: util.CreateCache()}
+}
+
+func (p *omdbProvider) Details(_ api.Movie, known api.MovieDetails, _ string, tryLoad bool) (md api.MovieDetails, found bool, err error) {
+	if known.ImdbId == "" {
+		return
+	}
+	var v interface{}
+	v, err = cached(p.cache, known.ImdbId, tryLoad, func() (interface{}, error) {
+		return p.omdbConn.GetMovie(known.ImdbId)
+	})
+	if err == nil && v != nil {
+		found = true
+		mov := v.(omdb.Movie)
+		md = api.MovieDetails{
+			Countries:   omdbList(mov.Country),
+			Genres:      omdbList(mov.Genre),
+			ImdbId:      mov.ImdbId,
+			Overview:    omdbValue(mov.Plot),
+			ReleaseDate: omdbDate(mov.Released, mov.Year),
+			Title:       omdbValue(mov.Title),
+		}
+		for _, name := range omdbList(mov.Actors) {
+			md.Cast = append(md.Cast, api.Actor{Name: name})
+		}
+		for _, name := range omdbList(mov.Director) {
+			md.Directors = append(md.Directors, api.Crew{Name: name, Job: "Director"})
+		}
+		for _, name := range omdbList(mov.Writer) {
+			md.Writers = append(md.Writers, api.Crew{Name: omdbJob.ReplaceAllString(name, ""), Job: "Writer"})
+		}
+		// OMDb gives US rating only
+		if strings.EqualFold(p.conf.CertificationCountry, "US") {
+			md.Certification = omdbValue(mov.Rated)
+		}
+		if poster := omdbValue(mov.Poster); poster != "" {
+			md.PosterSmallUrl = poster
+			md.PosterLargeUrl = poster
+		}
+		md.Runtime, _ = strconv.Atoi(strings.TrimSuffix(mov.Runtime, " min"))
+		md.VoteAverage, _ = strconv.ParseFloat(mov.ImdbRating, 64)
+		md.VoteCount, _ = strconv.Atoi(strings.Replace(mov.ImdbVotes, ",", "", -1))
+	}
+	return
+}
+
+// omdbJob matches part of writer's credit, e.g. "(screenplay)".
+var omdbJob = regexp.MustCompile(`\s*\(.*\)$`)
+
+func omdbValue(v string) string {
+	if v == omdb.NotAvailable {
+		return ""
+	}
+	return v
+}
+
+func omdbList(v string) []string {
+	var result []string
+	for _, s := range strings.Split(omdbValue(v), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// omdbDate converts release date, e.g. "24 May 1995", to format of TMDb.
+func omdbDate(released, year string) string {
+	if t, err := time.Parse("02 Jan 2006", released); err == nil {
+		return t.Format("2006-01-02")
+	}
+	return omdbValue(year)
+}
+
+// certification returns age rating of movie in country, rating of theatrical release is preferred.
+func certification(releases tmdb.ReleaseDates, country string) (cert string) {
+	for _, r := range releases.Results {
+		if !strings.EqualFold(r.Country, country) {
+			continue
+		}
+		for _, rd := range r.Releases {
+			if rd.Certification == "" {
+				continue
+			}
+			if rd.Type == tmdb.TheatricalRelease {
+				return rd.Certification
+			}
+			if cert == "" {
+				cert = rd.Certification
+			}
+		}
+	}
+	return
+}
+
+// trailers returns keys of YouTube trailers, teasers are used if there is no trailer.
+func trailers(videos tmdb.Videos) []string {
+	var keys, teasers []string
+	for _, v := range videos.Results {
+		if v.Site != "YouTube" {
+			continue
+		}
+		switch v.Type {
+		case "Trailer":
+			keys = append(keys, v.Key)
+		case "Teaser":
+			teasers = append(teasers, v.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return teasers
+	}
+	return keys
+}
+
+func companyNames(companies []tmdb.Company) []string {
+	names := make([]string, 0, len(companies))
+	for _, c := range companies {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func countryNames(countries []tmdb.Country) []string {
+	names := make([]string, 0, len(countries))
+	for _, c := range countries {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func genreNames(genres []tmdb.Genre) []string {
+	names := make([]string, 0, len(genres))
+	for _, c := range genres {
+		names = append(names, c.Name)
+	}
+	return names
+}
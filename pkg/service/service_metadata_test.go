@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/omdb"
+	"github.com/andrew00x/gomovies/pkg/tmdb"
+)
+
+type fakeProvider struct {
+	md    api.MovieDetails
+	found bool
+	err   error
+	known api.MovieDetails
+}
+
+func (p *fakeProvider) Details(_ api.Movie, known api.MovieDetails, _ string, _ bool) (api.MovieDetails, bool, error) {
+	p.known = known
+	return p.md, p.found, p.err
+}
+
+func TestMovieDetailsMergesProviders(t *testing.T) {
+	local := &fakeProvider{md: api.MovieDetails{Title: "Brave", ImdbId: "tt1217209"}, found: true}
+	remote := &fakeProvider{err: fmt.Errorf("unavailable")}
+	fallback := &fakeProvider{md: api.MovieDetails{Title: "Brave (2012)", Overview: "Merida", Genres: []string{"Animation"}}, found: true}
+	srv := createDetailsService(&config.Config{}, local, remote, fallback)
+
+	md, found, err := srv.MovieDetails(api.Movie{File: "/movies/brave.mkv"}, "en", true)
+
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, api.MovieDetails{Title: "Brave", ImdbId: "tt1217209", Overview: "Merida", Genres: []string{"Animation"}}, md)
+	assert.Equal(t, "tt1217209", fallback.known.ImdbId)
+}
+
+func TestMovieDetailsAppliesOverrides(t *testing.T) {
+	remote := &fakeProvider{md: api.MovieDetails{Title: "Brave", ReleaseDate: "2012-06-22", Genres: []string{"Animation"}, PosterLargeUrl: "http://images/brave.jpg"}, found: true}
+	srv := createDetailsService(&config.Config{}, remote)
+	overrides := &api.Overrides{Title: "Brave (Director's cut)", Year: 2012, PosterUrl: "/file/movies/brave.png", Notes: "kids"}
+
+	md, found, err := srv.MovieDetails(api.Movie{File: "/movies/brave.mkv", Overrides: overrides, Tags: []string{"pixar"}}, "en", true)
+
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, api.MovieDetails{
+		Title:          "Brave (Director's cut)",
+		ReleaseDate:    "2012-06-22",
+		Genres:         []string{"Animation"},
+		PosterSmallUrl: "/file/movies/brave.png",
+		PosterLargeUrl: "/file/movies/brave.png",
+		Notes:          "kids",
+		Tags:           []string{"pixar"},
+	}, md)
+
+	md, found, err = createDetailsService(&config.Config{}, &fakeProvider{}).MovieDetails(api.Movie{Overrides: &api.Overrides{Year: 1995}}, "en", true)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "1995", md.ReleaseDate)
+}
+
+func TestMovieDetailsFailsWhenNothingFound(t *testing.T) {
+	srv := createDetailsService(&config.Config{}, &fakeProvider{}, &fakeProvider{err: fmt.Errorf("unavailable")})
+
+	_, found, err := srv.MovieDetails(api.Movie{File: "/movies/brave.mkv"}, "en", true)
+
+	assert.False(t, found)
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestTmdbProviderUsesKnownId(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/configuration":
+			_, _ = fmt.Fprint(w, `{"images": {"base_url": "http://images/"}}`)
+		case r.URL.Path == "/movie/62177":
+			_, _ = fmt.Fprint(w, `{"id": 62177, "title": "Brave", "poster_path": "/brave.jpg", "imdb_id": "tt1217209",
+"credits": {"crew": [{"id": 1, "name": "Mark Andrews", "department": "Directing", "job": "Director"}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"status_code": 34, "status_message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+	conf := &config.Config{TMDbPosterSmall: "w92", TMDbPosterLarge: "w500"}
+	p, err := createTmdbProvider(conf, tmdb.CreateTmDbWithUrl("key", server.URL))
+	assert.Nil(t, err)
+
+	_, found, err := p.Details(api.Movie{}, api.MovieDetails{}, "en", true)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	md, found, err := p.Details(api.Movie{}, api.MovieDetails{TMDbId: 62177}, "en", true)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Brave", md.Title)
+	assert.Equal(t, "tt1217209", md.ImdbId)
+	assert.Equal(t, "http://images/w500/brave.jpg", md.PosterLargeUrl)
+	assert.Equal(t, []api.Crew{{Name: "Mark Andrews", Job: "Director"}}, md.Directors)
+}
+
+func TestOmdbProviderMapsMovie(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("i") != "tt1217209" {
+			_, _ = fmt.Fprint(w, `{"Response": "False", "Error": "Incorrect IMDb ID."}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"Title": "Brave", "Year": "2012", "Rated": "PG", "Released": "22 Jun 2012", "Runtime": "93 min",
+"Genre": "Animation, Adventure", "Director": "Mark Andrews, Brenda Chapman", "Writer": "Mark Andrews (screenplay), Irene Mecchi",
+"Actors": "Kelly Macdonald, Billy Connolly", "Plot": "N/A", "Country": "United States", "Poster": "N/A",
+"imdbRating": "7.1", "imdbVotes": "452,123", "imdbID": "tt1217209", "Response": "True"}`)
+	}))
+	defer server.Close()
+	p := createOmdbProvider(&config.Config{CertificationCountry: "US"}, omdb.CreateOMDbWithUrl("key", server.URL))
+
+	_, found, err := p.Details(api.Movie{}, api.MovieDetails{}, "en", true)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	md, found, err := p.Details(api.Movie{}, api.MovieDetails{ImdbId: "tt1217209"}, "en", true)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Brave", md.Title)
+	assert.Equal(t, "PG", md.Certification)
+	assert.Equal(t, "2012-06-22", md.ReleaseDate)
+	assert.Equal(t, 93, md.Runtime)
+	assert.Equal(t, []string{"Animation", "Adventure"}, md.Genres)
+	assert.Equal(t, []api.Crew{{Name: "Mark Andrews", Job: "Writer"}, {Name: "Irene Mecchi", Job: "Writer"}}, md.Writers)
+	assert.Equal(t, 2, len(md.Directors))
+	assert.Equal(t, "Kelly Macdonald", md.Cast[0].Name)
+	assert.Equal(t, "", md.Overview)
+	assert.Equal(t, "", md.PosterLargeUrl)
+	assert.Equal(t, 7.1, md.VoteAverage)
+	assert.Equal(t, 452123, md.VoteCount)
+}
+
+func TestFranchiseShowsMissingPartsInOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/configuration":
+			_, _ = fmt.Fprint(w, `{"images": {"base_url": "http://images/"}}`)
+		case "/collection/10":
+			_, _ = fmt.Fprint(w, `{"id": 10, "name": "Star Wars Collection", "parts": [
+{"id": 1893, "title": "Star Wars: Episode I", "release_date": "1999-05-19"},
+{"id": 11, "title": "Star Wars", "release_date": "1977-05-25"},
+{"id": 1891, "title": "The Empire Strikes Back", "release_date": "1980-05-20"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"status_code": 34, "status_message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+	p, err := createTmdbProvider(&config.Config{}, tmdb.CreateTmDbWithUrl("key", server.URL))
+	assert.Nil(t, err)
+	local := &collectionProvider{byFile: map[string]api.MovieDetails{
+		"/movies/sw1.mkv": {TMDbId: 1893, Title: "Star Wars: Episode I", ReleaseDate: "1999-05-19", CollectionId: 10, CollectionName: "Star Wars Collection"},
+		"/movies/sw4.mkv": {TMDbId: 11, Title: "Star Wars", ReleaseDate: "1977-05-25", CollectionId: 10, CollectionName: "Star Wars Collection"},
+	}}
+	srv := createDetailsService(&config.Config{}, local, p)
+	movies := []api.Movie{{Id: 1, File: "/movies/sw1.mkv"}, {Id: 2, File: "/movies/gladiator.mkv"}, {Id: 3, File: "/movies/sw4.mkv"}}
+
+	franchises := srv.Franchises(movies, "en")
+	assert.Equal(t, 1, len(franchises))
+	assert.Equal(t, []int{3, 1}, partIds(franchises[0]))
+	assert.Equal(t, 0, franchises[0].Missing)
+
+	f, err := srv.Franchise(10, movies, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "Star Wars Collection", f.Name)
+	assert.Equal(t, []int{3, 0, 1}, partIds(f))
+	assert.Equal(t, "The Empire Strikes Back", f.Parts[1].Title)
+	assert.Equal(t, 1, f.Missing)
+
+	franchises = srv.Franchises(movies, "en")
+	assert.Equal(t, 1, franchises[0].Missing)
+
+	_, err = srv.Franchise(20, movies, "en")
+	assert.NotNil(t, err)
+}
+
+type collectionProvider struct {
+	byFile map[string]api.MovieDetails
+}
+
+func (p *collectionProvider) Details(m api.Movie, _ api.MovieDetails, _ string, _ bool) (api.MovieDetails, bool, error) {
+	md, found := p.byFile[m.File]
+	return md, found, nil
+}
+
+func partIds(f api.Franchise) []int {
+	var ids []int
+	for _, p := range f.Parts {
+		ids = append(ids, p.MovieId)
+	}
+	return ids
+}
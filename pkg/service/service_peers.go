@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/player"
+)
+
+const peerRequestTimeout = 5 * time.Second
+
+// PeerService gives access to players and catalogs of other gomovies instances listed in configuration.
+type PeerService struct {
+	catalog *CatalogService
+	local   *PlayerService
+	peers   []*peer
+	client  *http.Client
+}
+
+type peer struct {
+	name   string
+	addr   string
+	player *PlayerService
+}
+
+type UnknownHostError struct {
+	Host string
+}
+
+func (e *UnknownHostError) Error() string {
+	return fmt.Sprintf("unknown host: %s", e.Host)
+}
+
+func CreatePeerService(conf *config.Config, catalog *CatalogService, local *PlayerService) *PeerService {
+	client := &http.Client{Timeout: peerRequestTimeout}
+	srv := &PeerService{catalog: catalog, local: local, client: client}
+	for name, addr := range conf.Peers {
+		addr = strings.TrimSuffix(addr, "/")
+		p := &peer{name: name, addr: addr}
+		// peer checks availability of files and player options against its own catalog, files of peer are unknown to
+		// catalog of this instance, so restriction of the active profile is checked with movie from catalog of peer
+		p.player = createPlayerService(player.CreateRemotePlayer(addr, client), CreatePlayQueue(), nil, nil)
+		p.player.allowed = func(path string) bool { return srv.allowedOnPeer(p, path) }
+		srv.peers = append(srv.peers, p)
+	}
+	sort.Slice(srv.peers, func(i, j int) bool { return srv.peers[i].name < srv.peers[j].name })
+	return srv
+}
+
+// Player returns player of specified host, player of this instance is returned if host is empty or config.LocalHost.
+func (srv *PeerService) Player(host string) (*PlayerService, error) {
+	if host == "" || host == config.LocalHost {
+		return srv.local, nil
+	}
+	for _, p := range srv.peers {
+		if p.name == host {
+			return p.player, nil
+		}
+	}
+	return nil, &UnknownHostError{Host: host}
+}
+
+// Peers returns configured peers, peer is available if its player responds.
+func (srv *PeerService) Peers() []api.Peer {
+	result := make([]api.Peer, len(srv.peers))
+	var wg sync.WaitGroup
+	for i, p := range srv.peers {
+		result[i] = api.Peer{Name: p.name, Addr: p.addr}
+		wg.Add(1)
+		go func(i int, p *peer) {
+			defer wg.Done()
+			_, err := p.player.Status()
+			result[i].Available = err == nil
+		}(i, p)
+	}
+	wg.Wait()
+	return result
+}
+
+// AllMovies returns movies of this instance together with movies of all available peers. Host of each movie is set,
+// movies of unavailable peers are skipped. Movies of peers are filtered with restriction of the active profile as
+// well, movie which details are unknown to this instance is not allowed.
+func (srv *PeerService) AllMovies() []api.Movie {
+	local := srv.catalog.All()
+	for i := range local {
+		local[i].Host = config.LocalHost
+	}
+	remote := make([][]api.Movie, len(srv.peers))
+	var wg sync.WaitGroup
+	for i, p := range srv.peers {
+		wg.Add(1)
+		go func(i int, p *peer) {
+			defer wg.Done()
+			movies, err := srv.fetchMovies(p.addr)
+			if err != nil {
+				log.WithFields(log.Fields{"peer": p.name, "err": err}).Warn("Unable get catalog of peer")
+				return
+			}
+			for j := range movies {
+				movies[j].Host = p.name
+			}
+			remote[i] = srv.catalog.restrict(movies)
+		}(i, p)
+	}
+	wg.Wait()
+	all := local
+	for _, movies := range remote {
+		all = append(all, movies...)
+	}
+	return all
+}
+
+// allowedOnPeer checks whether file of peer is allowed by the active profile of this instance. File which is not found in
+// catalog of peer is not allowed since it is unknown whether it matches restriction.
+func (srv *PeerService) allowedOnPeer(p *peer, path string) bool {
+	if srv.catalog.restriction == nil {
+		return true
+	}
+	movies, err := srv.fetchMovies(p.addr)
+	if err != nil {
+		log.WithFields(log.Fields{"peer": p.name, "err": err}).Warn("Unable get catalog of peer")
+		return false
+	}
+	for _, m := range movies {
+		if m.File == path {
+			return srv.catalog.restriction.AllowsMovie(m)
+		}
+	}
+	return false
+}
+
+func (srv *PeerService) fetchMovies(addr string) (movies []api.Movie, err error) {
+	var resp *http.Response
+	if resp, err = srv.client.Get(addr + "/api/list?details=false"); err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s: %s", addr, resp.Status)
+		return
+	}
+	err = json.NewDecoder(resp.Body).Decode(&movies)
+	return
+}
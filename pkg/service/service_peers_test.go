@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+func TestAllMoviesOfPeers(t *testing.T) {
+	bedroom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]api.Movie{{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv"}})
+	}))
+	defer bedroom.Close()
+	conf := &config.Config{Peers: map[string]string{"bedroom": bedroom.URL, "kitchen": "http://127.0.0.1:1"}}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{{Id: 1, File: "/movies/gladiator.mkv", Title: "gladiator.mkv"}}}, conf)
+	srv := CreatePeerService(conf, ctl, createPlayerService(&playerMock{}, CreatePlayQueue(), ctl, conf))
+
+	movies := srv.AllMovies()
+
+	expected := []api.Movie{
+		{Id: 1, File: "/movies/gladiator.mkv", Title: "gladiator.mkv", Host: config.LocalHost},
+		{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv", Host: "bedroom"},
+	}
+	assert.Equal(t, expected, movies)
+}
+
+func TestPlayerOfHost(t *testing.T) {
+	conf := &config.Config{Peers: map[string]string{"bedroom": "http://192.168.0.20:8000"}}
+	ctl := createCatalogService(&catalogMock{}, conf)
+	local := createPlayerService(&playerMock{}, CreatePlayQueue(), ctl, conf)
+	srv := CreatePeerService(conf, ctl, local)
+
+	p, err := srv.Player("")
+	assert.Nil(t, err)
+	assert.Equal(t, local, p)
+	p, err = srv.Player("bedroom")
+	assert.Nil(t, err)
+	assert.NotEqual(t, local, p)
+	_, err = srv.Player("garage")
+	assert.Equal(t, &UnknownHostError{Host: "garage"}, err)
+}
+
+func TestMoviesOfPeersAreRestrictedByActiveProfile(t *testing.T) {
+	bedroom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]api.Movie{
+			{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv"},
+			{Id: 2, File: "/movies/alien.mkv", Title: "alien.mkv"},
+			{Id: 5, File: "/movies/unknown.mkv", Title: "unknown.mkv"},
+		})
+	}))
+	defer bedroom.Close()
+	profiles, ctl := createTestProfileService()
+	ctl.conf.Peers = map[string]string{"bedroom": bedroom.URL}
+	_, err := profiles.Save(api.Profile{Name: "kids", MaxCertification: "PG"}, "")
+	assert.Nil(t, err)
+	_, err = profiles.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+	srv := CreatePeerService(ctl.conf, ctl, createPlayerService(&playerMock{}, CreatePlayQueue(), ctl, ctl.conf))
+
+	var remote []api.Movie
+	for _, m := range srv.AllMovies() {
+		if m.Host == "bedroom" {
+			remote = append(remote, m)
+		}
+	}
+
+	assert.Equal(t, []api.Movie{{Id: 1, File: "/movies/brave.mkv", Title: "brave.mkv", Host: "bedroom"}}, remote)
+}
+
+func TestPlaybackOnPeerIsRestrictedByActiveProfile(t *testing.T) {
+	var played []string
+	bedroom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/list":
+			_ = json.NewEncoder(w).Encode([]api.Movie{
+				{Id: 1, File: "/media/bedroom/brave.mkv", Title: "brave.mkv"},
+				{Id: 2, File: "/media/bedroom/alien.mkv", Title: "alien.mkv"},
+			})
+		case "/api/play":
+			var playback api.Playback
+			_ = json.NewDecoder(r.Body).Decode(&playback)
+			played = append(played, playback.File)
+			_, _ = w.Write([]byte("{}"))
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	defer bedroom.Close()
+	profiles, ctl := createTestProfileService()
+	ctl.conf.Peers = map[string]string{"bedroom": bedroom.URL}
+	_, err := profiles.Save(api.Profile{Name: "kids", MaxCertification: "PG"}, "")
+	assert.Nil(t, err)
+	_, err = profiles.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+	srv := CreatePeerService(ctl.conf, ctl, createPlayerService(&playerMock{}, CreatePlayQueue(), ctl, ctl.conf))
+	p, err := srv.Player("bedroom")
+	assert.Nil(t, err)
+
+	_, err = p.PlayMovie(api.Playback{File: "/media/bedroom/alien.mkv"})
+	assert.Equal(t, &RestrictedError{File: "/media/bedroom/alien.mkv"}, err)
+	_, err = p.PlayMovie(api.Playback{File: "/media/bedroom/unknown.mkv"})
+	assert.Equal(t, &RestrictedError{File: "/media/bedroom/unknown.mkv"}, err)
+	_, err = p.PlayMovie(api.Playback{File: "/media/bedroom/brave.mkv"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/media/bedroom/brave.mkv"}, played)
+}
+
+func TestPlaybackOnPeerIsNotCheckedWithLocalCatalog(t *testing.T) {
+	var played api.Playback
+	bedroom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/play" {
+			_ = json.NewDecoder(r.Body).Decode(&played)
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer bedroom.Close()
+	conf := &config.Config{Peers: map[string]string{"bedroom": bedroom.URL}}
+	ctl := createCatalogService(&catalogMock{}, conf)
+	srv := CreatePeerService(conf, ctl, createPlayerService(&playerMock{}, CreatePlayQueue(), ctl, conf))
+	p, err := srv.Player("bedroom")
+	assert.Nil(t, err)
+
+	_, err = p.PlayMovie(api.Playback{File: "/media/bedroom/alien.mkv", Position: 30})
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.Playback{File: "/media/bedroom/alien.mkv", Position: 30}, played)
+}
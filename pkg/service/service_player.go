@@ -1,7 +1,8 @@
 package service
 
 import (
-	"sync"
+	"errors"
+	"fmt"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -12,139 +13,190 @@ import (
 )
 
 type PlayerService struct {
-	player player.Player
-	queue  *PlayQueue
-}
-
-func CreatePlayerService(conf *config.Config) (*PlayerService, error) {
+	player  player.Player
+	queue   *PlayQueue
+	catalog *CatalogService
+	conf    *config.Config
+	// allowed checks restriction instead of catalog, it is used for players of peers which play files unknown to
+	// catalog of this instance
+	allowed func(path string) bool
+	// beforeAdvance is called when playback of the current item is finished, next item from queue is not played if
+	// it returns false
+	beforeAdvance func() bool
+}
+
+func CreatePlayerService(conf *config.Config, catalog *CatalogService) (*PlayerService, error) {
 	p, err := player.Create(conf)
 	if err != nil {
 		return nil, err
 	}
-	q := PlayQueue{}
-	l := playListener{queue: &q, player: p}
-	p.AddListener(&l)
-	return createPlayerService(p, &q), nil
+	return CreatePlayerServiceWith(p, conf, catalog), nil
 }
 
-func createPlayerService(p player.Player, q *PlayQueue) *PlayerService {
-	return &PlayerService{player: p, queue: q}
+// CreatePlayerServiceWith creates service on top of player which is not created from configuration, e.g. fake player of
+// servertest.
+func CreatePlayerServiceWith(p player.Player, conf *config.Config, catalog *CatalogService) *PlayerService {
+	srv := createPlayerService(p, CreatePlayQueue(), catalog, conf)
+	p.AddListener(&playListener{srv: srv})
+	return srv
+}
+
+func createPlayerService(p player.Player, q *PlayQueue, catalog *CatalogService, conf *config.Config) *PlayerService {
+	return &PlayerService{player: p, queue: q, catalog: catalog, conf: conf}
 }
 
 type playListener struct {
-	queue  *PlayQueue
-	player player.Player
+	srv *PlayerService
 }
 
 func (l *playListener) StartPlay(path string) {
 }
 
 func (l *playListener) StopPlay(path string) {
-	next := l.queue.Pop()
-	if next != "" {
-		err := l.player.PlayMovie(next)
-		if err != nil {
-			log.WithFields(log.Fields{"file": next, "err": err}).Error("Unable start play")
-		}
-	}
 }
 
-type PlayQueue struct {
-	lock sync.Mutex
-	arr  []string
+func (l *playListener) CompletePlay(path string) {
+	go l.srv.advance(true)
 }
 
-func (q *PlayQueue) Enqueue(path []string) {
-	q.lock.Lock()
-	q.arr = append(q.arr, path...)
-	q.lock.Unlock()
+func (l *playListener) CrashPlay(path string, err error) {
+	// do not repeat movie which crashed player
+	go l.srv.advance(false)
 }
 
-func (q *PlayQueue) Dequeue(i int) {
-	q.lock.Lock()
-	if i < len(q.arr) {
-		q.arr = append(q.arr[:i], q.arr[i+1:]...)
+// advance plays next item from queue when playback of the current item is finished.
+func (srv *PlayerService) advance(repeat bool) {
+	if srv.beforeAdvance != nil && !srv.beforeAdvance() {
+		return
 	}
-	q.lock.Unlock()
-}
-
-func (q *PlayQueue) Pop() (path string) {
-	q.lock.Lock()
-	if len(q.arr) > 0 {
-		path, q.arr = q.arr[0], q.arr[1:]
+	if !srv.queue.Mode().AutoAdvance {
+		return
+	}
+	next, ok := srv.queue.PeekNext(repeat)
+	if !ok {
+		// marks end of queue
+		srv.queue.Next(repeat)
+		return
+	}
+	if err := srv.playMove(next); err != nil {
+		log.WithFields(log.Fields{"file": next.Path, "err": err}).Error("Unable start play")
 	}
-	q.lock.Unlock()
-	return
-}
-
-func (q *PlayQueue) Empty() (r bool) {
-	q.lock.Lock()
-	r = len(q.arr) == 0
-	q.lock.Unlock()
-	return
-}
-
-func (q *PlayQueue) All() (all []string) {
-	q.lock.Lock()
-	all = append([]string{}, q.arr...)
-	q.lock.Unlock()
-	return
-}
-
-func (q *PlayQueue) Clear() {
-	q.lock.Lock()
-	q.arr = []string{}
-	q.lock.Unlock()
 }
 
-func (q *PlayQueue) Shift(i int) {
-	q.lock.Lock()
-	if i < len(q.arr) {
-		q.arr = append([]string{}, q.arr[i:]...)
-	}
-	q.lock.Unlock()
-	return
+// AddListener registers listener of player events, listener may implement player.CrashPlayListener and
+// player.CompletePlayListener as well.
+func (srv *PlayerService) AddListener(l player.PlayListener) {
+	srv.player.AddListener(l)
 }
 
 func (srv *PlayerService) AudioTracks() ([]api.Stream, error) {
 	return srv.player.AudioTracks()
 }
 
+// Enqueue adds files to the queue. If player does not play anything playback of the next item from queue is started.
 func (srv *PlayerService) Enqueue(files []string) (queue []string, err error) {
+	for _, f := range files {
+		if err = srv.checkAllowed(f); err != nil {
+			queue = srv.queue.All()
+			return
+		}
+	}
 	if len(files) > 0 {
-		s, _ := srv.player.Status()
-		if s.File == "" {
-			err = srv.player.PlayMovie(files[0])
-			srv.queue.Enqueue(files[1:])
-		} else {
-			srv.queue.Enqueue(files)
+		srv.queue.Enqueue(files)
+		if s, _ := srv.player.Status(); s.Stopped {
+			if next, ok := srv.queue.PeekNext(false); ok {
+				err = srv.playMove(next)
+			}
+		}
+	}
+	queue = srv.queue.All()
+	return
+}
+
+// ReplaceQueue replaces content and mode of the queue and starts playback of the first item even if player plays
+// something else. Queue is not changed if any of files is restricted or mode is invalid.
+func (srv *PlayerService) ReplaceQueue(files []string, mode api.QueueMode) (queue []string, err error) {
+	for _, f := range files {
+		if err = srv.checkAllowed(f); err != nil {
+			queue = srv.queue.All()
+			return
 		}
 	}
+	if err = srv.queue.SetMode(mode); err != nil {
+		queue = srv.queue.All()
+		return
+	}
+	srv.queue.Clear()
+	srv.queue.Enqueue(files)
+	if next, ok := srv.queue.PeekNext(false); ok {
+		err = srv.playMove(next)
+	}
 	queue = srv.queue.All()
 	return
 }
 
-func (srv *PlayerService) Dequeue(i int) (queue []string) {
-	srv.queue.Dequeue(i)
+func (srv *PlayerService) Dequeue(i int) (queue []string, err error) {
+	err = srv.queue.Dequeue(i)
 	queue = srv.queue.All()
 	return
 }
 
-func (srv *PlayerService) Queue() []string {
-	return srv.queue.All()
+func (srv *PlayerService) Queue() api.Queue {
+	return srv.queue.State()
 }
 
 func (srv *PlayerService) ClearQueue() {
 	srv.queue.Clear()
 }
 
-func (srv *PlayerService) ShiftQueue(i int) (queue []string) {
-	srv.queue.Shift(i)
-	queue = srv.queue.All()
-	srv.player.Stop()
+func (srv *PlayerService) SetQueueMode(mode api.QueueMode) (queue api.Queue, err error) {
+	err = srv.queue.SetMode(mode)
+	queue = srv.queue.State()
 	return
 }
 
+// NextInQueue plays next item from queue. Playback is stopped if there is nothing to play next.
+func (srv *PlayerService) NextInQueue() (queue api.Queue, err error) {
+	if next, ok := srv.queue.PeekNext(false); ok {
+		err = srv.playMove(next)
+	} else if err = srv.player.Stop(); err == nil {
+		// marks end of queue
+		srv.queue.Next(false)
+	}
+	queue = srv.queue.State()
+	return
+}
+
+// PreviousInQueue plays previous item from queue.
+func (srv *PlayerService) PreviousInQueue() (api.Queue, error) {
+	prev, ok := srv.queue.PeekPrevious()
+	if !ok {
+		return srv.queue.State(), errors.New("there is no previous item in queue")
+	}
+	err := srv.playMove(prev)
+	return srv.queue.State(), err
+}
+
+// JumpInQueue plays item at specified position of queue.
+func (srv *PlayerService) JumpInQueue(i int) (api.Queue, error) {
+	m, ok := srv.queue.PeekJump(i)
+	if !ok {
+		return srv.queue.State(), fmt.Errorf("invalid queue position: %d", i)
+	}
+	err := srv.playMove(m)
+	return srv.queue.State(), err
+}
+
+// playMove plays item of queue, cursor of queue is moved only when playback is started so failed attempt does not
+// consume item.
+func (srv *PlayerService) playMove(m QueueMove) error {
+	if err := srv.playFile(m.Path, 0); err != nil {
+		return err
+	}
+	srv.queue.Commit(m)
+	return nil
+}
+
 func (srv *PlayerService) NextAudioTrack() (audios []api.Stream, err error) {
 	err = srv.player.NextAudioTrack()
 	if err == nil {
@@ -178,15 +230,14 @@ func (srv *PlayerService) Play() (status api.PlayerStatus, err error) {
 }
 
 func (srv *PlayerService) PlayMovie(playback api.Playback) (status api.PlayerStatus, err error) {
-	err = srv.player.PlayMovie(playback.File)
+	if err = srv.checkAllowed(playback.File); err != nil {
+		return
+	}
+	// queue is changed only when movie is started, failed attempt must not rewrite history of playback
+	err = srv.playFile(playback.File, playback.Position)
 	if err == nil {
+		srv.queue.PlayNow(playback.File)
 		var playbackErr error
-		if playback.Position > 0 {
-			playbackErr = srv.player.SetPosition(time.Duration(playback.Position) * time.Second)
-			if playbackErr != nil {
-				log.WithFields(log.Fields{"file": playback.File, "position": playback.Position, "err": playbackErr}).Error("Unable set playback position")
-			}
-		}
 		if playback.ActiveAudioTrack > 0 {
 			playbackErr = srv.player.SelectAudio(playback.ActiveAudioTrack)
 			if playbackErr != nil {
@@ -206,6 +257,85 @@ func (srv *PlayerService) PlayMovie(playback api.Playback) (status api.PlayerSta
 	return
 }
 
+// playFile plays movie, restriction is checked again since profile may be changed after movie is queued.
+func (srv *PlayerService) playFile(path string, position int) error {
+	if err := srv.checkAllowed(path); err != nil {
+		return err
+	}
+	if srv.catalog != nil {
+		if err := srv.catalog.EnsureAvailable(path); err != nil {
+			return err
+		}
+	}
+	return srv.player.PlayMovie(path, srv.launchOptions(path, position))
+}
+
+func (srv *PlayerService) checkAllowed(path string) error {
+	if srv.allowed != nil {
+		if !srv.allowed(path) {
+			return &RestrictedError{File: path}
+		}
+		return nil
+	}
+	if srv.catalog != nil && !srv.catalog.Allowed(path) {
+		return &RestrictedError{File: path}
+	}
+	return nil
+}
+
+// launchOptions resolves player options for the movie. Options from configuration are used as defaults, they are
+// overridden with options of named profile and then with options saved in catalog for particular movie.
+func (srv *PlayerService) launchOptions(path string, position int) player.LaunchOptions {
+	var opts api.PlayerOptions
+	var override api.PlayerOptions
+	if srv.conf != nil {
+		opts = srv.conf.Player
+	}
+	if srv.catalog != nil {
+		if m, found := srv.catalog.GetByFile(path); found && m.PlayerOptions != nil {
+			override = *m.PlayerOptions
+		}
+	}
+	profile := override.Profile
+	if profile == "" {
+		profile = opts.Profile
+	}
+	if profile != "" && srv.conf != nil {
+		if p, ok := srv.conf.PlayerProfiles[profile]; ok {
+			opts = mergePlayerOptions(opts, p)
+		} else {
+			log.WithFields(log.Fields{"file": path, "profile": profile}).Warn("Unknown player profile")
+		}
+	}
+	opts = mergePlayerOptions(opts, override)
+	return player.LaunchOptions{Position: time.Duration(position) * time.Second, Options: opts}
+}
+
+func mergePlayerOptions(opts, override api.PlayerOptions) api.PlayerOptions {
+	if override.Profile != "" {
+		opts.Profile = override.Profile
+	}
+	if override.AudioOutput != "" {
+		opts.AudioOutput = override.AudioOutput
+	}
+	if override.Passthrough != nil {
+		opts.Passthrough = override.Passthrough
+	}
+	if override.SubtitleFontSize != 0 {
+		opts.SubtitleFontSize = override.SubtitleFontSize
+	}
+	if override.SubtitleAlign != "" {
+		opts.SubtitleAlign = override.SubtitleAlign
+	}
+	if override.AspectMode != "" {
+		opts.AspectMode = override.AspectMode
+	}
+	if override.Display != 0 {
+		opts.Display = override.Display
+	}
+	return opts
+}
+
 func (srv *PlayerService) PlayPause() (status api.PlayerStatus, err error) {
 	err = srv.player.PlayPause()
 	if err == nil {
@@ -274,8 +404,8 @@ func (srv *PlayerService) Status() (api.PlayerStatus, error) {
 	return srv.player.Status()
 }
 
+// Stop stops playback, content of queue is kept and playback may be continued with NextInQueue or JumpInQueue.
 func (srv *PlayerService) Stop() (status api.PlayerStatus, err error) {
-	srv.ClearQueue()
 	var statusErr error
 	status, statusErr = srv.player.Status()
 	if err = srv.player.Stop(); err == nil {
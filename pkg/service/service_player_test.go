@@ -0,0 +1,257 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/player"
+)
+
+func TestPlayMovieWithConfiguredOptions(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{Player: api.PlayerOptions{AudioOutput: "hdmi", SubtitleFontSize: 40}}
+	srv := createPlayerService(p, CreatePlayQueue(), createCatalogService(&catalogMock{}, conf), conf)
+
+	_, err := srv.PlayMovie(api.Playback{File: "/movies/gladiator.mkv", Position: 90})
+
+	assert.Nil(t, err)
+	expected := player.LaunchOptions{Position: time.Duration(90) * time.Second, Options: api.PlayerOptions{AudioOutput: "hdmi", SubtitleFontSize: 40}}
+	assert.Equal(t, []playRequest{{path: "/movies/gladiator.mkv", opts: expected}}, p.played)
+}
+
+func TestPlayMovieWithProfileAndMovieOptions(t *testing.T) {
+	p := &playerMock{}
+	passthrough := true
+	conf := &config.Config{
+		Player: api.PlayerOptions{AudioOutput: "local", SubtitleFontSize: 40, AspectMode: "letterbox"},
+		PlayerProfiles: map[string]api.PlayerOptions{
+			"surround": {AudioOutput: "hdmi", Passthrough: &passthrough},
+		},
+	}
+	ctl := &catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/gladiator.mkv", PlayerOptions: &api.PlayerOptions{Profile: "surround", AspectMode: "fill"}},
+	}}
+	srv := createPlayerService(p, CreatePlayQueue(), createCatalogService(ctl, conf), conf)
+
+	_, err := srv.PlayMovie(api.Playback{File: "/movies/gladiator.mkv"})
+
+	assert.Nil(t, err)
+	expected := player.LaunchOptions{Options: api.PlayerOptions{Profile: "surround", AudioOutput: "hdmi", Passthrough: &passthrough, SubtitleFontSize: 40, AspectMode: "fill"}}
+	assert.Equal(t, []playRequest{{path: "/movies/gladiator.mkv", opts: expected}}, p.played)
+}
+
+func TestFailedPlayMovieKeepsQueue(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	srv := createPlayerService(p, createTestQueue("/movies/a.mkv", "/movies/b.mkv"), createCatalogService(&catalogMock{}, conf), conf)
+	_, err := srv.NextInQueue()
+	assert.Nil(t, err)
+
+	p.err = errors.New("omxplayer is stopping")
+	_, err = srv.PlayMovie(api.Playback{File: "/movies/gladiator.mkv"})
+
+	assert.NotNil(t, err)
+	queue := srv.Queue()
+	assert.Equal(t, []api.MoviePath{{File: "/movies/a.mkv"}, {File: "/movies/b.mkv"}}, queue.Items)
+	assert.Equal(t, 0, queue.Current)
+}
+
+func TestFailedStartDoesNotMoveQueue(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	srv := createPlayerService(p, createTestQueue("/movies/a.mkv", "/movies/b.mkv", "/movies/c.mkv"), createCatalogService(&catalogMock{}, conf), conf)
+	_, err := srv.JumpInQueue(1)
+	assert.Nil(t, err)
+
+	p.err = errors.New("omxplayer is stopping")
+	queue, err := srv.NextInQueue()
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, queue.Current)
+	queue, err = srv.PreviousInQueue()
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, queue.Current)
+	queue, err = srv.JumpInQueue(2)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, queue.Current)
+	srv.advance(true)
+	assert.Equal(t, 1, srv.Queue().Current)
+
+	p.err = nil
+	queue, err = srv.NextInQueue()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, queue.Current)
+	assert.Equal(t, "/movies/c.mkv", p.status.File)
+}
+
+func TestRestrictedItemIsNotConsumedByEnqueue(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{{Id: 1, File: "/movies/a.mkv"}}}, conf)
+	srv := createPlayerService(p, CreatePlayQueue(), ctl, conf)
+	_, err := srv.Enqueue([]string{"/movies/a.mkv"})
+	assert.Nil(t, err)
+	_, err = srv.Stop()
+	assert.Nil(t, err)
+	p.status = api.PlayerStatus{}
+
+	ctl.SetRestriction(deniedFiles{"/other/b.mkv"})
+	srv.queue.Enqueue([]string{"/other/b.mkv"})
+	_, err = srv.Enqueue([]string{"/movies/c.mkv"})
+
+	assert.Equal(t, &RestrictedError{File: "/other/b.mkv"}, err)
+	assert.Equal(t, 0, srv.Queue().Current)
+}
+
+func TestAdvanceWhenPlaybackCompleted(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	srv := createPlayerService(p, createTestQueue("/movies/a.mkv", "/movies/b.mkv"), createCatalogService(&catalogMock{}, conf), conf)
+
+	_, err := srv.NextInQueue()
+	assert.Nil(t, err)
+	srv.advance(true)
+
+	assert.Equal(t, 2, len(p.played))
+	assert.Equal(t, "/movies/b.mkv", p.played[1].path)
+	assert.Equal(t, 1, srv.Queue().Current)
+}
+
+func TestDoNotAdvanceWhenAutoAdvanceOff(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	q := createTestQueue("/movies/a.mkv", "/movies/b.mkv")
+	assert.Nil(t, q.SetMode(api.QueueMode{AutoAdvance: false}))
+	srv := createPlayerService(p, q, createCatalogService(&catalogMock{}, conf), conf)
+
+	_, err := srv.NextInQueue()
+	assert.Nil(t, err)
+	srv.advance(true)
+
+	assert.Equal(t, 1, len(p.played))
+}
+
+func TestStopKeepsQueue(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	srv := createPlayerService(p, createTestQueue("/movies/a.mkv", "/movies/b.mkv"), createCatalogService(&catalogMock{}, conf), conf)
+
+	_, err := srv.NextInQueue()
+	assert.Nil(t, err)
+	_, err = srv.Stop()
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(srv.Queue().Items))
+	_, err = srv.PreviousInQueue()
+	assert.NotNil(t, err)
+	_, err = srv.NextInQueue()
+	assert.Nil(t, err)
+	assert.Equal(t, "/movies/b.mkv", p.played[1].path)
+}
+
+type playRequest struct {
+	path string
+	opts player.LaunchOptions
+}
+
+type playerMock struct {
+	err         error
+	status      api.PlayerStatus
+	played      []playRequest
+	stopped     int
+	volumeDowns int
+	listeners   []player.PlayListener
+}
+
+func (p *playerMock) AudioTracks() ([]api.Stream, error) { return nil, p.err }
+func (p *playerMock) NextAudioTrack() error              { return p.err }
+func (p *playerMock) NextSubtitle() error                { return p.err }
+func (p *playerMock) Pause() error                       { return p.err }
+func (p *playerMock) Play() error                        { return p.err }
+func (p *playerMock) PlayPause() error                   { return p.err }
+func (p *playerMock) PreviousAudioTrack() error          { return p.err }
+func (p *playerMock) PreviousSubtitle() error            { return p.err }
+func (p *playerMock) ReplayCurrent() error               { return p.err }
+func (p *playerMock) Seek(time.Duration) error           { return p.err }
+func (p *playerMock) SelectAudio(int) error              { return p.err }
+func (p *playerMock) SelectSubtitle(int) error           { return p.err }
+func (p *playerMock) SetPosition(time.Duration) error    { return p.err }
+func (p *playerMock) Subtitles() ([]api.Stream, error)   { return nil, p.err }
+func (p *playerMock) ToggleMute() error                  { return p.err }
+func (p *playerMock) ToggleSubtitles() error             { return p.err }
+func (p *playerMock) Volume() (float64, error)           { return 1, p.err }
+func (p *playerMock) VolumeDown() error                  { p.volumeDowns++; return p.err }
+func (p *playerMock) VolumeUp() error                    { return p.err }
+
+func (p *playerMock) PlayMovie(path string, opts player.LaunchOptions) error {
+	p.played = append(p.played, playRequest{path: path, opts: opts})
+	if p.err == nil {
+		p.status = api.PlayerStatus{File: path, Position: int(opts.Position / time.Second)}
+	}
+	return p.err
+}
+
+func (p *playerMock) Status() (api.PlayerStatus, error) {
+	status := p.status
+	status.Stopped = status.File == ""
+	return status, p.err
+}
+
+func (p *playerMock) Stop() error {
+	p.stopped++
+	p.status = api.PlayerStatus{}
+	return p.err
+}
+
+func (p *playerMock) AddListener(l player.PlayListener) {
+	p.listeners = append(p.listeners, l)
+}
+
+type catalogMock struct {
+	movies  []api.Movie
+	drives  []api.Drive
+	mounted []string
+}
+
+func (c *catalogMock) Import([]api.Movie) (api.ImportResult, error) { return api.ImportResult{}, nil }
+
+func (c *catalogMock) MountDrive(name string) error {
+	c.mounted = append(c.mounted, name)
+	return nil
+}
+
+func (c *catalogMock) All() []api.Movie                         { return c.movies }
+func (c *catalogMock) Find(string) []api.Movie                  { return c.movies }
+func (c *catalogMock) Load() error                              { return nil }
+func (c *catalogMock) Refresh() error                           { return nil }
+func (c *catalogMock) Save() error                              { return nil }
+func (c *catalogMock) Update(u api.Movie) (api.Movie, error)    { return u, nil }
+func (c *catalogMock) AddTag(string, int) error                 { return nil }
+func (c *catalogMock) RenameLabel(string, string, string) error { return nil }
+func (c *catalogMock) DeleteLabel(string, string) error         { return nil }
+func (c *catalogMock) Drives() ([]api.Drive, error)             { return c.drives, nil }
+
+func (c *catalogMock) AddLabel(string, int, string) (api.Movie, error)    { return api.Movie{}, nil }
+func (c *catalogMock) RemoveLabel(string, int, string) (api.Movie, error) { return api.Movie{}, nil }
+
+func (c *catalogMock) Get(id int) (api.Movie, bool) {
+	for _, m := range c.movies {
+		if m.Id == id {
+			return m, true
+		}
+	}
+	return api.Movie{}, false
+}
+
+func (c *catalogMock) GetByFile(path string) (api.Movie, bool) {
+	for _, m := range c.movies {
+		if m.File == path {
+			return m, true
+		}
+	}
+	return api.Movie{}, false
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/playlist"
+)
+
+type PlaylistService struct {
+	store   playlist.Store
+	catalog *CatalogService
+	player  *PlayerService
+}
+
+func CreatePlaylistService(conf *config.Config, catalog *CatalogService, player *PlayerService) (*PlaylistService, error) {
+	store, err := playlist.CreateStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	return createPlaylistService(store, catalog, player), nil
+}
+
+func createPlaylistService(store playlist.Store, catalog *CatalogService, player *PlayerService) *PlaylistService {
+	return &PlaylistService{store: store, catalog: catalog, player: player}
+}
+
+func (srv *PlaylistService) All() []api.Playlist {
+	all := srv.store.All()
+	for i := range all {
+		srv.resolve(&all[i])
+	}
+	return all
+}
+
+func (srv *PlaylistService) Get(name string) (p api.Playlist, err error) {
+	var found bool
+	if p, found = srv.store.Get(name); !found {
+		err = &playlist.NotFoundError{Name: name}
+		return
+	}
+	srv.resolve(&p)
+	return
+}
+
+func (srv *PlaylistService) Create(p api.Playlist) (created api.Playlist, err error) {
+	if err = srv.checkMovies(p.Items); err != nil {
+		return
+	}
+	if created, err = srv.store.Create(p); err == nil {
+		srv.resolve(&created)
+	}
+	return
+}
+
+func (srv *PlaylistService) Update(p api.Playlist) (updated api.Playlist, err error) {
+	if err = srv.checkMovies(p.Items); err != nil {
+		return
+	}
+	if updated, err = srv.store.Update(p); err == nil {
+		srv.resolve(&updated)
+	}
+	return
+}
+
+func (srv *PlaylistService) Delete(name string) error {
+	return srv.store.Delete(name)
+}
+
+// Insert adds movies at specified position of playlist, movies are appended to the end if position is nil.
+func (srv *PlaylistService) Insert(name string, pos *int, ids []int) (p api.Playlist, err error) {
+	if err = srv.checkMovies(ids); err != nil {
+		return
+	}
+	at := -1
+	if pos != nil {
+		at = *pos
+	}
+	if p, err = srv.store.Insert(name, at, ids); err == nil {
+		srv.resolve(&p)
+	}
+	return
+}
+
+func (srv *PlaylistService) Remove(name string, pos int) (p api.Playlist, err error) {
+	if p, err = srv.store.Remove(name, pos); err == nil {
+		srv.resolve(&p)
+	}
+	return
+}
+
+func (srv *PlaylistService) Move(name string, from, to int) (p api.Playlist, err error) {
+	if p, err = srv.store.Move(name, from, to); err == nil {
+		srv.resolve(&p)
+	}
+	return
+}
+
+// Play replaces content of play queue with movies from playlist and starts playback of the first one. Unknown movies
+// and movies restricted by the active profile are skipped, queue is left untouched if there is nothing to play.
+func (srv *PlaylistService) Play(name string) (queue []string, err error) {
+	p, found := srv.store.Get(name)
+	if !found {
+		err = &playlist.NotFoundError{Name: name}
+		return
+	}
+	files := make([]string, 0, len(p.Items))
+	for _, id := range p.Items {
+		m, ok := srv.catalog.Get(id)
+		if !ok {
+			log.WithFields(log.Fields{"playlist": name, "id": id}).Warn("Skip unknown movie in playlist")
+			continue
+		}
+		if !srv.catalog.Allowed(m.File) {
+			log.WithFields(log.Fields{"playlist": name, "file": m.File}).Warn("Skip movie restricted by active profile")
+			continue
+		}
+		files = append(files, m.File)
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("nothing to play in playlist %s", name)
+		queue = srv.player.queue.All()
+		return
+	}
+	return srv.player.ReplaceQueue(files, api.QueueMode{Repeat: p.Repeat, Shuffle: p.Shuffle, AutoAdvance: true})
+}
+
+func (srv *PlaylistService) checkMovies(ids []int) error {
+	for _, id := range ids {
+		if _, found := srv.catalog.Get(id); !found {
+			return fmt.Errorf("unknown movie, id: %d", id)
+		}
+	}
+	return nil
+}
+
+func (srv *PlaylistService) resolve(p *api.Playlist) {
+	p.Movies = make([]api.Movie, 0, len(p.Items))
+	for _, id := range p.Items {
+		if m, found := srv.catalog.Get(id); found {
+			p.Movies = append(p.Movies, m)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/playlist"
+)
+
+func TestPlayPlaylist(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/a.mkv"},
+		{Id: 2, File: "/movies/b.mkv"},
+		{Id: 3, File: "/movies/c.mkv"},
+	}}, conf)
+	q := createTestQueue("/movies/old.mkv")
+	store := &playlistStoreMock{playlists: map[string]api.Playlist{
+		"evening": {Name: "evening", Items: []int{3, 1, 4, 2}, Repeat: playlist.RepeatAll},
+	}}
+	srv := createPlaylistService(store, ctl, createPlayerService(p, q, ctl, conf))
+
+	queue, err := srv.Play("evening")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/movies/c.mkv", p.played[0].path)
+	assert.Equal(t, []string{"/movies/c.mkv", "/movies/a.mkv", "/movies/b.mkv"}, queue)
+	assert.Equal(t, api.QueueMode{Repeat: playlist.RepeatAll, AutoAdvance: true}, q.Mode())
+}
+
+func TestPlayShuffledPlaylist(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/a.mkv"},
+		{Id: 2, File: "/movies/b.mkv"},
+	}}, conf)
+	store := &playlistStoreMock{playlists: map[string]api.Playlist{
+		"evening": {Name: "evening", Items: []int{1, 2}, Shuffle: true},
+	}}
+	q := CreatePlayQueue()
+	q.shuffleFunc = reverse
+	srv := createPlaylistService(store, ctl, createPlayerService(p, q, ctl, conf))
+
+	queue, err := srv.Play("evening")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/movies/b.mkv", p.played[0].path)
+	assert.Equal(t, []string{"/movies/b.mkv", "/movies/a.mkv"}, queue)
+}
+
+func TestPlayPlaylistWhilePlaying(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/a.mkv"},
+		{Id: 2, File: "/movies/b.mkv"},
+	}}, conf)
+	store := &playlistStoreMock{playlists: map[string]api.Playlist{"evening": {Name: "evening", Items: []int{2, 1}}}}
+	player := createPlayerService(p, CreatePlayQueue(), ctl, conf)
+	_, err := player.PlayMovie(api.Playback{File: "/movies/old.mkv"})
+	assert.Nil(t, err)
+	srv := createPlaylistService(store, ctl, player)
+
+	queue, err := srv.Play("evening")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/movies/b.mkv", "/movies/a.mkv"}, queue)
+	assert.Equal(t, "/movies/b.mkv", p.status.File)
+}
+
+func TestPlayPlaylistSkipsRestrictedMovies(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/brave.mkv"},
+		{Id: 2, File: "/movies/alien.mkv"},
+	}}, conf)
+	ctl.SetRestriction(deniedFiles{"/movies/alien.mkv"})
+	q := createTestQueue("/movies/brave.mkv")
+	store := &playlistStoreMock{playlists: map[string]api.Playlist{
+		"evening": {Name: "evening", Items: []int{2, 1}},
+		"horror":  {Name: "horror", Items: []int{2}, Repeat: playlist.RepeatAll},
+	}}
+	srv := createPlaylistService(store, ctl, createPlayerService(p, q, ctl, conf))
+
+	queue, err := srv.Play("evening")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/movies/brave.mkv"}, queue)
+	assert.Equal(t, "/movies/brave.mkv", p.played[0].path)
+
+	queue, err = srv.Play("horror")
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"/movies/brave.mkv"}, queue)
+	assert.Equal(t, api.QueueMode{AutoAdvance: true}, q.Mode())
+}
+
+func TestCreatePlaylistFailsWhenMovieUnknown(t *testing.T) {
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{{Id: 1, File: "/movies/a.mkv"}}}, conf)
+	srv := createPlaylistService(&playlistStoreMock{}, ctl, nil)
+
+	_, err := srv.Create(api.Playlist{Name: "evening", Items: []int{1, 5}})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "unknown movie, id: 5", err.Error())
+}
+
+// deniedFiles is restriction which denies listed files only.
+type deniedFiles []string
+
+func (d deniedFiles) AllowsMovie(m api.Movie) bool { return d.AllowsFile(m.File) }
+
+func (d deniedFiles) AllowsFile(path string) bool {
+	for _, f := range d {
+		if f == path {
+			return false
+		}
+	}
+	return true
+}
+
+type playlistStoreMock struct {
+	playlists map[string]api.Playlist
+}
+
+func (s *playlistStoreMock) All() []api.Playlist { return nil }
+
+func (s *playlistStoreMock) Get(name string) (api.Playlist, bool) {
+	p, ok := s.playlists[name]
+	return p, ok
+}
+
+func (s *playlistStoreMock) Create(p api.Playlist) (api.Playlist, error) { return p, nil }
+func (s *playlistStoreMock) Update(p api.Playlist) (api.Playlist, error) { return p, nil }
+func (s *playlistStoreMock) Delete(string) error                         { return nil }
+
+func (s *playlistStoreMock) Insert(string, int, []int) (api.Playlist, error) {
+	return api.Playlist{}, nil
+}
+
+func (s *playlistStoreMock) Remove(string, int) (api.Playlist, error) {
+	return api.Playlist{}, nil
+}
+
+func (s *playlistStoreMock) Move(string, int, int) (api.Playlist, error) {
+	return api.Playlist{}, nil
+}
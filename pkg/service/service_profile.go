@@ -0,0 +1,186 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/profile"
+)
+
+// ErrProfileLocked is returned when the active profile has PIN and valid PIN is not provided.
+var ErrProfileLocked = errors.New("active profile is locked, valid PIN is required")
+
+// ProfileService manages profiles and restricts catalog according to the active profile. Allowed directories of profile
+// are either absolute paths or names of drives.
+type ProfileService struct {
+	store   profile.Store
+	conf    *config.Config
+	details func(m api.Movie) (api.MovieDetails, bool)
+}
+
+func CreateProfileService(conf *config.Config, catalog *CatalogService, details *DetailsService) (*ProfileService, error) {
+	store, err := profile.CreateStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	return CreateProfileServiceWith(store, conf, catalog, details), nil
+}
+
+// CreateProfileServiceWith creates service on top of store which is not created from configuration, e.g. fake store of
+// servertest.
+func CreateProfileServiceWith(store profile.Store, conf *config.Config, catalog *CatalogService, details *DetailsService) *ProfileService {
+	srv := createProfileService(store, conf, func(m api.Movie) (api.MovieDetails, bool) {
+		md, found, err := details.MovieDetails(m, conf.DetailsLangs[0], false)
+		return md, found && err == nil
+	})
+	catalog.SetRestriction(srv)
+	return srv
+}
+
+func createProfileService(store profile.Store, conf *config.Config, details func(m api.Movie) (api.MovieDetails, bool)) *ProfileService {
+	return &ProfileService{store: store, conf: conf, details: details}
+}
+
+func (srv *ProfileService) All() []api.Profile {
+	all := srv.store.All()
+	for i := range all {
+		all[i] = hidePin(all[i])
+	}
+	return all
+}
+
+func (srv *ProfileService) Get(name string) (api.Profile, error) {
+	if p, found := srv.store.Get(name); found {
+		return hidePin(p), nil
+	}
+	return api.Profile{}, &profile.NotFoundError{Name: name}
+}
+
+// Save creates or updates profile. PIN of existing profile is kept if new PIN is not specified.
+func (srv *ProfileService) Save(p api.Profile, pin string) (api.Profile, error) {
+	if err := srv.CheckUnlocked(pin); err != nil {
+		return api.Profile{}, err
+	}
+	if p.MaxCertification != "" && !ValidCertification(srv.conf.CertificationCountry, p.MaxCertification) {
+		return api.Profile{}, fmt.Errorf("unknown certification %s for country %s", p.MaxCertification, srv.conf.CertificationCountry)
+	}
+	p.Locked = false
+	if p.Pin != "" {
+		p.Pin = hashPin(p.Pin)
+	} else if existing, found := srv.store.Get(p.Name); found {
+		p.Pin = existing.Pin
+	}
+	saved, err := srv.store.Save(p)
+	return hidePin(saved), err
+}
+
+func (srv *ProfileService) Delete(name, pin string) error {
+	if err := srv.CheckUnlocked(pin); err != nil {
+		return err
+	}
+	return srv.store.Delete(name)
+}
+
+// Active returns the active profile, profile with empty name is returned if there is no active profile.
+func (srv *ProfileService) Active() api.Profile {
+	p, _ := srv.store.Get(srv.store.Active())
+	return hidePin(p)
+}
+
+// Activate switches to another profile, empty name removes all restrictions.
+func (srv *ProfileService) Activate(sw api.ProfileSwitch) (api.Profile, error) {
+	if err := srv.CheckUnlocked(sw.Pin); err != nil {
+		return api.Profile{}, err
+	}
+	if err := srv.store.SetActive(sw.Name); err != nil {
+		return api.Profile{}, err
+	}
+	return srv.Active(), nil
+}
+
+func (srv *ProfileService) AllowsMovie(m api.Movie) bool {
+	p, found := srv.store.Get(srv.store.Active())
+	if !found {
+		return true
+	}
+	if len(p.AllowedDirs) > 0 && !inAllowedDirs(p, m.File, m.DriveName) {
+		return false
+	}
+	if p.MaxCertification == "" && len(p.BlockedGenres) == 0 {
+		return true
+	}
+	// movie without details is not allowed since it is unknown whether it matches restrictions
+	md, found := srv.details(m)
+	if !found {
+		return false
+	}
+	if p.MaxCertification != "" && !CertificationAllowed(srv.conf.CertificationCountry, md.Certification, p.MaxCertification) {
+		return false
+	}
+	for _, blocked := range p.BlockedGenres {
+		for _, g := range md.Genres {
+			if strings.EqualFold(blocked, g) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// AllowsFile checks file which is not in catalog. Such file is denied if profile limits certification or genres, since
+// it is unknown whether it matches restrictions.
+func (srv *ProfileService) AllowsFile(path string) bool {
+	p, found := srv.store.Get(srv.store.Active())
+	if !found {
+		return true
+	}
+	if p.MaxCertification != "" || len(p.BlockedGenres) > 0 {
+		return false
+	}
+	return len(p.AllowedDirs) == 0 || inAllowedDirs(p, path, "")
+}
+
+// CheckUnlocked checks PIN if the active profile is locked.
+func (srv *ProfileService) CheckUnlocked(pin string) error {
+	p, found := srv.store.Get(srv.store.Active())
+	if !found || p.Pin == "" {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(p.Pin), []byte(hashPin(pin))) != 1 {
+		return ErrProfileLocked
+	}
+	return nil
+}
+
+func inAllowedDirs(p api.Profile, path, drive string) bool {
+	path = filepath.Clean(path)
+	for _, d := range p.AllowedDirs {
+		if filepath.IsAbs(d) {
+			d = filepath.Clean(d)
+			if path == d || strings.HasPrefix(path, strings.TrimSuffix(d, string(filepath.Separator))+string(filepath.Separator)) {
+				return true
+			}
+		} else if drive != "" && d == drive {
+			return true
+		}
+	}
+	return false
+}
+
+func hidePin(p api.Profile) api.Profile {
+	p.Locked = p.Pin != ""
+	p.Pin = ""
+	return p
+}
+
+func hashPin(pin string) string {
+	sum := sha256.Sum256([]byte("gomovies-profile:" + pin))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/profile"
+)
+
+func TestRestrictCatalogByActiveProfile(t *testing.T) {
+	srv, ctl := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", MaxCertification: "PG", BlockedGenres: []string{"horror"}}, "")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 4, len(ctl.All()))
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []api.Movie{{Id: 1, File: "/movies/kids/brave.mkv", Title: "brave.mkv", DriveName: "usb1"}}, ctl.All())
+	assert.Equal(t, 4, len(ctl.Unrestricted()))
+	assert.True(t, ctl.Allowed("/movies/kids/brave-poster.jpg"))
+	assert.False(t, ctl.Allowed("/movies/alien.mkv"))
+}
+
+func TestRestrictCatalogByAllowedDirs(t *testing.T) {
+	srv, ctl := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", AllowedDirs: []string{"/movies/kids", "usb2"}}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+
+	movies := ctl.All()
+
+	assert.Equal(t, 2, len(movies))
+	assert.Equal(t, "brave.mkv", movies[0].Title)
+	assert.Equal(t, "contact.mkv", movies[1].Title)
+}
+
+func TestRestrictPlayback(t *testing.T) {
+	srv, ctl := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", MaxCertification: "PG"}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+	p := &playerMock{}
+	player := createPlayerService(p, CreatePlayQueue(), ctl, ctl.conf)
+
+	_, err = player.PlayMovie(api.Playback{File: "/movies/alien.mkv"})
+	assert.Equal(t, &RestrictedError{File: "/movies/alien.mkv"}, err)
+	_, err = player.Enqueue([]string{"/movies/kids/brave.mkv", "/movies/alien.mkv"})
+	assert.Equal(t, &RestrictedError{File: "/movies/alien.mkv"}, err)
+	assert.Empty(t, player.Queue().Items)
+}
+
+func TestRestrictionIsNotBypassedWithPath(t *testing.T) {
+	srv, ctl := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", MaxCertification: "PG", AllowedDirs: []string{"/movies/kids"}}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	defer func() { _ = os.Chdir(wd) }()
+	assert.Nil(t, os.Chdir("/"))
+
+	assert.True(t, ctl.Allowed("/movies/kids/./brave.mkv"))
+	assert.True(t, ctl.Allowed("movies/kids/brave.mkv"))
+	assert.False(t, ctl.Allowed("/movies/kids/../alien.mkv"))
+	assert.False(t, ctl.Allowed("./movies/alien.mkv"))
+	assert.False(t, ctl.Allowed("./unknown.mkv"))
+	player := createPlayerService(&playerMock{}, CreatePlayQueue(), ctl, ctl.conf)
+	_, err = player.PlayMovie(api.Playback{File: "/movies/kids/../alien.mkv"})
+	assert.Equal(t, &RestrictedError{File: "/movies/kids/../alien.mkv"}, err)
+}
+
+func TestFileNotInCatalogIsDeniedByCertificationLimit(t *testing.T) {
+	srv, ctl := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", BlockedGenres: []string{"horror"}}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+
+	assert.False(t, ctl.Allowed("/downloads/alien.mkv"))
+	assert.True(t, ctl.Allowed("/movies/kids/brave-poster.jpg"))
+}
+
+func TestRestrictionFollowsSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	assert.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	dir, err = filepath.EvalSymlinks(dir)
+	assert.Nil(t, err)
+	kids := filepath.Join(dir, "kids")
+	adult := filepath.Join(dir, "adult")
+	assert.Nil(t, os.Mkdir(kids, 0755))
+	assert.Nil(t, os.Mkdir(adult, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(adult, "alien.mkv"), nil, 0644))
+	assert.Nil(t, os.Symlink(filepath.Join(adult, "alien.mkv"), filepath.Join(kids, "cartoon.mkv")))
+	conf := &config.Config{CertificationCountry: "US"}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{{Id: 1, File: filepath.Join(adult, "alien.mkv"), Title: "alien.mkv"}}}, conf)
+	srv := createProfileService(&profileStoreMock{profiles: make(map[string]api.Profile)}, conf, func(api.Movie) (api.MovieDetails, bool) {
+		return api.MovieDetails{}, false
+	})
+	ctl.SetRestriction(srv)
+	_, err = srv.Save(api.Profile{Name: "kids", AllowedDirs: []string{kids}}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+
+	assert.True(t, ctl.Allowed(filepath.Join(kids, "poster.jpg")))
+	assert.False(t, ctl.Allowed(filepath.Join(kids, "cartoon.mkv")))
+}
+
+func TestGetDoesNotReturnRestrictedMovie(t *testing.T) {
+	srv, ctl := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", MaxCertification: "PG"}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+
+	_, found := ctl.Get(2)
+	assert.False(t, found)
+	m, found := ctl.Get(1)
+	assert.True(t, found)
+	assert.Equal(t, "brave.mkv", m.Title)
+}
+
+func TestLockedProfileRequiresPin(t *testing.T) {
+	srv, _ := createTestProfileService()
+	_, err := srv.Save(api.Profile{Name: "kids", MaxCertification: "PG", Pin: "1234"}, "")
+	assert.Nil(t, err)
+	_, err = srv.Activate(api.ProfileSwitch{Name: "kids"})
+	assert.Nil(t, err)
+
+	_, err = srv.Activate(api.ProfileSwitch{Name: ""})
+	assert.Equal(t, ErrProfileLocked, err)
+	_, err = srv.Save(api.Profile{Name: "kids", MaxCertification: "R"}, "0000")
+	assert.Equal(t, ErrProfileLocked, err)
+	assert.Equal(t, api.Profile{Name: "kids", MaxCertification: "PG", Locked: true}, srv.Active())
+
+	active, err := srv.Activate(api.ProfileSwitch{Name: "", Pin: "1234"})
+	assert.Nil(t, err)
+	assert.Equal(t, api.Profile{}, active)
+}
+
+func createTestProfileService() (*ProfileService, *CatalogService) {
+	conf := &config.Config{CertificationCountry: "US"}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{
+		{Id: 1, File: "/movies/kids/brave.mkv", Title: "brave.mkv", DriveName: "usb1"},
+		{Id: 2, File: "/movies/alien.mkv", Title: "alien.mkv", DriveName: "usb1"},
+		{Id: 3, File: "/movies/it.mkv", Title: "it.mkv", DriveName: "usb1"},
+		{Id: 4, File: "/media/usb2/contact.mkv", Title: "contact.mkv", DriveName: "usb2"},
+	}}, conf)
+	details := map[int]api.MovieDetails{
+		1: {Certification: "PG", Genres: []string{"Animation"}},
+		2: {Certification: "R", Genres: []string{"Horror"}},
+		3: {Certification: "PG", Genres: []string{"Horror"}},
+	}
+	srv := createProfileService(&profileStoreMock{profiles: make(map[string]api.Profile)}, conf, func(m api.Movie) (api.MovieDetails, bool) {
+		md, found := details[m.Id]
+		return md, found
+	})
+	ctl.SetRestriction(srv)
+	return srv, ctl
+}
+
+type profileStoreMock struct {
+	profiles map[string]api.Profile
+	active   string
+}
+
+func (s *profileStoreMock) All() []api.Profile {
+	result := make([]api.Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		result = append(result, p)
+	}
+	return result
+}
+
+func (s *profileStoreMock) Get(name string) (api.Profile, bool) {
+	p, ok := s.profiles[name]
+	return p, ok
+}
+
+func (s *profileStoreMock) Save(p api.Profile) (api.Profile, error) {
+	s.profiles[p.Name] = p
+	return p, nil
+}
+
+func (s *profileStoreMock) Delete(name string) error {
+	if _, ok := s.profiles[name]; !ok {
+		return &profile.NotFoundError{Name: name}
+	}
+	delete(s.profiles, name)
+	return nil
+}
+
+func (s *profileStoreMock) Active() string {
+	return s.active
+}
+
+func (s *profileStoreMock) SetActive(name string) error {
+	if _, ok := s.profiles[name]; !ok && name != "" {
+		return &profile.NotFoundError{Name: name}
+	}
+	s.active = name
+	return nil
+}
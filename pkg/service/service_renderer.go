@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/player"
+	"github.com/andrew00x/gomovies/pkg/upnp"
+)
+
+const rendererDiscoveryTimeout = 3 * time.Second
+
+// RendererService discovers UPnP MediaRenderers in local network and gives players which cast movies to them. Movies
+// are served to renderers by "/file/" handler of this instance.
+type RendererService struct {
+	conf      *config.Config
+	catalog   *CatalogService
+	client    *http.Client
+	discover  func() ([]upnp.Device, error)
+	mu        sync.Mutex
+	renderers map[string]*renderer
+}
+
+type renderer struct {
+	device upnp.Device
+	player *PlayerService
+}
+
+func CreateRendererService(conf *config.Config, catalog *CatalogService) *RendererService {
+	client := &http.Client{Timeout: peerRequestTimeout}
+	return createRendererService(conf, catalog, client, func() ([]upnp.Device, error) {
+		return upnp.Discover(client, rendererDiscoveryTimeout)
+	})
+}
+
+func createRendererService(conf *config.Config, catalog *CatalogService, client *http.Client, discover func() ([]upnp.Device, error)) *RendererService {
+	return &RendererService{conf: conf, catalog: catalog, client: client, discover: discover, renderers: make(map[string]*renderer)}
+}
+
+// Discover searches renderers in local network. Renderers found before are kept, they may be used as targets of
+// playback even if they do not respond to the last search.
+func (srv *RendererService) Discover() ([]api.Renderer, error) {
+	devices, err := srv.discover()
+	if err != nil {
+		return nil, err
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, d := range devices {
+		if r, ok := srv.renderers[d.UDN]; ok && r.device == d {
+			continue
+		}
+		p := player.CreateDLNAPlayer(d, srv.client, srv.mediaURL(d))
+		// renderer plays files of this instance so restrictions and availability are checked with local catalog,
+		// player options resolved from catalog are not used since renderer does not support them
+		srv.renderers[d.UDN] = &renderer{device: d, player: createPlayerService(p, CreatePlayQueue(), srv.catalog, srv.conf)}
+	}
+	result := make([]api.Renderer, 0, len(srv.renderers))
+	for _, r := range srv.renderers {
+		result = append(result, api.Renderer{Id: r.device.UDN, Name: r.device.FriendlyName, Location: r.device.Location})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Player returns player of renderer with specified id. Renderer must be discovered before.
+func (srv *RendererService) Player(id string) (*PlayerService, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if r, ok := srv.renderers[id]; ok {
+		return r.player, nil
+	}
+	return nil, &UnknownHostError{Host: id}
+}
+
+// mediaURL builds url of movie file using address of network interface through which renderer is reachable.
+func (srv *RendererService) mediaURL(d upnp.Device) player.MediaURL {
+	return func(path string) (string, error) {
+		u, err := url.Parse(d.AVTransportURL)
+		if err != nil {
+			return "", err
+		}
+		conn, err := net.Dial("udp", u.Host)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		ip := conn.LocalAddr().(*net.UDPAddr).IP.String()
+		host := net.JoinHostPort(ip, strconv.Itoa(srv.conf.WebPort))
+		return fmt.Sprintf("http://%s/file%s", host, (&url.URL{Path: path}).EscapedPath()), nil
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/upnp"
+	"github.com/andrew00x/gomovies/pkg/upnp/upnptest"
+)
+
+func TestCastMovieToDiscoveredRenderer(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+	conf := &config.Config{WebPort: 8000}
+	srv := createRendererService(conf, createCatalogService(&catalogMock{}, conf), http.DefaultClient, func() ([]upnp.Device, error) {
+		d, err := upnp.FetchDevice(http.DefaultClient, r.Location())
+		return []upnp.Device{d}, err
+	})
+
+	renderers, err := srv.Discover()
+	assert.Nil(t, err)
+	assert.Equal(t, []api.Renderer{{Id: "uuid:fake-tv", Name: "Fake TV", Location: r.Location()}}, renderers)
+	p, err := srv.Player("uuid:fake-tv")
+	assert.Nil(t, err)
+	_, err = p.PlayMovie(api.Playback{File: "/movies/the gladiator.mkv"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://127.0.0.1:8000/file/movies/the%20gladiator.mkv", r.URI)
+	assert.Equal(t, "PLAYING", r.State)
+}
+
+func TestPlayerOfUnknownRenderer(t *testing.T) {
+	conf := &config.Config{}
+	srv := createRendererService(conf, createCatalogService(&catalogMock{}, conf), http.DefaultClient, func() ([]upnp.Device, error) {
+		return nil, nil
+	})
+
+	_, err := srv.Player("uuid:fake-tv")
+
+	assert.Equal(t, &UnknownHostError{Host: "uuid:fake-tv"}, err)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/timer"
+)
+
+const (
+	fadeOutDuration = 30 * time.Second
+	fadeOutSteps    = 10
+)
+
+// SchedulerService runs timers which stop playback or start playlist. Pending timers are persisted and restored after
+// restart, timers which were missed while application was not running are dropped.
+type SchedulerService struct {
+	mu        sync.Mutex
+	store     timer.Store
+	player    *PlayerService
+	playlists *PlaylistService
+	pending   map[int]func() bool
+	now       func() time.Time
+	schedule  func(d time.Duration, f func()) (cancel func() bool)
+	sleep     func(d time.Duration)
+}
+
+func CreateSchedulerService(conf *config.Config, player *PlayerService, playlists *PlaylistService) (*SchedulerService, error) {
+	store, err := timer.CreateStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	srv := createSchedulerService(store, player, playlists)
+	srv.start()
+	return srv, nil
+}
+
+func createSchedulerService(store timer.Store, player *PlayerService, playlists *PlaylistService) *SchedulerService {
+	srv := &SchedulerService{
+		store:     store,
+		player:    player,
+		playlists: playlists,
+		pending:   make(map[int]func() bool),
+		now:       time.Now,
+		schedule:  func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop },
+		sleep:     time.Sleep,
+	}
+	player.beforeAdvance = srv.stopAfterCurrent
+	return srv
+}
+
+func (srv *SchedulerService) start() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	now := srv.now()
+	for _, t := range srv.store.All() {
+		if t.At != nil && t.At.Before(now) {
+			log.WithFields(log.Fields{"timer": t.Id, "action": t.Action, "at": t.At}).Warn("Drop missed timer")
+			if err := srv.store.Delete(t.Id); err != nil {
+				log.WithFields(log.Fields{"timer": t.Id, "err": err}).Error("Unable delete timer")
+			}
+			continue
+		}
+		srv.arm(t)
+	}
+}
+
+func (srv *SchedulerService) All() []api.Timer {
+	return srv.store.All()
+}
+
+func (srv *SchedulerService) Get(id int) (t api.Timer, err error) {
+	var found bool
+	if t, found = srv.store.Get(id); !found {
+		err = &timer.NotFoundError{Id: id}
+	}
+	return
+}
+
+func (srv *SchedulerService) Create(t api.Timer) (created api.Timer, err error) {
+	if err = srv.prepare(&t); err != nil {
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if created, err = srv.store.Create(t); err == nil {
+		srv.arm(created)
+	}
+	return
+}
+
+func (srv *SchedulerService) Update(t api.Timer) (updated api.Timer, err error) {
+	if err = srv.prepare(&t); err != nil {
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if updated, err = srv.store.Update(t); err == nil {
+		srv.disarm(t.Id)
+		srv.arm(updated)
+	}
+	return
+}
+
+func (srv *SchedulerService) Delete(id int) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.disarm(id)
+	return srv.store.Delete(id)
+}
+
+// prepare converts relative time of timer to absolute and checks that timer refers existing playlist.
+func (srv *SchedulerService) prepare(t *api.Timer) error {
+	if t.In > 0 {
+		at := srv.now().Add(time.Duration(t.In) * time.Second)
+		t.At = &at
+		t.In = 0
+	}
+	if t.At != nil && t.At.Before(srv.now()) {
+		return errors.New("timer time is in the past")
+	}
+	if t.Action == timer.ActionPlay && t.Playlist != "" {
+		if _, err := srv.playlists.Get(t.Playlist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (srv *SchedulerService) arm(t api.Timer) {
+	if t.At == nil {
+		return
+	}
+	d := t.At.Sub(srv.now())
+	if d < 0 {
+		d = 0
+	}
+	id := t.Id
+	srv.pending[id] = srv.schedule(d, func() { srv.fire(id) })
+}
+
+func (srv *SchedulerService) disarm(id int) {
+	if cancel, ok := srv.pending[id]; ok {
+		cancel()
+		delete(srv.pending, id)
+	}
+}
+
+func (srv *SchedulerService) fire(id int) {
+	srv.mu.Lock()
+	delete(srv.pending, id)
+	t, found := srv.store.Get(id)
+	if found {
+		if err := srv.store.Delete(id); err != nil {
+			log.WithFields(log.Fields{"timer": id, "err": err}).Error("Unable delete timer")
+		}
+	}
+	srv.mu.Unlock()
+	if !found {
+		return
+	}
+	log.WithFields(log.Fields{"timer": id, "action": t.Action}).Info("Timer fired")
+	switch t.Action {
+	case timer.ActionStop:
+		srv.fadeOutAndStop()
+	case timer.ActionPlay:
+		srv.playPlaylist(t.Playlist)
+	}
+}
+
+// stopAfterCurrent consumes timers which stop playback after the current movie. It returns false if there was any
+// such timer and next movie from queue must not be played.
+func (srv *SchedulerService) stopAfterCurrent() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	advance := true
+	for _, t := range srv.store.All() {
+		if t.Action == timer.ActionStop && t.AfterCurrent {
+			if err := srv.store.Delete(t.Id); err != nil {
+				log.WithFields(log.Fields{"timer": t.Id, "err": err}).Error("Unable delete timer")
+			}
+			log.WithFields(log.Fields{"timer": t.Id}).Info("Stop after current movie")
+			advance = false
+		}
+	}
+	return advance
+}
+
+func (srv *SchedulerService) fadeOutAndStop() {
+	if status, err := srv.player.Status(); err != nil || status.Stopped {
+		return
+	}
+	for i := 0; i < fadeOutSteps; i++ {
+		if _, err := srv.player.VolumeDown(); err != nil {
+			log.WithFields(log.Fields{"err": err}).Warn("Unable fade out volume")
+			break
+		}
+		srv.sleep(fadeOutDuration / fadeOutSteps)
+	}
+	if _, err := srv.player.Stop(); err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Unable stop player")
+	}
+}
+
+func (srv *SchedulerService) playPlaylist(name string) {
+	if status, err := srv.player.Status(); err == nil && !status.Stopped {
+		if _, err = srv.player.Stop(); err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Unable stop player")
+			return
+		}
+	}
+	if _, err := srv.playlists.Play(name); err != nil {
+		log.WithFields(log.Fields{"playlist": name, "err": err}).Error("Unable play playlist")
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/timer"
+)
+
+var schedulerNow = time.Date(2019, 3, 1, 19, 0, 0, 0, time.UTC)
+
+func TestStopTimerFadesOutVolume(t *testing.T) {
+	p := &playerMock{status: api.PlayerStatus{File: "/movies/a.mkv"}}
+	srv, scheduled := createTestScheduler(p, &timerStoreMock{})
+
+	created, err := srv.Create(api.Timer{Action: timer.ActionStop, In: 45 * 60})
+	assert.Nil(t, err)
+	assert.Equal(t, schedulerNow.Add(time.Duration(45)*time.Minute), *created.At)
+	assert.Equal(t, time.Duration(45)*time.Minute, scheduled.calls[0].d)
+
+	scheduled.calls[0].f()
+
+	assert.Equal(t, fadeOutSteps, p.volumeDowns)
+	assert.Equal(t, 1, p.stopped)
+	assert.Empty(t, srv.All())
+}
+
+func TestStopAfterCurrentMovie(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	players := createPlayerService(p, createTestQueue("/movies/a.mkv", "/movies/b.mkv"), createCatalogService(&catalogMock{}, conf), conf)
+	srv := createSchedulerService(&timerStoreMock{}, players, nil)
+	_, err := players.NextInQueue()
+	assert.Nil(t, err)
+
+	_, err = srv.Create(api.Timer{Action: timer.ActionStop, AfterCurrent: true})
+	assert.Nil(t, err)
+	players.advance(true)
+
+	assert.Equal(t, 1, len(p.played))
+	assert.Empty(t, srv.All())
+	players.advance(true)
+	assert.Equal(t, 2, len(p.played))
+}
+
+func TestPlayTimerStartsPlaylist(t *testing.T) {
+	p := &playerMock{}
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{movies: []api.Movie{{Id: 1, File: "/movies/a.mkv"}}}, conf)
+	players := createPlayerService(p, CreatePlayQueue(), ctl, conf)
+	playlists := createPlaylistService(&playlistStoreMock{playlists: map[string]api.Playlist{
+		"evening": {Name: "evening", Items: []int{1}},
+	}}, ctl, players)
+	srv, scheduled := createTestScheduler(nil, &timerStoreMock{})
+	srv.player = players
+	srv.playlists = playlists
+	at := schedulerNow.Add(time.Hour)
+
+	_, err := srv.Create(api.Timer{Action: timer.ActionPlay, At: &at, Playlist: "evening"})
+	assert.Nil(t, err)
+	scheduled.calls[0].f()
+
+	assert.Equal(t, "/movies/a.mkv", p.played[0].path)
+}
+
+func TestCreateTimerFailsWhenPlaylistUnknown(t *testing.T) {
+	conf := &config.Config{}
+	ctl := createCatalogService(&catalogMock{}, conf)
+	srv, _ := createTestScheduler(nil, &timerStoreMock{})
+	srv.playlists = createPlaylistService(&playlistStoreMock{}, ctl, nil)
+	at := schedulerNow.Add(time.Hour)
+
+	_, err := srv.Create(api.Timer{Action: timer.ActionPlay, At: &at, Playlist: "evening"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "unknown playlist: evening", err.Error())
+}
+
+func TestDeleteTimerCancelsIt(t *testing.T) {
+	srv, scheduled := createTestScheduler(&playerMock{}, &timerStoreMock{})
+	created, err := srv.Create(api.Timer{Action: timer.ActionStop, In: 60})
+	assert.Nil(t, err)
+
+	assert.Nil(t, srv.Delete(created.Id))
+
+	assert.True(t, scheduled.calls[0].cancelled)
+	assert.Empty(t, srv.All())
+}
+
+func TestRestorePendingTimersAndDropMissed(t *testing.T) {
+	missed := schedulerNow.Add(-time.Minute)
+	pending := schedulerNow.Add(time.Hour)
+	store := &timerStoreMock{timers: map[int]api.Timer{
+		1: {Id: 1, Action: timer.ActionStop, At: &missed},
+		2: {Id: 2, Action: timer.ActionStop, At: &pending},
+	}}
+	srv, scheduled := createTestScheduler(&playerMock{}, store)
+
+	srv.start()
+
+	assert.Equal(t, []api.Timer{{Id: 2, Action: timer.ActionStop, At: &pending}}, srv.All())
+	assert.Equal(t, 1, len(scheduled.calls))
+	assert.Equal(t, time.Hour, scheduled.calls[0].d)
+}
+
+type scheduledCall struct {
+	d         time.Duration
+	f         func()
+	cancelled bool
+}
+
+type schedulerMock struct {
+	calls []*scheduledCall
+}
+
+func (s *schedulerMock) schedule(d time.Duration, f func()) func() bool {
+	call := &scheduledCall{d: d, f: f}
+	s.calls = append(s.calls, call)
+	return func() bool {
+		call.cancelled = true
+		return true
+	}
+}
+
+func createTestScheduler(p *playerMock, store timer.Store) (*SchedulerService, *schedulerMock) {
+	conf := &config.Config{}
+	var players *PlayerService
+	if p != nil {
+		players = createPlayerService(p, CreatePlayQueue(), createCatalogService(&catalogMock{}, conf), conf)
+	} else {
+		players = &PlayerService{}
+	}
+	srv := createSchedulerService(store, players, nil)
+	scheduled := &schedulerMock{}
+	srv.now = func() time.Time { return schedulerNow }
+	srv.sleep = func(time.Duration) {}
+	srv.schedule = scheduled.schedule
+	return srv, scheduled
+}
+
+type timerStoreMock struct {
+	timers map[int]api.Timer
+}
+
+func (s *timerStoreMock) All() []api.Timer {
+	result := make([]api.Timer, 0, len(s.timers))
+	for _, t := range s.timers {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+func (s *timerStoreMock) Get(id int) (t api.Timer, found bool) {
+	t, found = s.timers[id]
+	return
+}
+
+func (s *timerStoreMock) Create(t api.Timer) (api.Timer, error) {
+	if s.timers == nil {
+		s.timers = make(map[int]api.Timer)
+	}
+	t.Id = len(s.timers) + 1
+	s.timers[t.Id] = t
+	return t, nil
+}
+
+func (s *timerStoreMock) Update(t api.Timer) (api.Timer, error) {
+	if _, ok := s.timers[t.Id]; !ok {
+		return api.Timer{}, &timer.NotFoundError{Id: t.Id}
+	}
+	s.timers[t.Id] = t
+	return t, nil
+}
+
+func (s *timerStoreMock) Delete(id int) error {
+	if _, ok := s.timers[id]; !ok {
+		return &timer.NotFoundError{Id: id}
+	}
+	delete(s.timers, id)
+	return nil
+}
@@ -19,7 +19,13 @@ type TorrentService struct {
 }
 
 func CreateTorrentService(conf *config.Config) *TorrentService {
-	return &TorrentService{tr: torrent.CreateTorrent(conf)}
+	return CreateTorrentServiceWith(torrent.CreateTorrent(conf))
+}
+
+// CreateTorrentServiceWith creates service on top of torrent client which is not created from configuration, e.g. fake
+// client of servertest.
+func CreateTorrentServiceWith(tr torrent.Torrent) *TorrentService {
+	return &TorrentService{tr: tr}
 }
 
 func (srv *TorrentService) AddFile(file []byte) error {
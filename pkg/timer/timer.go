@@ -0,0 +1,39 @@
+package timer
+
+import (
+	"fmt"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+)
+
+const (
+	ActionStop = "stop"
+	ActionPlay = "play"
+)
+
+type Factory func(*config.Config) (Store, error)
+
+var storeFactory Factory
+
+func CreateStore(conf *config.Config) (Store, error) {
+	return storeFactory(conf)
+}
+
+// Store keeps pending timers. Timer either fires at specified time or, for stop action, when playback of the current
+// movie is completed.
+type Store interface {
+	All() []api.Timer
+	Get(id int) (api.Timer, bool)
+	Create(t api.Timer) (api.Timer, error)
+	Update(t api.Timer) (api.Timer, error)
+	Delete(id int) error
+}
+
+type NotFoundError struct {
+	Id int
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("unknown timer: %d", e.Id)
+}
@@ -0,0 +1,165 @@
+package timer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/config"
+	"github.com/andrew00x/gomovies/pkg/file"
+	"github.com/andrew00x/gomovies/pkg/util"
+)
+
+type JsonStore struct {
+	mu     sync.RWMutex
+	timers map[int]*api.Timer
+	idGen  *util.IdGenerator
+}
+
+var timersFile string
+
+func init() {
+	timersFile = filepath.Join(config.ConfDir(), "timers.json")
+	storeFactory = createJsonStore
+}
+
+func createJsonStore(_ *config.Config) (Store, error) {
+	timers, err := readTimers()
+	if err != nil {
+		return nil, err
+	}
+	s := &JsonStore{timers: make(map[int]*api.Timer)}
+	maxId := 0
+	for i := range timers {
+		t := timers[i]
+		s.timers[t.Id] = &t
+		if t.Id > maxId {
+			maxId = t.Id
+		}
+	}
+	s.idGen = util.CreateIdGenerator(maxId)
+	return s, nil
+}
+
+func (s *JsonStore) All() []api.Timer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.all()
+}
+
+func (s *JsonStore) Get(id int) (t api.Timer, found bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if stored, ok := s.timers[id]; ok {
+		t, found = *stored, true
+	}
+	return
+}
+
+func (s *JsonStore) Create(t api.Timer) (api.Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := validate(t); err != nil {
+		return api.Timer{}, err
+	}
+	t.Id = s.idGen.Next()
+	t.In = 0
+	s.timers[t.Id] = &t
+	if err := s.save(); err != nil {
+		return api.Timer{}, err
+	}
+	return t, nil
+}
+
+func (s *JsonStore) Update(t api.Timer) (api.Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := validate(t); err != nil {
+		return api.Timer{}, err
+	}
+	if _, ok := s.timers[t.Id]; !ok {
+		return api.Timer{}, &NotFoundError{t.Id}
+	}
+	t.In = 0
+	s.timers[t.Id] = &t
+	if err := s.save(); err != nil {
+		return api.Timer{}, err
+	}
+	return t, nil
+}
+
+func (s *JsonStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.timers[id]; !ok {
+		return &NotFoundError{id}
+	}
+	delete(s.timers, id)
+	return s.save()
+}
+
+func (s *JsonStore) all() []api.Timer {
+	result := make([]api.Timer, 0, len(s.timers))
+	for _, t := range s.timers {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+func (s *JsonStore) save() error {
+	return file.WriteAtomic(timersFile, 0644, 0, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s.all())
+	})
+}
+
+func readTimers() (timers []api.Timer, err error) {
+	var exists bool
+	if exists, err = file.Exists(timersFile); exists && err == nil {
+		var f *os.File
+		if f, err = os.Open(timersFile); err != nil {
+			return
+		}
+		defer func() {
+			if clsErr := f.Close(); clsErr != nil {
+				err = clsErr
+			}
+		}()
+		parser := json.NewDecoder(f)
+		err = parser.Decode(&timers)
+	}
+	return
+}
+
+func validate(t api.Timer) error {
+	switch t.Action {
+	case ActionStop:
+		if t.At == nil && !t.AfterCurrent {
+			return errors.New("stop timer requires either time or after current movie flag")
+		}
+		if t.At != nil && t.AfterCurrent {
+			return errors.New("stop timer may not have both time and after current movie flag")
+		}
+	case ActionPlay:
+		if t.At == nil {
+			return errors.New("play timer requires time")
+		}
+		if t.Playlist == "" {
+			return errors.New("play timer requires playlist")
+		}
+		if t.AfterCurrent {
+			return errors.New("play timer may not be started after current movie")
+		}
+	default:
+		return fmt.Errorf("invalid timer action '%s', supported: %s, %s", t.Action, ActionStop, ActionPlay)
+	}
+	return nil
+}
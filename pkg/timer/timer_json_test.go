@@ -0,0 +1,107 @@
+package timer
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/api"
+	"github.com/andrew00x/gomovies/pkg/util"
+)
+
+func TestCreateTimer(t *testing.T) {
+	store := setup()
+	at := time.Date(2019, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	created, err := store.Create(api.Timer{Action: ActionPlay, At: &at, Playlist: "evening"})
+
+	assert.Nil(t, err)
+	expected := api.Timer{Id: 1, Action: ActionPlay, At: &at, Playlist: "evening"}
+	assert.Equal(t, expected, created)
+	assert.Equal(t, 1, len(mustReadTimersFile()))
+}
+
+func TestCreateTimerFailsWhenActionInvalid(t *testing.T) {
+	store := setup()
+
+	_, err := store.Create(api.Timer{Action: "pause", AfterCurrent: true})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid timer action 'pause', supported: stop, play", err.Error())
+}
+
+func TestCreatePlayTimerFailsWithoutPlaylist(t *testing.T) {
+	store := setup()
+	at := time.Now()
+
+	_, err := store.Create(api.Timer{Action: ActionPlay, At: &at})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "play timer requires playlist", err.Error())
+}
+
+func TestUpdateTimer(t *testing.T) {
+	store := setup()
+	created, err := store.Create(api.Timer{Action: ActionStop, AfterCurrent: true})
+	assert.Nil(t, err)
+	at := time.Date(2019, 3, 1, 21, 0, 0, 0, time.UTC)
+
+	updated, err := store.Update(api.Timer{Id: created.Id, Action: ActionStop, At: &at})
+
+	assert.Nil(t, err)
+	assert.Equal(t, api.Timer{Id: created.Id, Action: ActionStop, At: &at}, updated)
+}
+
+func TestDeleteUnknownTimer(t *testing.T) {
+	store := setup()
+
+	err := store.Delete(7)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "unknown timer: 7", err.Error())
+}
+
+func TestLoadTimers(t *testing.T) {
+	store := setup()
+	_, err := store.Create(api.Timer{Action: ActionStop, AfterCurrent: true})
+	assert.Nil(t, err)
+	_, err = store.Create(api.Timer{Action: ActionStop, AfterCurrent: true})
+	assert.Nil(t, err)
+
+	loaded, err := createJsonStore(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, store.All(), loaded.All())
+	created, err := loaded.Create(api.Timer{Action: ActionStop, AfterCurrent: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, created.Id)
+}
+
+func setup() *JsonStore {
+	dir := filepath.Join(os.Getenv("TMPDIR"), "TimerTest")
+	if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		log.Fatal(err)
+	}
+	timersFile = filepath.Join(dir, "timers.json")
+	return &JsonStore{timers: make(map[int]*api.Timer), idGen: util.CreateIdGenerator(0)}
+}
+
+func mustReadTimersFile() []api.Timer {
+	f, err := os.Open(timersFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	var timers []api.Timer
+	if err = json.NewDecoder(f).Decode(&timers); err != nil {
+		log.Fatal(err)
+	}
+	return timers
+}
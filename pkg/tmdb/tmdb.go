@@ -31,22 +31,90 @@ type MovieShort struct {
 }
 
 type MovieDetails struct {
-	Id                  int       `json:"id"`
-	Title               string    `json:"title"`
-	OriginalTitle       string    `json:"original_title"`
-	TagLine             string    `json:"tagline"`
-	PosterPath          string    `json:"poster_path"`
-	BackdropPath        string    `json:"backdrop_path"`
-	ReleaseDate         string    `json:"release_date"`
-	Revenue             int64     `json:"revenue"`
-	ProductionCountries []Country `json:"production_countries"`
-	ProductionCompanies []Company `json:"production_companies"`
-	Budget              int64     `json:"budget"`
-	OriginalLanguage    string    `json:"original_language"`
-	Overview            string    `json:"overview"`
-	Genres              []Genre   `json:"genres"`
-	ImdbId              string    `json:"imdb_id"`
-	Runtime             int       `json:"runtime"`
+	Id                  int          `json:"id"`
+	Title               string       `json:"title"`
+	OriginalTitle       string       `json:"original_title"`
+	TagLine             string       `json:"tagline"`
+	PosterPath          string       `json:"poster_path"`
+	BackdropPath        string       `json:"backdrop_path"`
+	ReleaseDate         string       `json:"release_date"`
+	Revenue             int64        `json:"revenue"`
+	ProductionCountries []Country    `json:"production_countries"`
+	ProductionCompanies []Company    `json:"production_companies"`
+	Budget              int64        `json:"budget"`
+	OriginalLanguage    string       `json:"original_language"`
+	Overview            string       `json:"overview"`
+	Genres              []Genre      `json:"genres"`
+	ImdbId              string       `json:"imdb_id"`
+	Runtime             int          `json:"runtime"`
+	VoteAverage         float64      `json:"vote_average"`
+	VoteCount           int          `json:"vote_count"`
+	Credits             Credits      `json:"credits"`
+	Videos              Videos       `json:"videos"`
+	ReleaseDates        ReleaseDates `json:"release_dates"`
+	BelongsToCollection *Collection  `json:"belongs_to_collection"`
+}
+
+// Collection is series of movies, e.g. trilogy. Parts are present only in response of GetCollection.
+type Collection struct {
+	Id           int          `json:"id"`
+	Name         string       `json:"name"`
+	Overview     string       `json:"overview"`
+	PosterPath   string       `json:"poster_path"`
+	BackdropPath string       `json:"backdrop_path"`
+	Parts        []MovieShort `json:"parts"`
+}
+
+type Videos struct {
+	Results []Video `json:"results"`
+}
+
+type Video struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Site     string `json:"site"`
+	Type     string `json:"type"`
+	Language string `json:"iso_639_1"`
+}
+
+type ReleaseDates struct {
+	Results []CountryReleases `json:"results"`
+}
+
+type CountryReleases struct {
+	Country  string    `json:"iso_3166_1"`
+	Releases []Release `json:"release_dates"`
+}
+
+// Release type, e.g. theatrical, digital, etc, see https://developers.themoviedb.org/3/movies/get-movie-release-dates
+type Release struct {
+	Certification string `json:"certification"`
+	ReleaseDate   string `json:"release_date"`
+	Type          int    `json:"type"`
+}
+
+// TheatricalRelease is type of theatrical release.
+const TheatricalRelease = 3
+
+type Credits struct {
+	Cast []Cast `json:"cast"`
+	Crew []Crew `json:"crew"`
+}
+
+type Cast struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Character   string `json:"character"`
+	Order       int    `json:"order"`
+	ProfilePath string `json:"profile_path"`
+}
+
+type Crew struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Department  string `json:"department"`
+	Job         string `json:"job"`
+	ProfilePath string `json:"profile_path"`
 }
 
 type GenreResult struct {
@@ -103,6 +171,7 @@ type TmDb struct {
 	mu        sync.Mutex
 	rateTimer time.Time
 	apiKey    string
+	apiUrl    string
 	client    apiClient
 }
 
@@ -121,20 +190,25 @@ var once sync.Once
 
 func GetTmDbInstance(apiKey string) *TmDb {
 	once.Do(func() {
-		tmDb = &TmDb{apiKey: apiKey, client: clientFactory()}
+		tmDb = &TmDb{apiKey: apiKey, apiUrl: baseUrl, client: clientFactory()}
 	})
 	return tmDb
 }
 
+// CreateTmDbWithUrl creates client of TMDb compatible API at specified url, e.g. fake server.
+func CreateTmDbWithUrl(apiKey, apiUrl string) *TmDb {
+	return &TmDb{apiKey: apiKey, apiUrl: apiUrl, client: &defaultApiClient{}}
+}
+
 func (tmdb *TmDb) GetGenres(lang string) ([]Genre, error) {
-	reqUrl := fmt.Sprintf("%s/genre/movie/list?api_key=%s&language=%s", baseUrl, tmdb.apiKey, lang)
+	reqUrl := fmt.Sprintf("%s/genre/movie/list?api_key=%s&language=%s", tmdb.apiUrl, tmdb.apiKey, lang)
 	genres := GenreResult{}
 	_, err := tmdb.request(reqUrl, &genres)
 	return genres.Genres, err
 }
 
 func (tmdb *TmDb) GetConfiguration() (Config, error) {
-	reqUrl := fmt.Sprintf("%s/configuration?api_key=%s", baseUrl, tmdb.apiKey)
+	reqUrl := fmt.Sprintf("%s/configuration?api_key=%s", tmdb.apiUrl, tmdb.apiKey)
 	config := Config{}
 	_, err := tmdb.request(reqUrl, &config)
 	return config, err
@@ -142,7 +216,7 @@ func (tmdb *TmDb) GetConfiguration() (Config, error) {
 
 func (tmdb *TmDb) SearchMovies(query, lang string) ([]MovieShort, error) {
 	reqUrlFormat := "%s/search/movie?api_key=%s&query=%s&page=%d&language=%s"
-	reqUrl := fmt.Sprintf(reqUrlFormat, baseUrl, tmdb.apiKey, url.QueryEscape(query), 1, lang)
+	reqUrl := fmt.Sprintf(reqUrlFormat, tmdb.apiUrl, tmdb.apiKey, url.QueryEscape(query), 1, lang)
 	result := MovieSearchResult{}
 	_, err := tmdb.request(reqUrl, &result)
 	all := make([]MovieShort, 0, result.TotalResults)
@@ -152,7 +226,7 @@ func (tmdb *TmDb) SearchMovies(query, lang string) ([]MovieShort, error) {
 	totalPages := result.TotalPages
 	if totalPages > 1 {
 		for page := 2; err == nil && page <= totalPages; page++ {
-			reqUrl = fmt.Sprintf(reqUrlFormat, baseUrl, tmdb.apiKey, url.QueryEscape(query), page, lang)
+			reqUrl = fmt.Sprintf(reqUrlFormat, tmdb.apiUrl, tmdb.apiKey, url.QueryEscape(query), page, lang)
 			_, err = tmdb.request(reqUrl, &result)
 			for _, m := range result.Results {
 				all = append(all, m)
@@ -162,8 +236,11 @@ func (tmdb *TmDb) SearchMovies(query, lang string) ([]MovieShort, error) {
 	return all, err
 }
 
+// GetMovie returns details of movie together with its cast and crew, videos and release dates in all countries.
+// Videos in requested language and videos without language are included.
 func (tmdb *TmDb) GetMovie(id int, lang string) (MovieDetails, error) {
-	reqUrl := fmt.Sprintf("%s/movie/%d?api_key=%s&language=%s", baseUrl, id, tmdb.apiKey, lang)
+	reqUrl := fmt.Sprintf("%s/movie/%d?api_key=%s&language=%s&append_to_response=credits,videos,release_dates&include_video_language=%s,null",
+		tmdb.apiUrl, id, tmdb.apiKey, lang, lang)
 	mov := MovieDetails{}
 	_, err := tmdb.request(reqUrl, &mov)
 	if err != nil {
@@ -174,6 +251,17 @@ func (tmdb *TmDb) GetMovie(id int, lang string) (MovieDetails, error) {
 	return mov, err
 }
 
+// GetCollection returns collection together with all its parts.
+func (tmdb *TmDb) GetCollection(id int, lang string) (Collection, error) {
+	reqUrl := fmt.Sprintf("%s/collection/%d?api_key=%s&language=%s", tmdb.apiUrl, id, tmdb.apiKey, lang)
+	collection := Collection{}
+	_, err := tmdb.request(reqUrl, &collection)
+	if err != nil {
+		log.WithFields(log.Fields{"collection_id": id, "lang": lang, "err": err}).Error("Error occurred while retrieving collection from TMDb")
+	}
+	return collection, err
+}
+
 func (tmdb *TmDb) request(reqUrl string, payload interface{}) (interface{}, error) {
 	tmdb.mu.Lock()
 	defer tmdb.mu.Unlock()
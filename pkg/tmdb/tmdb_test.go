@@ -88,7 +88,8 @@ func TestSearchMovie(t *testing.T) {
 
 func TestGetMovie(t *testing.T) {
 	lang := "en"
-	expectedReqUrl := fmt.Sprintf("%s/movie/%d?api_key=%s&language=%s", baseUrl, 123, fakeApiKey, lang)
+	expectedReqUrl := fmt.Sprintf("%s/movie/%d?api_key=%s&language=%s&append_to_response=credits,videos,release_dates&include_video_language=%s,null",
+		baseUrl, 123, fakeApiKey, lang, lang)
 	doGetFunc = func(reqUrl string) (*http.Response, error) {
 		if reqUrl == expectedReqUrl {
 			resp := http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(movieDetailsResponse))}
@@ -126,6 +127,24 @@ func TestGetMovie(t *testing.T) {
 		ProductionCountries: []Country{
 			{Code: "US", Name: "USA"},
 		},
+		Credits: Credits{
+			Cast: []Cast{
+				{Id: 2461, Name: "Mel Gibson", Character: "William Wallace", ProfilePath: "/gibson.jpg"},
+				{Id: 2462, Name: "Sophie Marceau", Character: "Princess Isabelle", Order: 1},
+			},
+			Crew: []Crew{
+				{Id: 2461, Name: "Mel Gibson", Department: "Directing", Job: "Director", ProfilePath: "/gibson.jpg"},
+				{Id: 2463, Name: "Randall Wallace", Department: "Writing", Job: "Screenplay"},
+			},
+		},
+		VoteAverage: 7.9,
+		VoteCount:   8400,
+		Videos: Videos{Results: []Video{
+			{Key: "1NJO0jxBtMo", Name: "Braveheart - Trailer", Site: "YouTube", Type: "Trailer", Language: "en"},
+		}},
+		ReleaseDates: ReleaseDates{Results: []CountryReleases{
+			{Country: "US", Releases: []Release{{Certification: "R", ReleaseDate: "1995-05-24T00:00:00.000Z", Type: TheatricalRelease}}},
+		}},
 	}
 	assert.Equal(t, expectedResult, result)
 }
@@ -247,7 +266,29 @@ const movieDetailsResponse = `
       "iso_3166_1": "US",
       "name": "USA"
     }
-  ]
+  ],
+  "vote_average": 7.9,
+  "vote_count": 8400,
+  "videos": {
+    "results": [
+      {"iso_639_1": "en", "key": "1NJO0jxBtMo", "name": "Braveheart - Trailer", "site": "YouTube", "type": "Trailer"}
+    ]
+  },
+  "release_dates": {
+    "results": [
+      {"iso_3166_1": "US", "release_dates": [{"certification": "R", "release_date": "1995-05-24T00:00:00.000Z", "type": 3}]}
+    ]
+  },
+  "credits": {
+    "cast": [
+      {"id": 2461, "name": "Mel Gibson", "character": "William Wallace", "order": 0, "profile_path": "\/gibson.jpg"},
+      {"id": 2462, "name": "Sophie Marceau", "character": "Princess Isabelle", "order": 1, "profile_path": null}
+    ],
+    "crew": [
+      {"id": 2461, "name": "Mel Gibson", "department": "Directing", "job": "Director", "profile_path": "\/gibson.jpg"},
+      {"id": 2463, "name": "Randall Wallace", "department": "Writing", "job": "Screenplay", "profile_path": null}
+    ]
+  }
 }`
 
 const genresResponse = `
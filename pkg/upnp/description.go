@@ -0,0 +1,102 @@
+package upnp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type deviceDescription struct {
+	URLBase string            `xml:"URLBase"`
+	Device  deviceDescElement `xml:"device"`
+}
+
+type deviceDescElement struct {
+	DeviceType   string               `xml:"deviceType"`
+	FriendlyName string               `xml:"friendlyName"`
+	UDN          string               `xml:"UDN"`
+	Services     []serviceDescElement `xml:"serviceList>service"`
+	Devices      []deviceDescElement  `xml:"deviceList>device"`
+}
+
+type serviceDescElement struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// FetchDevice reads description of MediaRenderer from location received in SSDP response.
+func FetchDevice(client *http.Client, location string) (d Device, err error) {
+	var resp *http.Response
+	if resp, err = client.Get(location); err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unable get device description from %s: %s", location, resp.Status)
+		return
+	}
+	return parseDescription(location, resp.Body)
+}
+
+func parseDescription(location string, r io.Reader) (d Device, err error) {
+	var desc deviceDescription
+	if err = xml.NewDecoder(r).Decode(&desc); err != nil {
+		return
+	}
+	base := location
+	if desc.URLBase != "" {
+		base = desc.URLBase
+	}
+	renderer, found := findRenderer(desc.Device)
+	if !found {
+		err = fmt.Errorf("%s is not media renderer", location)
+		return
+	}
+	d = Device{UDN: renderer.UDN, FriendlyName: renderer.FriendlyName, Location: location}
+	for _, s := range renderer.Services {
+		var u string
+		if u, err = resolveURL(base, s.ControlURL); err != nil {
+			return
+		}
+		switch s.ServiceType {
+		case AVTransportService:
+			d.AVTransportURL = u
+		case RenderingControlService:
+			d.RenderingControlURL = u
+		}
+	}
+	if d.AVTransportURL == "" {
+		err = fmt.Errorf("media renderer %s does not provide AVTransport service", d.FriendlyName)
+	}
+	return
+}
+
+func findRenderer(d deviceDescElement) (deviceDescElement, bool) {
+	if d.DeviceType == MediaRendererType {
+		return d, true
+	}
+	for _, child := range d.Devices {
+		if found, ok := findRenderer(child); ok {
+			return found, true
+		}
+	}
+	return deviceDescElement{}, false
+}
+
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
@@ -0,0 +1,77 @@
+package upnp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+var mimeTypes = map[string]string{
+	".avi": "video/x-msvideo",
+	".mkv": "video/x-matroska",
+	".mp4": "video/mp4",
+	".m4v": "video/mp4",
+}
+
+// Object is item or container of ContentDirectory.
+type Object struct {
+	Id         string
+	ParentId   string
+	Title      string
+	Container  bool
+	ChildCount int
+	// URL of media file, relative url is resolved against address of media server
+	URL       string
+	Size      int64
+	Duration  time.Duration
+	PosterURL string
+	Genres    []string
+}
+
+// MimeType guesses type of video file by its name.
+func MimeType(name string) string {
+	if mime, ok := mimeTypes[strings.ToLower(path.Ext(name))]; ok {
+		return mime
+	}
+	return "video/mpeg"
+}
+
+// DIDL creates DIDL-Lite document which describes objects of ContentDirectory.
+func DIDL(objects []Object) string {
+	var b bytes.Buffer
+	b.WriteString(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`)
+	for _, o := range objects {
+		if o.Container {
+			fmt.Fprintf(&b, `<container id="%s" parentID="%s" restricted="1" childCount="%d">`, escape(o.Id), escape(o.ParentId), o.ChildCount)
+			fmt.Fprintf(&b, `<dc:title>%s</dc:title><upnp:class>object.container.storageFolder</upnp:class></container>`, escape(o.Title))
+			continue
+		}
+		fmt.Fprintf(&b, `<item id="%s" parentID="%s" restricted="1">`, escape(o.Id), escape(o.ParentId))
+		fmt.Fprintf(&b, `<dc:title>%s</dc:title><upnp:class>object.item.videoItem.movie</upnp:class>`, escape(o.Title))
+		for _, g := range o.Genres {
+			fmt.Fprintf(&b, `<upnp:genre>%s</upnp:genre>`, escape(g))
+		}
+		if o.PosterURL != "" {
+			fmt.Fprintf(&b, `<upnp:albumArtURI>%s</upnp:albumArtURI>`, escape(o.PosterURL))
+		}
+		fmt.Fprintf(&b, `<res protocolInfo="http-get:*:%s:*"`, MimeType(o.URL))
+		if o.Size > 0 {
+			fmt.Fprintf(&b, ` size="%d"`, o.Size)
+		}
+		if o.Duration > 0 {
+			fmt.Fprintf(&b, ` duration="%s"`, FormatDuration(o.Duration))
+		}
+		fmt.Fprintf(&b, `>%s</res></item>`, escape(o.URL))
+	}
+	b.WriteString(`</DIDL-Lite>`)
+	return b.String()
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,284 @@
+package upnp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MediaServerType          = "urn:schemas-upnp-org:device:MediaServer:1"
+	ContentDirectoryService  = "urn:schemas-upnp-org:service:ContentDirectory:1"
+	ConnectionManagerService = "urn:schemas-upnp-org:service:ConnectionManager:1"
+)
+
+// RootId is id of root container of ContentDirectory.
+const RootId = "0"
+
+// Directory is content of media server.
+type Directory interface {
+	// Object returns object by id, false is returned if there is no object with such id
+	Object(id string) (Object, bool)
+	// Children returns content of container, false is returned if there is no container with such id
+	Children(id string) ([]Object, bool)
+}
+
+// MediaServer handles description and control requests of UPnP MediaServer with ContentDirectory service. Requests
+// are expected under prefix, e.g. "/upnp/".
+type MediaServer struct {
+	prefix string
+	udn    string
+	name   string
+	dir    Directory
+}
+
+func NewMediaServer(prefix, udn, name string, dir Directory) *MediaServer {
+	return &MediaServer{prefix: prefix, udn: udn, name: name, dir: dir}
+}
+
+// UDN is unique device name of media server.
+func (s *MediaServer) UDN() string {
+	return s.udn
+}
+
+// DescriptionPath is path of device description which is announced over SSDP.
+func (s *MediaServer) DescriptionPath() string {
+	return s.prefix + "description.xml"
+}
+
+func (s *MediaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, s.prefix) {
+	case "description.xml":
+		s.writeXML(w, s.description())
+	case "cd/scpd.xml":
+		s.writeXML(w, contentDirectorySCPD)
+	case "cm/scpd.xml":
+		s.writeXML(w, connectionManagerSCPD)
+	case "cd/control":
+		s.contentDirectory(w, r)
+	case "cm/control":
+		s.connectionManager(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *MediaServer) contentDirectory(w http.ResponseWriter, r *http.Request) {
+	action, args, err := readAction(r)
+	if err != nil {
+		writeFault(w, 402, err.Error())
+		return
+	}
+	switch action {
+	case "Browse":
+		s.browse(w, r, args)
+	case "GetSystemUpdateID":
+		writeResponse(w, ContentDirectoryService, action, Arg{"Id", "0"})
+	case "GetSearchCapabilities":
+		writeResponse(w, ContentDirectoryService, action, Arg{"SearchCaps", ""})
+	case "GetSortCapabilities":
+		writeResponse(w, ContentDirectoryService, action, Arg{"SortCaps", ""})
+	default:
+		writeFault(w, 401, "Invalid Action")
+	}
+}
+
+func (s *MediaServer) browse(w http.ResponseWriter, r *http.Request, args map[string]string) {
+	id := args["ObjectID"]
+	var objects []Object
+	var total int
+	switch args["BrowseFlag"] {
+	case "BrowseMetadata":
+		o, found := s.dir.Object(id)
+		if !found {
+			writeFault(w, 701, "No such object")
+			return
+		}
+		objects, total = []Object{o}, 1
+	case "BrowseDirectChildren":
+		children, found := s.dir.Children(id)
+		if !found {
+			writeFault(w, 701, "No such object")
+			return
+		}
+		total = len(children)
+		start, _ := strconv.Atoi(args["StartingIndex"])
+		count, _ := strconv.Atoi(args["RequestedCount"])
+		if start < 0 || start > total {
+			start = total
+		}
+		end := total
+		if count > 0 && start+count < total {
+			end = start + count
+		}
+		objects = children[start:end]
+	default:
+		writeFault(w, 402, "Invalid Args")
+		return
+	}
+	base := "http://" + r.Host
+	for i := range objects {
+		if objects[i].URL != "" && strings.HasPrefix(objects[i].URL, "/") {
+			objects[i].URL = base + objects[i].URL
+		}
+	}
+	writeResponse(w, ContentDirectoryService, "Browse",
+		Arg{"Result", DIDL(objects)},
+		Arg{"NumberReturned", strconv.Itoa(len(objects))},
+		Arg{"TotalMatches", strconv.Itoa(total)},
+		Arg{"UpdateID", "0"})
+}
+
+func (s *MediaServer) connectionManager(w http.ResponseWriter, r *http.Request) {
+	action, _, err := readAction(r)
+	if err != nil {
+		writeFault(w, 402, err.Error())
+		return
+	}
+	switch action {
+	case "GetProtocolInfo":
+		protocols := make([]string, 0, len(mimeTypes))
+		for _, mime := range mimeTypes {
+			protocols = append(protocols, fmt.Sprintf("http-get:*:%s:*", mime))
+		}
+		sort.Strings(protocols)
+		writeResponse(w, ConnectionManagerService, action, Arg{"Source", strings.Join(protocols, ",")}, Arg{"Sink", ""})
+	case "GetCurrentConnectionIDs":
+		writeResponse(w, ConnectionManagerService, action, Arg{"ConnectionIDs", "0"})
+	default:
+		writeFault(w, 401, "Invalid Action")
+	}
+}
+
+func (s *MediaServer) description() string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <deviceType>%s</deviceType>
+    <friendlyName>%s</friendlyName>
+    <manufacturer>gomovies</manufacturer>
+    <modelName>gomovies</modelName>
+    <UDN>%s</UDN>
+    <serviceList>
+      <service>
+        <serviceType>%s</serviceType>
+        <serviceId>urn:upnp-org:serviceId:ContentDirectory</serviceId>
+        <SCPDURL>%scd/scpd.xml</SCPDURL>
+        <controlURL>%scd/control</controlURL>
+        <eventSubURL>%scd/event</eventSubURL>
+      </service>
+      <service>
+        <serviceType>%s</serviceType>
+        <serviceId>urn:upnp-org:serviceId:ConnectionManager</serviceId>
+        <SCPDURL>%scm/scpd.xml</SCPDURL>
+        <controlURL>%scm/control</controlURL>
+        <eventSubURL>%scm/event</eventSubURL>
+      </service>
+    </serviceList>
+  </device>
+</root>`, MediaServerType, escape(s.name), s.udn,
+		ContentDirectoryService, s.prefix, s.prefix, s.prefix,
+		ConnectionManagerService, s.prefix, s.prefix, s.prefix)
+}
+
+func (s *MediaServer) writeXML(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	if _, err := io.WriteString(w, content); err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error occurred while write response")
+	}
+}
+
+func readAction(r *http.Request) (action string, args map[string]string, err error) {
+	soapAction := strings.Trim(r.Header.Get("SOAPAction"), `"`)
+	if i := strings.LastIndex(soapAction, "#"); i >= 0 {
+		action = soapAction[i+1:]
+	}
+	args, _, err = parseEnvelope(r.Body)
+	return
+}
+
+const contentDirectorySCPD = `<?xml version="1.0" encoding="utf-8"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <actionList>
+    <action>
+      <name>Browse</name>
+      <argumentList>
+        <argument><name>ObjectID</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_ObjectID</relatedStateVariable></argument>
+        <argument><name>BrowseFlag</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_BrowseFlag</relatedStateVariable></argument>
+        <argument><name>Filter</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Filter</relatedStateVariable></argument>
+        <argument><name>StartingIndex</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Index</relatedStateVariable></argument>
+        <argument><name>RequestedCount</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Count</relatedStateVariable></argument>
+        <argument><name>SortCriteria</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_SortCriteria</relatedStateVariable></argument>
+        <argument><name>Result</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Result</relatedStateVariable></argument>
+        <argument><name>NumberReturned</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Count</relatedStateVariable></argument>
+        <argument><name>TotalMatches</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_Count</relatedStateVariable></argument>
+        <argument><name>UpdateID</name><direction>out</direction><relatedStateVariable>A_ARG_TYPE_UpdateID</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+    <action>
+      <name>GetSystemUpdateID</name>
+      <argumentList>
+        <argument><name>Id</name><direction>out</direction><relatedStateVariable>SystemUpdateID</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+    <action>
+      <name>GetSearchCapabilities</name>
+      <argumentList>
+        <argument><name>SearchCaps</name><direction>out</direction><relatedStateVariable>SearchCapabilities</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+    <action>
+      <name>GetSortCapabilities</name>
+      <argumentList>
+        <argument><name>SortCaps</name><direction>out</direction><relatedStateVariable>SortCapabilities</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+  </actionList>
+  <serviceStateTable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_ObjectID</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Result</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_BrowseFlag</name><dataType>string</dataType>
+      <allowedValueList><allowedValue>BrowseMetadata</allowedValue><allowedValue>BrowseDirectChildren</allowedValue></allowedValueList>
+    </stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Filter</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_SortCriteria</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Index</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Count</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_UpdateID</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="yes"><name>SystemUpdateID</name><dataType>ui4</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>SearchCapabilities</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="no"><name>SortCapabilities</name><dataType>string</dataType></stateVariable>
+  </serviceStateTable>
+</scpd>`
+
+const connectionManagerSCPD = `<?xml version="1.0" encoding="utf-8"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <actionList>
+    <action>
+      <name>GetProtocolInfo</name>
+      <argumentList>
+        <argument><name>Source</name><direction>out</direction><relatedStateVariable>SourceProtocolInfo</relatedStateVariable></argument>
+        <argument><name>Sink</name><direction>out</direction><relatedStateVariable>SinkProtocolInfo</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+    <action>
+      <name>GetCurrentConnectionIDs</name>
+      <argumentList>
+        <argument><name>ConnectionIDs</name><direction>out</direction><relatedStateVariable>CurrentConnectionIDs</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+  </actionList>
+  <serviceStateTable>
+    <stateVariable sendEvents="yes"><name>SourceProtocolInfo</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="yes"><name>SinkProtocolInfo</name><dataType>string</dataType></stateVariable>
+    <stateVariable sendEvents="yes"><name>CurrentConnectionIDs</name><dataType>string</dataType></stateVariable>
+  </serviceStateTable>
+</scpd>`
@@ -0,0 +1,120 @@
+package upnp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type Arg struct {
+	Name  string
+	Value string
+}
+
+// Call invokes action of UPnP service and returns output arguments of action.
+func Call(client *http.Client, controlURL, serviceType, action string, args ...Arg) (out map[string]string, err error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	body.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, serviceType)
+	for _, a := range args {
+		fmt.Fprintf(&body, "<%s>", a.Name)
+		if err = xml.EscapeText(&body, []byte(a.Value)); err != nil {
+			return
+		}
+		fmt.Fprintf(&body, "</%s>", a.Name)
+	}
+	fmt.Fprintf(&body, `</u:%s></s:Body></s:Envelope>`, action)
+
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, controlURL, &body); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, serviceType, action))
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		if clsErr := resp.Body.Close(); clsErr != nil && err == nil {
+			err = clsErr
+		}
+	}()
+	var fault string
+	if out, fault, err = parseEnvelope(resp.Body); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		if fault == "" {
+			fault = resp.Status
+		}
+		err = fmt.Errorf("%s failed: %s", action, fault)
+	}
+	return
+}
+
+// parseEnvelope collects elements of SOAP envelope with text content. Description of UPnP error is returned as fault
+// if body contains fault.
+func parseEnvelope(r io.Reader) (out map[string]string, fault string, err error) {
+	out = make(map[string]string)
+	decoder := xml.NewDecoder(r)
+	var text []byte
+	inFault := false
+	for {
+		var t xml.Token
+		if t, err = decoder.Token(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			text = text[:0]
+			if e.Name.Local == "Fault" {
+				inFault = true
+			}
+		case xml.CharData:
+			text = append(text, e...)
+		case xml.EndElement:
+			if len(bytes.TrimSpace(text)) > 0 || out[e.Name.Local] == "" {
+				out[e.Name.Local] = string(text)
+			}
+			text = text[:0]
+		}
+	}
+	if inFault {
+		fault = out["errorDescription"]
+		if fault == "" {
+			fault = out["faultstring"]
+		}
+		if code := out["errorCode"]; code != "" {
+			fault = fmt.Sprintf("%s (UPnP error %s)", fault, code)
+		}
+	}
+	return
+}
+
+func writeResponse(w http.ResponseWriter, serviceType, action string, args ...Arg) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`)
+	fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(w, `<u:%sResponse xmlns:u="%s">`, action, serviceType)
+	for _, a := range args {
+		fmt.Fprintf(w, "<%s>%s</%s>", a.Name, escape(a.Value), a.Name)
+	}
+	fmt.Fprintf(w, `</u:%sResponse></s:Body></s:Envelope>`, action)
+}
+
+func writeFault(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`)
+	fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(w, `<s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`)
+	fmt.Fprintf(w, `<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode><errorDescription>%s</errorDescription></UPnPError>`, code, escape(description))
+	fmt.Fprintf(w, `</detail></s:Fault></s:Body></s:Envelope>`)
+}
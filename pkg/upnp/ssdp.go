@@ -0,0 +1,85 @@
+package upnp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const ssdpAddr = "239.255.255.250:1900"
+
+var searchRequest = "M-SEARCH * HTTP/1.1\r\n" +
+	"HOST: " + ssdpAddr + "\r\n" +
+	"MAN: \"ssdp:discover\"\r\n" +
+	"MX: 2\r\n" +
+	"ST: " + MediaRendererType + "\r\n\r\n"
+
+// Discover searches MediaRenderers in local network with SSDP. Renderers which respond within timeout are returned.
+func Discover(client *http.Client, timeout time.Duration) (devices []Device, err error) {
+	var conn net.PacketConn
+	if conn, err = net.ListenPacket("udp4", ":0"); err != nil {
+		return
+	}
+	defer conn.Close()
+	var addr *net.UDPAddr
+	if addr, err = net.ResolveUDPAddr("udp4", ssdpAddr); err != nil {
+		return
+	}
+	if _, err = conn.WriteTo([]byte(searchRequest), addr); err != nil {
+		return
+	}
+	if err = conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return
+	}
+	var locations []string
+	seen := make(map[string]bool)
+	buf := make([]byte, 8192)
+	for {
+		n, _, readErr := conn.ReadFrom(buf)
+		if readErr != nil {
+			if netErr, ok := readErr.(net.Error); !ok || !netErr.Timeout() {
+				err = readErr
+				return
+			}
+			break
+		}
+		location, parseErr := parseSearchResponse(buf[:n])
+		if parseErr != nil {
+			log.WithFields(log.Fields{"err": parseErr}).Debug("Skip invalid SSDP response")
+			continue
+		}
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	for _, location := range locations {
+		d, fetchErr := FetchDevice(client, location)
+		if fetchErr != nil {
+			log.WithFields(log.Fields{"location": location, "err": fetchErr}).Warn("Unable get description of media renderer")
+			continue
+		}
+		devices = append(devices, d)
+	}
+	return
+}
+
+func parseSearchResponse(data []byte) (location string, err error) {
+	var resp *http.Response
+	if resp, err = http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = errors.New(resp.Status)
+		return
+	}
+	if location = resp.Header.Get("Location"); location == "" {
+		err = errors.New("no location in SSDP response")
+	}
+	return
+}
@@ -0,0 +1,151 @@
+package upnp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ssdpMaxAge         = 1800
+	ssdpNotifyInterval = 15 * time.Minute
+	ssdpServer         = "Linux UPnP/1.0 gomovies/1.0"
+)
+
+// Advertiser announces MediaServer over SSDP and answers search requests of control points.
+type Advertiser struct {
+	udn      string
+	location func(ip net.IP) string
+	conn     *net.UDPConn
+	group    *net.UDPAddr
+	done     chan struct{}
+}
+
+// Advertise starts announcement of MediaServer. Location of device description is built for address of network
+// interface through which control point is reachable.
+func Advertise(udn string, location func(ip net.IP) string) (*Advertiser, error) {
+	group, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return nil, err
+	}
+	a := &Advertiser{udn: udn, location: location, conn: conn, group: group, done: make(chan struct{})}
+	go a.listen()
+	go a.notifyPeriodically()
+	return a, nil
+}
+
+// Close sends byebye notifications and stops advertisement.
+func (a *Advertiser) Close() error {
+	close(a.done)
+	a.notify("ssdp:byebye")
+	return a.conn.Close()
+}
+
+func (a *Advertiser) notificationTypes() []string {
+	return []string{"upnp:rootdevice", a.udn, MediaServerType, ContentDirectoryService, ConnectionManagerService}
+}
+
+func (a *Advertiser) usn(nt string) string {
+	if nt == a.udn {
+		return a.udn
+	}
+	return a.udn + "::" + nt
+}
+
+func (a *Advertiser) listen() {
+	buf := make([]byte, 8192)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-a.done:
+			default:
+				log.WithFields(log.Fields{"err": err}).Error("SSDP listener stopped")
+			}
+			return
+		}
+		for _, st := range a.searchTargets(buf[:n]) {
+			a.reply(from, st)
+		}
+	}
+}
+
+// searchTargets returns notification types which should be sent in response to search request.
+func (a *Advertiser) searchTargets(data []byte) []string {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil || req.Method != "M-SEARCH" || req.Header.Get("MAN") != `"ssdp:discover"` {
+		return nil
+	}
+	st := req.Header.Get("ST")
+	if st == "ssdp:all" {
+		return a.notificationTypes()
+	}
+	for _, nt := range a.notificationTypes() {
+		if nt == st {
+			return []string{st}
+		}
+	}
+	return nil
+}
+
+func (a *Advertiser) reply(to *net.UDPAddr, st string) {
+	ip, err := localIP(to.String())
+	if err != nil {
+		log.WithFields(log.Fields{"to": to, "err": err}).Warn("Unable reply to SSDP search")
+		return
+	}
+	msg := fmt.Sprintf("HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=%d\r\nEXT:\r\nLOCATION: %s\r\nSERVER: %s\r\nST: %s\r\nUSN: %s\r\n\r\n",
+		ssdpMaxAge, a.location(ip), ssdpServer, st, a.usn(st))
+	if _, err = a.conn.WriteToUDP([]byte(msg), to); err != nil {
+		log.WithFields(log.Fields{"to": to, "err": err}).Warn("Unable reply to SSDP search")
+	}
+}
+
+func (a *Advertiser) notifyPeriodically() {
+	a.notify("ssdp:alive")
+	ticker := time.NewTicker(ssdpNotifyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.notify("ssdp:alive")
+		case <-a.done:
+			return
+		}
+	}
+}
+
+func (a *Advertiser) notify(nts string) {
+	ip, err := localIP(ssdpAddr)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Warn("Unable send SSDP notification")
+		return
+	}
+	for _, nt := range a.notificationTypes() {
+		msg := fmt.Sprintf("NOTIFY * HTTP/1.1\r\nHOST: %s\r\nCACHE-CONTROL: max-age=%d\r\nLOCATION: %s\r\nNT: %s\r\nNTS: %s\r\nSERVER: %s\r\nUSN: %s\r\n\r\n",
+			ssdpAddr, ssdpMaxAge, a.location(ip), nt, nts, ssdpServer, a.usn(nt))
+		if _, err = a.conn.WriteToUDP([]byte(msg), a.group); err != nil {
+			log.WithFields(log.Fields{"err": err}).Warn("Unable send SSDP notification")
+			return
+		}
+	}
+}
+
+// localIP returns address of network interface which is used to reach specified address.
+func localIP(addr string) (net.IP, error) {
+	conn, err := net.Dial("udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
@@ -0,0 +1,53 @@
+package upnp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MediaRendererType       = "urn:schemas-upnp-org:device:MediaRenderer:1"
+	AVTransportService      = "urn:schemas-upnp-org:service:AVTransport:1"
+	RenderingControlService = "urn:schemas-upnp-org:service:RenderingControl:1"
+)
+
+// Device is UPnP MediaRenderer with control urls of services needed to play media on it.
+type Device struct {
+	UDN                 string
+	FriendlyName        string
+	Location            string
+	AVTransportURL      string
+	RenderingControlURL string
+}
+
+// FormatDuration formats duration as H+:MM:SS which is used by AVTransport service.
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// ParseDuration parses duration in format H+:MM:SS[.F+], fraction of second is ignored. Zero is returned for values
+// like NOT_IMPLEMENTED which renderers return when duration is unknown.
+func ParseDuration(s string) time.Duration {
+	if i := strings.Index(s, "."); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	var seconds int
+	for _, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + v
+	}
+	return time.Duration(seconds) * time.Second
+}
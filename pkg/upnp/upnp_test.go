@@ -0,0 +1,144 @@
+package upnp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrew00x/gomovies/pkg/upnp/upnptest"
+)
+
+func TestFetchDevice(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+
+	d, err := FetchDevice(http.DefaultClient, r.Location())
+
+	assert.Nil(t, err)
+	expected := Device{
+		UDN:                 "uuid:fake-tv",
+		FriendlyName:        "Fake TV",
+		Location:            r.Location(),
+		AVTransportURL:      r.URL + "/avt",
+		RenderingControlURL: r.URL + "/rc",
+	}
+	assert.Equal(t, expected, d)
+}
+
+func TestCallAction(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+
+	_, err := Call(http.DefaultClient, r.URL+"/avt", AVTransportService, "SetAVTransportURI",
+		Arg{"InstanceID", "0"}, Arg{"CurrentURI", "http://192.168.0.10:8000/file/movies/a&b.mkv"}, Arg{"CurrentURIMetaData", ""})
+	assert.Nil(t, err)
+	out, err := Call(http.DefaultClient, r.URL+"/avt", AVTransportService, "GetPositionInfo", Arg{"InstanceID", "0"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://192.168.0.10:8000/file/movies/a&b.mkv", out["TrackURI"])
+	assert.Equal(t, "1:40:00", out["TrackDuration"])
+}
+
+func TestCallActionFault(t *testing.T) {
+	r := upnptest.NewRenderer()
+	defer r.Close()
+
+	_, err := Call(http.DefaultClient, r.URL+"/avt", AVTransportService, "Record", Arg{"InstanceID", "0"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "Record failed: Invalid Action (UPnP error 401)", err.Error())
+}
+
+func TestParseSearchResponse(t *testing.T) {
+	data := "HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=1800\r\nLOCATION: http://192.168.0.30:1400/xml/device_description.xml\r\nST: urn:schemas-upnp-org:device:MediaRenderer:1\r\n\r\n"
+
+	location, err := parseSearchResponse([]byte(data))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://192.168.0.30:1400/xml/device_description.xml", location)
+}
+
+func TestDurationFormat(t *testing.T) {
+	assert.Equal(t, "1:02:03", FormatDuration(time.Duration(3723)*time.Second))
+	assert.Equal(t, time.Duration(3723)*time.Second, ParseDuration("1:02:03.500"))
+	assert.Equal(t, time.Duration(0), ParseDuration("NOT_IMPLEMENTED"))
+}
+
+type testDirectory map[string][]Object
+
+func (d testDirectory) Object(id string) (Object, bool) {
+	for _, children := range d {
+		for _, o := range children {
+			if o.Id == id {
+				return o, true
+			}
+		}
+	}
+	return Object{}, false
+}
+
+func (d testDirectory) Children(id string) ([]Object, bool) {
+	children, ok := d[id]
+	return children, ok
+}
+
+func TestBrowseMediaServer(t *testing.T) {
+	dir := testDirectory{
+		RootId: {{Id: "all", ParentId: RootId, Title: "All movies", Container: true, ChildCount: 2}},
+		"all": {
+			{Id: "all/1", ParentId: "all", Title: "Brave", URL: "/file/movies/brave.mkv"},
+			{Id: "all/2", ParentId: "all", Title: "Tom & Jerry", URL: "/file/movies/tom.mkv"},
+		},
+	}
+	srv := httptest.NewServer(NewMediaServer("/upnp/", "uuid:gomovies", "gomovies", dir))
+	defer srv.Close()
+
+	out, err := Call(http.DefaultClient, srv.URL+"/upnp/cd/control", ContentDirectoryService, "Browse",
+		Arg{"ObjectID", "all"}, Arg{"BrowseFlag", "BrowseDirectChildren"}, Arg{"Filter", "*"},
+		Arg{"StartingIndex", "1"}, Arg{"RequestedCount", "10"}, Arg{"SortCriteria", ""})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "1", out["NumberReturned"])
+	assert.Equal(t, "2", out["TotalMatches"])
+	assert.Contains(t, out["Result"], `<item id="all/2" parentID="all" restricted="1"><dc:title>Tom &amp; Jerry</dc:title>`)
+	assert.Contains(t, out["Result"], `>`+srv.URL+`/file/movies/tom.mkv</res>`)
+}
+
+func TestBrowseUnknownObject(t *testing.T) {
+	srv := httptest.NewServer(NewMediaServer("/upnp/", "uuid:gomovies", "gomovies", testDirectory{}))
+	defer srv.Close()
+
+	_, err := Call(http.DefaultClient, srv.URL+"/upnp/cd/control", ContentDirectoryService, "Browse",
+		Arg{"ObjectID", "all/1"}, Arg{"BrowseFlag", "BrowseMetadata"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "Browse failed: No such object (UPnP error 701)", err.Error())
+}
+
+func TestMediaServerDescription(t *testing.T) {
+	srv := httptest.NewServer(NewMediaServer("/upnp/", "uuid:gomovies", "gomovies", testDirectory{}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/upnp/description.xml")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "<controlURL>/upnp/cd/control</controlURL>")
+	assert.Contains(t, string(body), "<UDN>uuid:gomovies</UDN>")
+}
+
+func TestSearchTargets(t *testing.T) {
+	a := &Advertiser{udn: "uuid:gomovies"}
+	search := func(st string) []byte {
+		return []byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nMX: 2\r\nST: " + st + "\r\n\r\n")
+	}
+
+	assert.Equal(t, []string{ContentDirectoryService}, a.searchTargets(search(ContentDirectoryService)))
+	assert.Equal(t, 5, len(a.searchTargets(search("ssdp:all"))))
+	assert.Empty(t, a.searchTargets(search(MediaRendererType)))
+	assert.Empty(t, a.searchTargets([]byte("NOTIFY * HTTP/1.1\r\nNT: upnp:rootdevice\r\n\r\n")))
+}
@@ -0,0 +1,148 @@
+// Package upnptest provides fake UPnP MediaRenderer for tests.
+package upnptest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const description = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>Fake TV</friendlyName>
+    <UDN>uuid:fake-tv</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+        <controlURL>/avt</controlURL>
+      </service>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+        <controlURL>/rc</controlURL>
+      </service>
+    </serviceList>
+  </device>
+</root>`
+
+// Renderer is MediaRenderer which keeps state of transport and records invoked actions.
+type Renderer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	actions  []string
+	URI      string
+	State    string
+	Position string
+	Duration string
+	Volume   int
+	Muted    bool
+}
+
+func NewRenderer() *Renderer {
+	r := &Renderer{State: "NO_MEDIA_PRESENT", Position: "0:00:00", Duration: "1:40:00", Volume: 50}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/description.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, description)
+	})
+	mux.HandleFunc("/avt", r.control)
+	mux.HandleFunc("/rc", r.control)
+	r.Server = httptest.NewServer(mux)
+	return r
+}
+
+func (r *Renderer) Location() string {
+	return r.URL + "/description.xml"
+}
+
+// Actions returns names of invoked actions.
+func (r *Renderer) Actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.actions...)
+}
+
+func (r *Renderer) control(w http.ResponseWriter, req *http.Request) {
+	soapAction := strings.Trim(req.Header.Get("SOAPAction"), `"`)
+	action := soapAction[strings.Index(soapAction, "#")+1:]
+	args, err := readArgs(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = append(r.actions, action)
+	out := map[string]string{}
+	switch action {
+	case "SetAVTransportURI":
+		r.URI = args["CurrentURI"]
+		r.State = "STOPPED"
+		r.Position = "0:00:00"
+	case "Play":
+		r.State = "PLAYING"
+	case "Pause":
+		r.State = "PAUSED_PLAYBACK"
+	case "Stop":
+		r.State = "STOPPED"
+	case "Seek":
+		r.Position = args["Target"]
+	case "GetTransportInfo":
+		out["CurrentTransportState"] = r.State
+	case "GetPositionInfo":
+		out["TrackURI"] = r.URI
+		out["TrackDuration"] = r.Duration
+		out["RelTime"] = r.Position
+	case "GetVolume":
+		out["CurrentVolume"] = strconv.Itoa(r.Volume)
+	case "SetVolume":
+		r.Volume, _ = strconv.Atoi(args["DesiredVolume"])
+	case "GetMute":
+		out["CurrentMute"] = "0"
+		if r.Muted {
+			out["CurrentMute"] = "1"
+		}
+	case "SetMute":
+		r.Muted = args["DesiredMute"] == "1"
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><faultstring>UPnPError</faultstring><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>401</errorCode><errorDescription>Invalid Action</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+		return
+	}
+	fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse xmlns:u="urn:schemas-upnp-org:service">`, action)
+	for k, v := range out {
+		fmt.Fprintf(w, "<%s>", k)
+		_ = xml.EscapeText(w, []byte(v))
+		fmt.Fprintf(w, "</%s>", k)
+	}
+	fmt.Fprintf(w, `</u:%sResponse></s:Body></s:Envelope>`, action)
+}
+
+func readArgs(body io.Reader) (map[string]string, error) {
+	args := make(map[string]string)
+	decoder := xml.NewDecoder(body)
+	var name string
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			return args, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			name = e.Name.Local
+		case xml.CharData:
+			if name != "" {
+				args[name] += string(e)
+			}
+		case xml.EndElement:
+			name = ""
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -83,3 +84,14 @@ func TestDeleteItem(t *testing.T) {
 	_, found := m[k]
 	assert.False(t, found)
 }
+
+func TestRateLimiterSpacesCalls(t *testing.T) {
+	l := CreateRateLimiter(20 * time.Millisecond)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		l.Wait()
+	}
+
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+}
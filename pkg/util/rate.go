@@ -0,0 +1,32 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces calls at least interval apart.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func CreateRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{interval: interval}
+}
+
+// Wait blocks until the next call is allowed.
+func (l *RateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	l.next = now.Add(wait + l.interval)
+	l.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}